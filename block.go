@@ -0,0 +1,215 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:31:50 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"fmt"
+	"hash/fnv"
+	"log"
+	"slices"
+	"strings"
+)
+
+// Blocking strategies supported by the block flag
+var blockings = []string{"token", "minhash", "length"}
+
+// Tokens shared by more rules than this are too common to narrow down candidates, and do not form blocks
+const maxTokenBlock = 200
+
+// MinHash signature layout, rules sharing all rows of any band are placed in the same block
+const (
+	minhashBands = 16
+	minhashRows  = 4
+)
+
+// Merge rules with identical expression groups in the slots named by slots, any combination of P, R, and S
+// rules are grouped by a hash of their matched slots in a single pass, rather than sorting and comparing neighbours
+// rules rejected by a guard are kept separately, and are not merged with later rules of their group
+// this gives the same rules as MergePR, MergePS, MergeRS, and MergeP with literal matching, and for R and S also merges equal slots which do not sort next to each other
+// rules are left in the order each group first appears rather than sorted, as every merge function sorts its input
+func HashMerge(slots string) MergeFunction {
+	name := fmt.Sprintf("HashMerge%s", slots)
+
+	return func(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
+		var (
+			groups = make(map[string]int)
+			out    = r[:0]
+		)
+
+		for i := range r {
+			k := slotKey(r[i], slots)
+			ind, ok := groups[k]
+			if !ok {
+				groups[k] = len(out)
+				out = append(out, r[i])
+				continue
+			}
+			rule := Rule{
+				pre:      unionSlot([]Rule{out[ind], r[i]}, func(r Rule) []string { return r.pre }),
+				root:     unionSlot([]Rule{out[ind], r[i]}, func(r Rule) []string { return r.root }),
+				suf:      unionSlot([]Rule{out[ind], r[i]}, func(r Rule) []string { return r.suf }),
+				isPublic: true,
+				lines:    unionLines(out[ind], r[i]),
+			}
+			if strings.ContainsRune(slots, 'P') {
+				rule.pre = out[ind].pre
+			}
+			if strings.ContainsRune(slots, 'R') {
+				rule.root = out[ind].root
+			}
+			if strings.ContainsRune(slots, 'S') {
+				rule.suf = out[ind].suf
+			}
+			if !allowMerge(g, out[ind], r[i], rule) {
+				out = append(out, r[i])
+				continue
+			}
+			l.Printf("merge function %s replaced %v and %v with new rule %v\n", name, out[ind], r[i], rule)
+			out[ind] = rule
+		}
+
+		return out
+	}
+}
+
+// Helper function to build a lookup key from the matched slots of a rule
+func slotKey(r Rule, slots string) string {
+	var b strings.Builder
+
+	for _, s := range slots {
+		var g []string
+		switch s {
+		case 'P':
+			g = r.pre
+		case 'R':
+			g = r.root
+		case 'S':
+			g = r.suf
+		}
+		for i := range g {
+			b.WriteString(g[i])
+			b.WriteByte(0)
+		}
+		b.WriteByte(1)
+	}
+
+	return b.String()
+}
+
+// Helper function to collect groups of indices with 2 or more members, in sorted order
+func collectBlocks(m map[string][]int) [][]int {
+	var out [][]int
+
+	for _, b := range m {
+		if len(b) > 1 {
+			out = append(out, b)
+		}
+	}
+	slices.SortFunc(out, slices.Compare)
+
+	return out
+}
+
+// Places rules sharing a token in the same block, tokens shared by more than maxTokenBlock rules are skipped
+func TokenBlocking(tok Tokenizer) BlockingFunction {
+	return func(keys []string) [][]int {
+		m := make(map[string][]int)
+
+		for i := range keys {
+			tokens := tok.tokenize(strings.ToLower(keys[i]))
+			slices.Sort(tokens)
+			for _, t := range slices.Compact(tokens) {
+				m[t] = append(m[t], i)
+			}
+		}
+		for k := range m {
+			if len(m[k]) > maxTokenBlock {
+				delete(m, k)
+			}
+		}
+
+		return collectBlocks(m)
+	}
+}
+
+// Places rules whose token counts differ by at most d in the same block
+func LengthBlocking(tok Tokenizer, d int) BlockingFunction {
+	return func(keys []string) [][]int {
+		var (
+			buckets = make(map[int][]int)
+			longest int
+			out     [][]int
+		)
+
+		for i := range keys {
+			n := len(tok.tokenize(keys[i]))
+			buckets[n] = append(buckets[n], i)
+			longest = max(longest, n)
+		}
+		for n := 0; n <= longest; n++ {
+			var b []int
+			for m := n; m <= n+d; m++ {
+				b = append(b, buckets[m]...)
+			}
+			if len(buckets[n]) > 0 && len(b) > 1 {
+				slices.Sort(b)
+				out = append(out, b)
+			}
+		}
+
+		return out
+	}
+}
+
+// Helper function to mix the bits of a 64 bit hash, from the splitmix64 generator
+func mix64(x uint64) uint64 {
+	x ^= x >> 30
+	x *= 0xbf58476d1ce4e5b9
+	x ^= x >> 27
+	x *= 0x94d049bb133111eb
+	x ^= x >> 31
+
+	return x
+}
+
+// Calculates the MinHash signature of a set of shingles with one hash function per row of each band
+func minhashSignature(shingles []string, n int) []uint64 {
+	sig := make([]uint64, n)
+	for i := range sig {
+		sig[i] = ^uint64(0)
+	}
+
+	for _, s := range shingles {
+		h := fnv.New64a()
+		h.Write([]byte(s))
+		base := h.Sum64()
+		for i := range sig {
+			sig[i] = min(sig[i], mix64(base^mix64(uint64(i+1))))
+		}
+	}
+
+	return sig
+}
+
+// Places rules in the same block if their MinHash signatures over character n-grams agree on all rows of any band
+// rules with higher jaccard similarity between their n-gram sets are more likely to share a block
+func MinHashBlocking(n int) BlockingFunction {
+	return func(keys []string) [][]int {
+		m := make(map[string][]int)
+
+		for i := range keys {
+			shingles := CharacterNGrams(strings.ToLower(keys[i]), n)
+			slices.Sort(shingles)
+			sig := minhashSignature(slices.Compact(shingles), minhashBands*minhashRows)
+			for b := range minhashBands {
+				k := fmt.Sprint(b, sig[b*minhashRows:(b+1)*minhashRows])
+				m[k] = append(m[k], i)
+			}
+		}
+
+		return collectBlocks(m)
+	}
+}
@@ -0,0 +1,192 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:31:50 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Reads and chunks a csv file into rules, as done by interpolate
+func testRules(f string) []Rule {
+	file, _ := os.Open(f)
+	defer file.Close()
+
+	tk := NewWordTokenizer()
+	tx := ReadTexts(bufio.NewScanner(file))
+	for i, t := range tx {
+		tx[i].text = tk.normalize(t.text)
+	}
+	tr := CollectTransitions(tx, TokenSplit(tk), 1)
+	for i, t := range tx {
+		tokens := tk.tokenize(t.text)
+		tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
+	}
+	ng := CollectChunks(tx)
+	rules := []Rule{}
+	for _, t := range tx {
+		rules = append(rules, ToRule(ToTriplet(t, ng)))
+	}
+
+	return rules
+}
+
+// Rules from the 20k utterance Bitext sample
+func benchmarkRules(b *testing.B) []Rule {
+	rules := testRules("./data/20000-Utterances-Training-dataset-for-chatbots-virtual-assistant-Bitext-sample.csv")
+	if len(rules) == 0 {
+		b.Fatal("no rules read from Bitext sample")
+	}
+
+	return rules
+}
+
+func TestHashMerge(t *testing.T) {
+	type args struct {
+		slots string
+		f     string
+	}
+	neighbours := map[string]MergeFunction{"PR": MergePR, "PS": MergePS, "RS": MergeRS, "P": MergeP}
+	tests := []struct {
+		args args
+	}{
+		{args: args{slots: "PR", f: "./data/tests/test6.csv"}},
+		{args: args{slots: "PS", f: "./data/tests/test6.csv"}},
+		{args: args{slots: "RS", f: "./data/tests/test6.csv"}},
+		{args: args{slots: "P", f: "./data/tests/test6.csv"}},
+		{args: args{slots: "PR", f: "./data/tests/test9.csv"}},
+		{args: args{slots: "PS", f: "./data/tests/test9.csv"}},
+		{args: args{slots: "RS", f: "./data/tests/test9.csv"}},
+		{args: args{slots: "P", f: "./data/tests/test9.csv"}},
+		{args: args{slots: "P", f: "./data/tests/test7.csv"}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			rules := testRules(tt.args.f)
+			want := neighbours[tt.args.slots](slices.Clone(rules), LiteralEqual(nilLogger), nilLogger)
+			assert.Equal(t, want, HashMerge(tt.args.slots)(rules, LiteralEqual(nilLogger), nilLogger))
+		})
+	}
+}
+
+func TestHashMerge_unsorted(t *testing.T) {
+	rules := []Rule{
+		{pre: []string{"a"}, root: []string{"x"}, suf: []string{"1"}},
+		{pre: []string{"b"}, root: []string{"y"}, suf: []string{"2"}},
+		{pre: []string{"c"}, root: []string{"x"}, suf: []string{"3"}},
+	}
+	want := []Rule{
+		{pre: []string{"a", "c"}, root: []string{"x"}, suf: []string{"1", "3"}, isPublic: true},
+		{pre: []string{"b"}, root: []string{"y"}, suf: []string{"2"}},
+	}
+
+	// MergeR only compares neighbours after sorting on prefixes
+	assert.Len(t, MergeR(slices.Clone(rules), LiteralEqual(nilLogger), nilLogger), 3)
+	assert.Equal(t, want, HashMerge("R")(slices.Clone(rules), LiteralEqual(nilLogger), nilLogger))
+	assert.Equal(t, want, ClusterMerge("R", "single", NoBlocking)(slices.Clone(rules), LiteralEqual(nilLogger), nilLogger))
+}
+
+func Test_slotKey(t *testing.T) {
+	r1 := Rule{pre: []string{"a b"}, root: []string{"c"}, suf: []string{}}
+	r2 := Rule{pre: []string{"a", "b"}, root: []string{"c"}, suf: []string{}}
+	r3 := Rule{pre: []string{"a b"}, root: []string{"c"}, suf: []string{"d"}}
+
+	assert.NotEqual(t, slotKey(r1, "PR"), slotKey(r2, "PR"))
+	assert.Equal(t, slotKey(r1, "PR"), slotKey(r3, "PR"))
+	assert.NotEqual(t, slotKey(r1, "PRS"), slotKey(r3, "PRS"))
+	assert.NotEqual(t, slotKey(Rule{pre: []string{"c"}}, "P"), slotKey(Rule{root: []string{"c"}}, "PR"))
+}
+
+func TestTokenBlocking(t *testing.T) {
+	keys := []string{"send the invoice", "pay the bill", "Invoice please", "", "hello"}
+	assert.Equal(t, [][]int{{0, 1}, {0, 2}}, TokenBlocking(NewWordTokenizer())(keys))
+	assert.Equal(t, [][]int(nil), TokenBlocking(NewWordTokenizer())([]string{}))
+}
+
+func TestLengthBlocking(t *testing.T) {
+	keys := []string{"a b", "a", "a b c d", "", "a b c", "b"}
+	assert.Equal(t, [][]int{{1, 3, 5}, {0, 1, 5}, {0, 4}, {2, 4}}, LengthBlocking(NewWordTokenizer(), 1)(keys))
+	assert.Equal(t, [][]int{{0, 1, 2, 3, 4, 5}}, LengthBlocking(NewWordTokenizer(), 4)(keys)[:1])
+}
+
+func Test_minhashSignature(t *testing.T) {
+	sig := minhashSignature([]string{"ab", "bc"}, 8)
+	assert.Len(t, sig, 8)
+	assert.Equal(t, sig, minhashSignature([]string{"bc", "ab"}, 8))
+	assert.NotEqual(t, sig, minhashSignature([]string{"ab", "cd"}, 8))
+	assert.Equal(t, slices.Repeat([]uint64{^uint64(0)}, 4), minhashSignature([]string{}, 4))
+}
+
+func TestMinHashBlocking(t *testing.T) {
+	keys := []string{"can i register two accounts", "pay my bill", "can i regisger two accounts", "can i register two accounts"}
+	blocks := MinHashBlocking(3)(keys)
+
+	shared := func(i, j int) bool {
+		for _, b := range blocks {
+			if slices.Contains(b, i) && slices.Contains(b, j) {
+				return true
+			}
+		}
+		return false
+	}
+	assert.True(t, shared(0, 3))
+	assert.True(t, shared(0, 2))
+	assert.False(t, shared(0, 1))
+}
+
+func BenchmarkMergePR(b *testing.B) {
+	rules := benchmarkRules(b)
+	for b.Loop() {
+		MergePR(slices.Clone(rules), LiteralEqual(nilLogger), nilLogger)
+	}
+}
+
+func BenchmarkHashMergePR(b *testing.B) {
+	rules := benchmarkRules(b)
+	for b.Loop() {
+		HashMerge("PR")(slices.Clone(rules), LiteralEqual(nilLogger), nilLogger)
+	}
+}
+
+func BenchmarkMergeMisc(b *testing.B) {
+	rules := benchmarkRules(b)
+	for b.Loop() {
+		MergeMisc(slices.Clone(rules), LiteralEqual(nilLogger), nilLogger)
+	}
+}
+
+// all pairs are compared for the first 2000 rules only, the full sample has 100 times as many pairs
+func BenchmarkClusterMerge_none(b *testing.B) {
+	rules := benchmarkRules(b)[:2000]
+	for b.Loop() {
+		ClusterMerge("R", "single", NoBlocking)(slices.Clone(rules), CharacterLevenshteinThreshold(0.9, nilLogger), nilLogger)
+	}
+}
+
+func BenchmarkClusterMerge_token(b *testing.B) {
+	rules := benchmarkRules(b)
+	for b.Loop() {
+		ClusterMerge("R", "single", TokenBlocking(NewWordTokenizer()))(slices.Clone(rules), CharacterLevenshteinThreshold(0.9, nilLogger), nilLogger)
+	}
+}
+
+func BenchmarkClusterMerge_length(b *testing.B) {
+	rules := benchmarkRules(b)
+	for b.Loop() {
+		ClusterMerge("R", "single", LengthBlocking(NewWordTokenizer(), 1))(slices.Clone(rules), CharacterLevenshteinThreshold(0.9, nilLogger), nilLogger)
+	}
+}
+
+func BenchmarkClusterMerge_minhash(b *testing.B) {
+	rules := benchmarkRules(b)
+	for b.Loop() {
+		ClusterMerge("R", "single", MinHashBlocking(3))(slices.Clone(rules), CharacterLevenshteinThreshold(0.9, nilLogger), nilLogger)
+	}
+}
@@ -0,0 +1,153 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:37:21 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"container/list"
+	"log"
+	"strings"
+	"sync"
+)
+
+// Size bounded least recently used cache, safe for concurrent use
+// a nil cache or a cache with size 0 stores nothing
+type Cache[K comparable, V any] struct {
+	mu     sync.Mutex
+	name   string
+	size   int
+	order  *list.List
+	items  map[K]*list.Element
+	hits   int
+	misses int
+}
+
+type cacheEntry[K comparable, V any] struct {
+	key K
+	val V
+}
+
+func NewCache[K comparable, V any](name string, n int) *Cache[K, V] {
+	return &Cache[K, V]{name: name, size: n, order: list.New(), items: make(map[K]*list.Element)}
+}
+
+// Looks up k, marking it as most recently used if found
+func (c *Cache[K, V]) get(k K) (V, bool) {
+	var zero V
+
+	if c == nil {
+		return zero, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	e, ok := c.items[k]
+	if !ok {
+		c.misses++
+		return zero, false
+	}
+	c.hits++
+	c.order.MoveToFront(e)
+
+	return e.Value.(cacheEntry[K, V]).val, true
+}
+
+// Stores v under k, evicting the least recently used entry if the cache is full
+func (c *Cache[K, V]) put(k K, v V) {
+	if c == nil || c.size <= 0 {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if e, ok := c.items[k]; ok {
+		e.Value = cacheEntry[K, V]{k, v}
+		c.order.MoveToFront(e)
+		return
+	}
+	c.items[k] = c.order.PushFront(cacheEntry[K, V]{k, v})
+	if c.order.Len() > c.size {
+		e := c.order.Back()
+		c.order.Remove(e)
+		delete(c.items, e.Value.(cacheEntry[K, V]).key)
+	}
+}
+
+// Removes every entry, keeping the hit and miss counts so far
+func (c *Cache[K, V]) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.order.Init()
+	clear(c.items)
+}
+
+// Returns the number of cache hits and misses so far
+func (c *Cache[K, V]) stats() (int, int) {
+	if c == nil {
+		return 0, 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.hits, c.misses
+}
+
+// Logs cache hit and miss counts
+func (c *Cache[K, V]) log(l *log.Logger) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	hits, misses, entries := c.hits, c.misses, c.order.Len()
+	c.mu.Unlock()
+	l.Printf("cache %s recorded %v hits and %v misses, %v entries\n", c.name, hits, misses, entries)
+}
+
+// Cached result of a tagger lookup
+type tagResult struct {
+	tags   []string
+	tokens []string
+}
+
+// Cached result of an equality function, with the criteria which decided it
+type cachedMatch struct {
+	match  bool
+	reason string
+}
+
+// Wraps an equality function such that results for each pair of expression groups are only computed once
+// keys are symmetric, so (e1, e2) and (e2, e1) share an entry
+// e logs its own matches when first called, matches found in the cache are logged with the criteria which decided them
+func CachedEqual(e func(e1, e2 []string) (bool, string), c *Cache[[2]string, cachedMatch], l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		k := pairKey(e1, e2)
+		res, ok := c.get(k)
+		if ok {
+			if res.match {
+				l.Printf("equality function %s matched %v and %v, cached, matched by %s\n", "CachedEqual", e1, e2, res.reason)
+			}
+			return res.match
+		}
+		res.match, res.reason = e(e1, e2)
+		c.put(k, res)
+
+		return res.match
+	}
+}
+
+// Helper function to key a pair of expression groups, keys are symmetric so (e1, e2) and (e2, e1) share an entry
+func pairKey(e1, e2 []string) [2]string {
+	k1 := strings.Join(e1, "\x00")
+	k2 := strings.Join(e2, "\x00")
+	if k2 < k1 {
+		k1, k2 = k2, k1
+	}
+
+	return [2]string{k1, k2}
+}
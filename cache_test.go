@@ -0,0 +1,154 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:37:21 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bytes"
+	"log"
+	"strings"
+	"testing"
+
+	"github.com/jdkato/prose/tag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCache(t *testing.T) {
+	type args struct {
+		n    int
+		puts []string
+		gets []string
+	}
+	tests := []struct {
+		args   args
+		want   []bool
+		hits   int
+		misses int
+	}{
+		{args: args{n: 0, puts: []string{"a", "b"}, gets: []string{"a", "b"}}, want: []bool{false, false}, hits: 0, misses: 2},
+		{args: args{n: 2, puts: []string{"a", "b"}, gets: []string{"a", "b", "c"}}, want: []bool{true, true, false}, hits: 2, misses: 1},
+		{args: args{n: 2, puts: []string{"a", "b", "c"}, gets: []string{"a", "b", "c"}}, want: []bool{false, true, true}, hits: 2, misses: 1},
+		{args: args{n: 1, puts: []string{"a", "a", "a"}, gets: []string{"a"}}, want: []bool{true}, hits: 1, misses: 0},
+		{args: args{n: 5, puts: []string{}, gets: []string{"a", "a"}}, want: []bool{false, false}, hits: 0, misses: 2},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			c := NewCache[string, int]("test", tt.args.n)
+			for i, p := range tt.args.puts {
+				c.put(p, i)
+			}
+			got := []bool{}
+			for _, g := range tt.args.gets {
+				_, ok := c.get(g)
+				got = append(got, ok)
+			}
+			hits, misses := c.stats()
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.hits, hits)
+			assert.Equal(t, tt.misses, misses)
+		})
+	}
+}
+
+func TestCache_nil(t *testing.T) {
+	var c *Cache[string, int]
+
+	c.put("a", 1)
+	_, ok := c.get("a")
+	hits, misses := c.stats()
+	c.log(nilLogger)
+	assert.False(t, ok)
+	assert.Equal(t, 0, hits)
+	assert.Equal(t, 0, misses)
+}
+
+func TestCache_clear(t *testing.T) {
+	c := NewCache[string, int]("test", 2)
+
+	c.put("a", 1)
+	c.get("a")
+	c.clear()
+	_, ok := c.get("a")
+	hits, misses := c.stats()
+	assert.False(t, ok)
+	assert.Equal(t, 1, hits)
+	assert.Equal(t, 1, misses)
+	c.put("b", 2)
+	_, ok = c.get("b")
+	assert.True(t, ok)
+}
+
+func TestCachedEqual(t *testing.T) {
+	type args struct {
+		pairs [][2][]string
+	}
+	tests := []struct {
+		args  args
+		want  []bool
+		calls int
+		hits  int
+	}{
+		{args: args{pairs: [][2][]string{}}, want: []bool{}, calls: 0, hits: 0},
+		{args: args{pairs: [][2][]string{{{"a"}, {"a"}}, {{"a"}, {"a"}}}}, want: []bool{true, true}, calls: 1, hits: 1},
+		{args: args{pairs: [][2][]string{{{"a"}, {"b"}}, {{"b"}, {"a"}}}}, want: []bool{false, false}, calls: 1, hits: 0},
+		{args: args{pairs: [][2][]string{{{"a", "b"}, {"a b"}}, {{"a b"}, {"a", "b"}}}}, want: []bool{false, false}, calls: 1, hits: 0},
+		{args: args{pairs: [][2][]string{{{"a"}, {"a"}}, {{"a"}, {"b"}}, {{"b"}, {"a"}}, {{"b"}, {"b"}}}}, want: []bool{true, false, false, true}, calls: 3, hits: 0},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			var buf bytes.Buffer
+			calls := 0
+			eq := func(e1, e2 []string) (bool, string) {
+				calls++
+				return LiteralEqual(nilLogger)(e1, e2), "literal"
+			}
+			f := CachedEqual(eq, NewCache[[2]string, cachedMatch]("test", 10), log.New(&buf, "", 0))
+			got := []bool{}
+			for _, p := range tt.args.pairs {
+				got = append(got, f(p[0], p[1]))
+			}
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.calls, calls)
+			// matches found in the cache are logged with the criteria which decided them
+			assert.Equal(t, tt.hits, strings.Count(buf.String(), "cached, matched by literal"))
+		})
+	}
+}
+
+func TestSyntacticTagger_WithCache(t *testing.T) {
+	type args struct {
+		s []string
+	}
+	tests := []struct {
+		args args
+		hits int
+	}{
+		{args: args{s: []string{}}, hits: 0},
+		{args: args{s: []string{"I have no online account"}}, hits: 1},
+		{args: args{s: []string{"I have no online account", "I have no online account"}}, hits: 3},
+		{args: args{s: []string{"i want an account", "I have no online account", "i want an account"}}, hits: 4},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			tok := NewWordTokenizer()
+			mod := tag.NewPerceptronTagger()
+			tagger := NewSyntacticTagger(mod, tok)
+			cache := NewCache[[2]string, tagResult]("test", 10)
+			cached := tagger.WithCache(cache)
+			for _, s := range tt.args.s {
+				want, want1 := tagger.Constituency(s)
+				got, got1 := cached.Constituency(s)
+				assert.Equal(t, want, got)
+				assert.Equal(t, want1, got1)
+				want, want1 = tagger.POS(s)
+				got, got1 = cached.POS(s)
+				assert.Equal(t, want, got)
+				assert.Equal(t, want1, got1)
+			}
+			hits, _ := cache.stats()
+			assert.Equal(t, tt.hits, hits)
+		})
+	}
+}
@@ -104,7 +104,8 @@ func CollectChunks(t []Text) []string {
 
 // Counts bigram co-occurrences and converts to probabilities
 // counts are normalized such that all probabilities sum to 1
-func CollectTransitions(t []Text, f TransitionSplitFunction) Transitions {
+// texts are split using n workers, bigrams are counted in corpus order
+func CollectTransitions(t []Text, f TransitionSplitFunction, n int) Transitions {
 	toBigrams := func(e []string) [][]string {
 		var b [][]string
 
@@ -146,9 +147,12 @@ func CollectTransitions(t []Text, f TransitionSplitFunction) Transitions {
 	var bigrams [][]string
 	tra := make(Transitions)
 
-	for i := range t {
-		tags, _ := f(t[i].text)
-		bigrams = append(bigrams, toBigrams(tags)...)
+	split := ParallelMap(t, n, func(t Text) [][]string {
+		tags, _ := f(t.text)
+		return toBigrams(tags)
+	})
+	for i := range split {
+		bigrams = append(bigrams, split[i]...)
 	}
 
 	for i := range bigrams {
@@ -31,7 +31,7 @@ func TestCollectTransitions(t *testing.T) {
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
 			tok := NewWordTokenizer()
-			assert.Equalf(t, tt.want, CollectTransitions(tt.args.c, TokenSplit(tok)), "NewTransitions(%v)", tt.args.c)
+			assert.Equalf(t, tt.want, CollectTransitions(tt.args.c, TokenSplit(tok), 1), "NewTransitions(%v)", tt.args.c)
 		})
 	}
 }
@@ -127,7 +127,7 @@ func TestCollectChunks(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
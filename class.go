@@ -0,0 +1,195 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:42:09 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strings"
+
+	entity "github.com/jdkato/prose/chunk"
+	"github.com/jdkato/prose/tag"
+)
+
+// Named class of expressions (numbers, dates, emails, etc.)
+// matches are replaced in corpus texts with a reference to a private rule containing all observed values
+type PatternClass struct {
+	name string
+	// returns the start and end byte offsets of each match in s
+	find func(s string) [][]int
+}
+
+// Creates a class matching a regular expression
+func RegexpClass(name string, expr string) (PatternClass, error) {
+	re, err := regexp.Compile(expr)
+	if err != nil {
+		return PatternClass{}, fmt.Errorf("in RegexpClass(%v):\n%+w", name, err)
+	}
+
+	return PatternClass{name: name, find: func(s string) [][]int { return re.FindAllStringIndex(s, -1) }}, nil
+}
+
+// Creates a class matching named entities found by the prose treebank entity chunker
+func EntityClass(name string, t SyntacticTagger) PatternClass {
+	find := func(s string) [][]int {
+		var (
+			tags, tokens = t.penn(s)
+			tagged       []tag.Token
+			offsets      [][]int
+			out          [][]int
+			pos          int
+		)
+
+		for i := range tokens {
+			ind := strings.Index(s[pos:], tokens[i])
+			if ind == -1 {
+				return out
+			}
+			offsets = append(offsets, []int{pos + ind, pos + ind + len(tokens[i])})
+			pos += ind + len(tokens[i])
+			tagged = append(tagged, tag.Token{Tag: tags[i], Text: tokens[i]})
+		}
+		for _, loc := range entity.Locate(tagged, entity.TreebankNamedEntities) {
+			out = append(out, []int{offsets[loc[0]][0], offsets[loc[1]-1][1]})
+		}
+
+		return out
+	}
+
+	return PatternClass{name: name, find: find}
+}
+
+// Spelled out numbers, a bare "one" is too often a pronoun ("which one") so it is only matched when followed by another number word
+var (
+	spelledNumber        = `(?:zero|one|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|thirteen|fourteen|fifteen|sixteen|seventeen|eighteen|nineteen|twenty|thirty|forty|fifty|sixty|seventy|eighty|ninety|hundred|thousand|million|billion)`
+	spelledNumberNotOne  = `(?:zero|two|three|four|five|six|seven|eight|nine|ten|eleven|twelve|thirteen|fourteen|fifteen|sixteen|seventeen|eighteen|nineteen|twenty|thirty|forty|fifty|sixty|seventy|eighty|ninety|hundred|thousand|million|billion)`
+	spelledNumberPattern = fmt.Sprintf(`(?i)\b(?:%[2]s|one(?:[ -]|[ -]and[ -])%[1]s)(?:(?:[ -]|[ -]and[ -])%[1]s)*\b`, spelledNumber, spelledNumberNotOne)
+)
+
+// Default classes, in the order they are applied
+// more specific classes come first so that e.g. the digits of a date are not matched as numbers
+var DefaultClasses = []PatternClass{
+	mustRegexpClass("email", `[\w.+-]+@[\w-]+(?:\.[\w-]+)+`),
+	mustRegexpClass("money", `(?i)[$€£]\s?\d[\d,]*(?:\.\d+)?|\b\d[\d,]*(?:\.\d+)?\s?(?:dollars?|euros?|pounds?|bucks|usd|eur|gbp)\b`),
+	mustRegexpClass("date", `(?i)\b\d{1,2}/\d{1,2}/\d{2,4}\b|\b\d{4}-\d{2}-\d{2}\b|\b(?:jan|feb|mar|apr|may|jun|jul|aug|sep|sept|oct|nov|dec)[a-z]*\.? \d{1,2}(?:st|nd|rd|th)?(?:,? \d{4})?\b`),
+	mustRegexpClass("time", `(?i)\b\d{1,2}:\d{2}(?:\s?[ap]\.?m\b\.?)?|\b\d{1,2}\s?[ap]\.?m\b\.?`),
+	mustRegexpClass("number", `\b\d+(?:[.,]\d+)*\b`),
+	mustRegexpClass("number", spelledNumberPattern),
+}
+
+// Helper function for classes defined at compile time
+func mustRegexpClass(name string, expr string) PatternClass {
+	c, err := RegexpClass(name, expr)
+	if err != nil {
+		panic(err)
+	}
+	return c
+}
+
+// Reads user provided classes from a json object mapping class names to regular expressions
+// classes are returned sorted by name
+func ReadClasses(p string) ([]PatternClass, error) {
+	var (
+		err     error
+		m       = make(map[string]string)
+		classes = []PatternClass{}
+	)
+
+	file, err := os.Open(p)
+	if err != nil {
+		return classes, err
+	}
+	defer file.Close()
+	dec := json.NewDecoder(file)
+	err = dec.Decode(&m)
+	if err != nil {
+		return classes, err
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(m)) {
+		c, err := RegexpClass(k, m[k])
+		if err != nil {
+			return []PatternClass{}, err
+		}
+		classes = append(classes, c)
+	}
+
+	return classes, nil
+}
+
+// Replaces class matches in each text with a reference to the class rule, recording the matched values
+// texts which become identical after replacement are merged, keeping the values of both
+// texts protected by con are left as they are and never merged, and each skipped text holding a class match is logged
+func ClassFactor(t []Text, c []PatternClass, con *Constraints, l *log.Logger) []Text {
+	if len(c) == 0 {
+		return t
+	}
+
+	factored, protected := []Text{}, []Text{}
+	for i := range t {
+		if p := con.protectsText(t[i].text); p != "" {
+			if slices.ContainsFunc(c, func(class PatternClass) bool { return len(class.find(t[i].text)) != 0 }) {
+				l.Printf("FACTOR: class factoring skipped %v, text is protected by %v\n", t[i].text, p)
+			}
+			protected = append(protected, t[i])
+			continue
+		}
+		for _, class := range c {
+			locs := class.find(t[i].text)
+			if len(locs) == 0 {
+				continue
+			}
+			if t[i].classes == nil {
+				t[i].classes = make(map[string][]string)
+			}
+			text := t[i].text
+			for j := len(locs) - 1; j >= 0; j-- {
+				val := text[locs[j][0]:locs[j][1]]
+				l.Printf("FACTOR: class %s replaced %v in %v\n", class.name, val, t[i].text)
+				t[i].classes[class.name] = append(t[i].classes[class.name], val)
+				text = text[:locs[j][0]] + fmt.Sprintf("<%s>", class.name) + text[locs[j][1]:]
+			}
+			t[i].text = text
+		}
+		factored = append(factored, t[i])
+	}
+
+	out := append(compactTexts(factored), protected...)
+	slices.SortStableFunc(out, func(i, j Text) int { return strings.Compare(i.text, j.text) })
+
+	return out
+}
+
+// Collects the values observed for each class into private rules named after the class, built from the corpus lines of the texts holding them
+func ClassRules(t []Text) []Rule {
+	var (
+		rules  []Rule
+		values = make(map[string][]string)
+		lines  = make(map[string][]int)
+	)
+
+	for i := range t {
+		for k, v := range t[i].classes {
+			values[k] = append(values[k], v...)
+			lines[k] = append(lines[k], t[i].lines...)
+		}
+	}
+
+	for _, k := range slices.Sorted(maps.Keys(values)) {
+		vals := values[k]
+		slices.Sort(vals)
+		vals = slices.Compact(vals)
+		slices.Sort(lines[k])
+		rules = append(rules, Rule{pre: []string{}, root: vals, suf: []string{}, isPublic: false, label: k, lines: slices.Compact(lines[k])})
+	}
+
+	return rules
+}
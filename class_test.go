@@ -0,0 +1,153 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:42:09 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bytes"
+	"log"
+	"testing"
+
+	"github.com/jdkato/prose/tag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDefaultClasses(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		args args
+		want string
+	}{
+		{args: args{s: ""}, want: ""},
+		{args: args{s: "send me 300 dollars"}, want: "send me <money>"},
+		{args: args{s: "send me $12.50 now"}, want: "send me <money> now"},
+		{args: args{s: "send me 300 apples"}, want: "send me <number> apples"},
+		{args: args{s: "send me three hundred apples"}, want: "send me <number> apples"},
+		{args: args{s: "send me twenty-five apples"}, want: "send me <number> apples"},
+		{args: args{s: "someone wants two apples"}, want: "someone wants <number> apples"},
+		{args: args{s: "send me one hundred apples"}, want: "send me <number> apples"},
+		{args: args{s: "send me twenty-one apples"}, want: "send me <number> apples"},
+		{args: args{s: "which one do you want"}, want: "which one do you want"},
+		{args: args{s: "call me at 3pm or 10:30 am"}, want: "call me at <time> or <time>"},
+		{args: args{s: "it arrived on 10/12/2025 and 2025-10-12"}, want: "it arrived on <date> and <date>"},
+		{args: args{s: "it arrived on October 12th, 2025"}, want: "it arrived on <date>"},
+		{args: args{s: "write to support@example.com"}, want: "write to <email>"},
+		{args: args{s: "I want an account"}, want: "I want an account"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := ClassFactor([]Text{{text: tt.args.s}}, DefaultClasses, nil, nilLogger)
+			assert.Equal(t, tt.want, got[0].text)
+		})
+	}
+}
+
+func TestClassFactor(t *testing.T) {
+	type args struct {
+		t []Text
+	}
+	tests := []struct {
+		args args
+		want []Text
+	}{
+		{args: args{t: []Text{}}, want: []Text{}},
+		{args: args{t: []Text{{text: "send me money"}}}, want: []Text{{text: "send me money"}}},
+		{args: args{t: []Text{{text: "send me 300 apples"}, {text: "send me 45 apples"}}}, want: []Text{{text: "send me <number> apples", classes: map[string][]string{"number": {"300", "45"}}}}},
+		{args: args{t: []Text{{text: "send me 45 apples"}, {text: "send me apples"}, {text: "send me 300 apples"}}}, want: []Text{{text: "send me <number> apples", classes: map[string][]string{"number": {"45", "300"}}}, {text: "send me apples"}}},
+		{args: args{t: []Text{{text: "send 3 apples to a@b.com"}, {text: "send 4 apples to c@d.com"}}}, want: []Text{{text: "send <number> apples to <email>", classes: map[string][]string{"number": {"3", "4"}, "email": {"a@b.com", "c@d.com"}}}}},
+		{args: args{t: []Text{{text: "1 and 2"}}}, want: []Text{{text: "<number> and <number>", classes: map[string][]string{"number": {"2", "1"}}}}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassFactor(tt.args.t, DefaultClasses, nil, nilLogger))
+		})
+	}
+}
+
+func TestClassFactor_protected(t *testing.T) {
+	var (
+		buf bytes.Buffer
+		con = NewConstraints(ConstraintSpec{Protected: []string{"call 911 now", "room 101"}}, NewWordTokenizer(), []Rule{})
+		tx  = []Text{{text: "call 911 now", lines: []int{1}}, {text: "call 42 now", lines: []int{2}}, {text: "book room 101 today", lines: []int{3}}, {text: "book room 7 today", lines: []int{4}}}
+	)
+
+	// protected utterances and texts holding a protected chunk are not factored, and not merged with the factored texts
+	assert.Equal(t, []Text{
+		{text: "book room 101 today", lines: []int{3}},
+		{text: "book room <number> today", classes: map[string][]string{"number": {"7"}}, lines: []int{4}},
+		{text: "call 911 now", lines: []int{1}},
+		{text: "call <number> now", classes: map[string][]string{"number": {"42"}}, lines: []int{2}},
+	}, ClassFactor(tx, DefaultClasses, con, log.New(&buf, "", 0)))
+	assert.Contains(t, buf.String(), "class factoring skipped call 911 now, text is protected by call 911 now")
+}
+
+func TestClassRules(t *testing.T) {
+	type args struct {
+		t []Text
+	}
+	tests := []struct {
+		args args
+		want []Rule
+	}{
+		{args: args{t: []Text{}}, want: nil},
+		{args: args{t: []Text{{text: "send me money"}}}, want: nil},
+		{args: args{t: []Text{{text: "<number>", classes: map[string][]string{"number": {"45", "300", "45"}}}}}, want: []Rule{{pre: []string{}, root: []string{"300", "45"}, suf: []string{}, isPublic: false, label: "number"}}},
+		{args: args{t: []Text{{text: "<number>", classes: map[string][]string{"number": {"1"}}}, {text: "<number> <email>", classes: map[string][]string{"number": {"2"}, "email": {"a@b.com"}}}}}, want: []Rule{{pre: []string{}, root: []string{"a@b.com"}, suf: []string{}, isPublic: false, label: "email"}, {pre: []string{}, root: []string{"1", "2"}, suf: []string{}, isPublic: false, label: "number"}}},
+		{args: args{t: []Text{{text: "<number>", classes: map[string][]string{"number": {"1"}}, lines: []int{4, 9}}, {text: "<number> <email>", classes: map[string][]string{"number": {"2"}, "email": {"a@b.com"}}, lines: []int{2, 4}}}}, want: []Rule{{pre: []string{}, root: []string{"a@b.com"}, suf: []string{}, isPublic: false, label: "email", lines: []int{2, 4}}, {pre: []string{}, root: []string{"1", "2"}, suf: []string{}, isPublic: false, label: "number", lines: []int{2, 4, 9}}}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, ClassRules(tt.args.t))
+		})
+	}
+}
+
+func TestReadClasses(t *testing.T) {
+	type args struct {
+		p string
+		s string
+	}
+	tests := []struct {
+		args      args
+		want      string
+		assertion assert.ErrorAssertionFunc
+	}{
+		{args: args{p: "", s: "order ORD-12"}, want: "order ORD-12", assertion: assert.Error},
+		{args: args{p: "./data/tests/classes1.json", s: "order ORD-12 for acct1234"}, want: "order <order_id> for <account>", assertion: assert.NoError},
+		{args: args{p: "./data/tests/classes2.json", s: "order ORD-12"}, want: "order ORD-12", assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := ReadClasses(tt.args.p)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, ClassFactor([]Text{{text: tt.args.s}}, got, nil, nilLogger)[0].text)
+		})
+	}
+}
+
+func TestEntityClass(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		args args
+		want string
+	}{
+		{args: args{s: ""}, want: ""},
+		{args: args{s: "I want an account"}, want: "I want an account"},
+		{args: args{s: "I want to talk to Customer Service"}, want: "I want to talk to <entity>"},
+		{args: args{s: "John Smith lives in Boston"}, want: "<entity> lives in <entity>"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			tok := NewWordTokenizer()
+			mod := tag.NewPerceptronTagger()
+			c := EntityClass("entity", NewSyntacticTagger(mod, tok))
+			assert.Equal(t, tt.want, ClassFactor([]Text{{text: tt.args.s}}, []PatternClass{c}, nil, nilLogger)[0].text)
+		})
+	}
+}
@@ -13,6 +13,8 @@ import (
 	"log"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
 
 	"github.com/jdkato/prose/tag"
 	"github.com/urfave/cli/v3"
@@ -35,6 +37,22 @@ var (
 			}
 		},
 	}
+	pipeline cli.StringFlag = cli.StringFlag{
+		Name:   "pipeline",
+		Hidden: true,
+		Validator: func(s string) error {
+			_, err := os.Open(s)
+			if err != nil {
+				return fmt.Errorf("in ValidatePipeline(%v):\n%+w", s, err)
+			}
+			switch filepath.Ext(s) {
+			case ".yaml", ".yml", ".json":
+				return nil
+			default:
+				return fmt.Errorf("in ValidatePipeline(%v):\n%+w", s, fmt.Errorf("file extension is not one of .yaml, .yml, .json"))
+			}
+		},
+	}
 	outFile cli.StringFlag = cli.StringFlag{
 		Name: "outFile",
 		Validator: func(s string) error {
@@ -55,6 +73,25 @@ var (
 		Value: false,
 		Usage: "format output grammar with single public rule",
 	}
+	provenance cli.BoolFlag = cli.BoolFlag{
+		Name:  "provenance",
+		Value: false,
+		Usage: "write the corpus lines each rule was built from as comments above each rule",
+	}
+	provenanceFile cli.StringFlag = cli.StringFlag{
+		Name: "provenanceFile",
+		Validator: func(s string) error {
+			_, err := os.Stat(filepath.Dir(s))
+			if err != nil {
+				return fmt.Errorf("in ValidateProvenanceFile(%v):\n%+w", s, err)
+			}
+			if filepath.Ext(s) != ".json" {
+				return fmt.Errorf("in ValidateProvenanceFile(%v):\n%+w", s, fmt.Errorf("file extension is not .json"))
+			}
+			return nil
+		},
+		Usage: "json file to write the corpus lines each rule was built from to, keyed by rule name",
+	}
 	filterQuantile cli.FloatFlag = cli.FloatFlag{
 		Name:  "filter",
 		Value: 0.0,
@@ -84,6 +121,25 @@ var (
 		},
 		Usage: "strategy to use during expression chunking. one of ['token', 'posTag', 'conTag']",
 	}
+	tagMap cli.StringFlag = cli.StringFlag{
+		Name: "tagMap",
+		Validator: func(s string) error {
+			if s == "universal" {
+				return nil
+			}
+			_, err := os.Open(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateTagMap(%v):\n%+w", s, err)
+			}
+			switch filepath.Ext(s) {
+			case ".json":
+				return nil
+			default:
+				return fmt.Errorf("in ValidateTagMap(%v):\n%+w", s, fmt.Errorf("tagMap must be 'universal' or a .json file"))
+			}
+		},
+		Usage: "map POS tags to a coarser tagset before chunking, merging, and factoring. one of ['universal'] or a user provided json file mapping tags to tags",
+	}
 	prob cli.FloatFlag = cli.FloatFlag{
 		Name:  "prob",
 		Value: 0.1,
@@ -99,16 +155,17 @@ var (
 	merge cli.StringFlag = cli.StringFlag{
 		Name: "merge",
 		Validator: func(s string) error {
-			switch s {
-			case "literal", "charDistance", "tokenDistance", "tfidf", "posTag", "conTag":
-				return nil
-			default:
-				return fmt.Errorf("in ValidateChunk(%v):\n%+w", s, fmt.Errorf("chunk must be one of ['literal', 'charDistance', 'tokenDistance', 'tfidf', 'posTag', 'conTag']"))
+			_, err := ParseMergeExpression(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateMerge(%v):\n%+w", s, err)
 			}
+			return nil
 		},
-		Usage: "strategy to use during rule merging. one of ['literal', 'charDistance', 'tokenDistance', 'tfidf', 'posTag', 'conTag']",
+		Usage: fmt.Sprintf("strategy to use during rule merging. one of %v, or an expression combining them with AND, OR, NOT, parentheses, and per strategy thresholds, e.g. 'literal OR (tokenDistance>=0.8 AND posTag)'", mergeStrategies),
 	}
-	similarity cli.FloatFlag = cli.FloatFlag{
+	mergeStrategies               = []string{"literal", "charDistance", "tokenDistance", "tfidf", "posTag", "conTag", "jaroWinkler", "jaccard", "dice", "ngram", "lcs", "vectors", "embed", "wordnet", "soundex", "metaphone", "phoneme", "lemma", "posDistance", "charTfidf"}
+	exactStrategies               = []string{"literal", "posTag", "conTag", "wordnet", "soundex", "metaphone", "lemma"}
+	similarity      cli.FloatFlag = cli.FloatFlag{
 		Name:  "sim",
 		Value: 0.8,
 		Validator: func(f float64) error {
@@ -119,6 +176,298 @@ var (
 		},
 		Usage: "similarity threshold above which expression groups will be considered eqivalent",
 	}
+	cluster cli.StringFlag = cli.StringFlag{
+		Name: "cluster",
+		Validator: func(s string) error {
+			if slices.Contains(linkages, s) {
+				return nil
+			}
+			return fmt.Errorf("in ValidateCluster(%v):\n%+w", s, fmt.Errorf("cluster must be one of %v", linkages))
+		},
+		Usage: "merge rules by clustering over all pairs of rules rather than comparing neighbouring rules after sorting, which makes merges independent of rule order. one of ['single', 'complete', 'average'] linkage",
+	}
+	block cli.StringFlag = cli.StringFlag{
+		Name: "block",
+		Validator: func(s string) error {
+			if slices.Contains(blockings, s) {
+				return nil
+			}
+			return fmt.Errorf("in ValidateBlock(%v):\n%+w", s, fmt.Errorf("block must be one of %v", blockings))
+		},
+		Usage: "only compare pairs of rules sharing a block when clustering, grouping rules by shared tokens, MinHash signatures of character trigrams, or token counts. one of ['token', 'minhash', 'length'], uses single linkage if cluster is unset",
+	}
+	maxNovel cli.FloatFlag = cli.FloatFlag{
+		Name:  "maxNovel",
+		Value: 0.0,
+		Validator: func(f float64) error {
+			if f < 0.0 {
+				return fmt.Errorf("in ValidateMaxNovel(%v):\n%+w", f, fmt.Errorf("maxNovel must be a positive number"))
+			}
+			return nil
+		},
+		Usage: "maximum number of productions not found in the corpus that a single merge may introduce. values below 1 are a ratio of the productions of the merged rules, 0 is unlimited",
+	}
+	maxNovelGrammar cli.FloatFlag = cli.FloatFlag{
+		Name:  "maxNovelGrammar",
+		Value: 0.0,
+		Validator: func(f float64) error {
+			if f < 0.0 {
+				return fmt.Errorf("in ValidateMaxNovelGrammar(%v):\n%+w", f, fmt.Errorf("maxNovelGrammar must be a positive number"))
+			}
+			return nil
+		},
+		Usage: "maximum number of productions not found in the corpus that all merges may introduce. values below 1 are a ratio of the productions of the corpus, 0 is unlimited",
+	}
+	negatives cli.StringFlag = cli.StringFlag{
+		Name: "negatives",
+		Validator: func(s string) error {
+			_, err := os.Open(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateNegatives(%v):\n%+w", s, err)
+			}
+			switch filepath.Ext(s) {
+			case ".txt", ".csv":
+				return nil
+			default:
+				return fmt.Errorf("in ValidateNegatives(%v):\n%+w", s, fmt.Errorf("file extension is not one of .txt, .csv"))
+			}
+		},
+		Usage: "user provided file of utterances the grammar must not produce, one per line. merges and factoring steps producing any of them are rejected, and the final grammar is checked against them",
+	}
+	constraints cli.StringFlag = cli.StringFlag{
+		Name: "constraints",
+		Validator: func(s string) error {
+			_, err := os.Open(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateConstraints(%v):\n%+w", s, err)
+			}
+			if filepath.Ext(s) != ".json" {
+				return fmt.Errorf("in ValidateConstraints(%v):\n%+w", s, fmt.Errorf("file extension is not .json"))
+			}
+			return nil
+		},
+		Usage: "user provided json file of protected utterances and chunks, and cannot-link pairs of phrases. merges and factoring steps changing a rule holding a protected phrase, or linking both phrases of a pair in one rule, are rejected",
+	}
+	heldOut cli.FloatFlag = cli.FloatFlag{
+		Name:  "heldOut",
+		Value: 0.0,
+		Validator: func(f float64) error {
+			if f < 0.0 || f >= 1.0 {
+				return fmt.Errorf("in ValidateHeldOut(%v):\n%+w", f, fmt.Errorf("heldOut must be at least 0 and below 1"))
+			}
+			return nil
+		},
+		Usage: "share of the corpus held out from building the grammar. single slot merges introducing productions not in the corpus are only made if they cover held-out utterances not already covered, within the maxNovel and maxNovelGrammar budgets",
+	}
+	heldOutReport cli.StringFlag = cli.StringFlag{
+		Name: "heldOutReport",
+		Validator: func(s string) error {
+			_, err := os.Stat(filepath.Dir(s))
+			if err != nil {
+				return fmt.Errorf("in ValidateHeldOutReport(%v):\n%+w", s, err)
+			}
+			if filepath.Ext(s) != ".json" {
+				return fmt.Errorf("in ValidateHeldOutReport(%v):\n%+w", s, fmt.Errorf("file extension is not .json"))
+			}
+			return nil
+		},
+		Usage: "json file to write held-out coverage and the held-out utterances justifying each merge to. requires heldOut",
+	}
+	novelReport cli.StringFlag = cli.StringFlag{
+		Name: "novelReport",
+		Validator: func(s string) error {
+			_, err := os.Stat(filepath.Dir(s))
+			if err != nil {
+				return fmt.Errorf("in ValidateNovelReport(%v):\n%+w", s, err)
+			}
+			if filepath.Ext(s) != ".json" {
+				return fmt.Errorf("in ValidateNovelReport(%v):\n%+w", s, fmt.Errorf("file extension is not .json"))
+			}
+			return nil
+		},
+		Usage: "json file to write each production not in the corpus and the merge or factoring step which introduced it to",
+	}
+	plan cli.StringFlag = cli.StringFlag{
+		Name: "plan",
+		Validator: func(s string) error {
+			_, err := os.Stat(filepath.Dir(s))
+			if err != nil {
+				return fmt.Errorf("in ValidatePlan(%v):\n%+w", s, err)
+			}
+			switch filepath.Ext(s) {
+			case ".json", ".csv":
+				return nil
+			default:
+				return fmt.Errorf("in ValidatePlan(%v):\n%+w", s, fmt.Errorf("file extension is not one of .json, .csv"))
+			}
+		},
+		Usage: "json or csv file of the merges proposed by each merge function, marked accept or reject by a reviewer. interpolate and extrapolate write the plan instead of a grammar, and apply-plan builds the grammar from the merges accepted in it",
+	}
+	ngram cli.IntFlag = cli.IntFlag{
+		Name:  "ngram",
+		Value: 3,
+		Validator: func(i int) error {
+			if i < 1 {
+				return fmt.Errorf("in ValidateNgram(%v):\n%+w", i, fmt.Errorf("ngram must be at least 1"))
+			}
+			return nil
+		},
+		Usage: "character n-gram length used by the ngram merge strategy",
+	}
+	ngramMin cli.IntFlag = cli.IntFlag{
+		Name:  "ngramMin",
+		Value: 2,
+		Validator: func(i int) error {
+			if i < 1 {
+				return fmt.Errorf("in ValidateNgramMin(%v):\n%+w", i, fmt.Errorf("ngramMin must be at least 1"))
+			}
+			return nil
+		},
+		Usage: "shortest character n-gram used by the charTfidf merge strategy",
+	}
+	ngramMax cli.IntFlag = cli.IntFlag{
+		Name:  "ngramMax",
+		Value: 5,
+		Validator: func(i int) error {
+			if i < 1 {
+				return fmt.Errorf("in ValidateNgramMax(%v):\n%+w", i, fmt.Errorf("ngramMax must be at least 1"))
+			}
+			return nil
+		},
+		Usage: "longest character n-gram used by the charTfidf merge strategy",
+	}
+	vectors cli.StringFlag = cli.StringFlag{
+		Name: "vectors",
+		Validator: func(s string) error {
+			_, err := os.Stat(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateVectors(%v):\n%+w", s, err)
+			}
+			return nil
+		},
+		Usage: "local GloVe, word2vec, or fastText word vector file used by the vectors merge strategy. files ending in .bin are read as word2vec binary, all others as text",
+	}
+	sif cli.BoolFlag = cli.BoolFlag{
+		Name:  "sif",
+		Value: false,
+		Usage: "weight word vectors by smooth inverse frequency in the corpus rather than averaging them",
+	}
+	endpoint cli.StringFlag = cli.StringFlag{
+		Name:  "endpoint",
+		Value: "http://localhost:8080/v1/embeddings",
+		Usage: "url of the OpenAI compatible embeddings endpoint used by the embed merge strategy",
+	}
+	embedModel cli.StringFlag = cli.StringFlag{
+		Name:  "embedModel",
+		Usage: "model name sent to the embeddings endpoint",
+	}
+	embedBatch cli.IntFlag = cli.IntFlag{
+		Name:  "embedBatch",
+		Value: 64,
+		Validator: func(i int) error {
+			if i < 1 {
+				return fmt.Errorf("in ValidateEmbedBatch(%v):\n%+w", i, fmt.Errorf("embedBatch must be at least 1"))
+			}
+			return nil
+		},
+		Usage: "maximum number of expression groups sent to the embeddings endpoint per request",
+	}
+	embedCache cli.StringFlag = cli.StringFlag{
+		Name: "embedCache",
+		Validator: func(s string) error {
+			_, err := os.Stat(filepath.Dir(s))
+			if err != nil {
+				return fmt.Errorf("in ValidateEmbedCache(%v):\n%+w", s, err)
+			}
+			return nil
+		},
+		Usage: "file in which embeddings returned by the endpoint are cached across runs",
+	}
+	wordnet cli.StringFlag = cli.StringFlag{
+		Name: "wordnet",
+		Validator: func(s string) error {
+			_, err := os.Stat(filepath.Join(s, "index.noun"))
+			if err != nil {
+				return fmt.Errorf("in ValidateWordNet(%v):\n%+w", s, err)
+			}
+			return nil
+		},
+		Usage: "local WordNet dict/ directory used by the wordnet merge strategy and wnSyn synonym expansion",
+	}
+	cmudict cli.StringFlag = cli.StringFlag{
+		Name: "cmudict",
+		Validator: func(s string) error {
+			_, err := os.Stat(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateCmudict(%v):\n%+w", s, err)
+			}
+			return nil
+		},
+		Usage: "local CMUdict format pronunciation dictionary used by the phoneme merge strategy",
+	}
+	costs cli.StringFlag = cli.StringFlag{
+		Name: "costs",
+		Validator: func(s string) error {
+			_, err := os.Open(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateCosts(%v):\n%+w", s, err)
+			}
+			switch filepath.Ext(s) {
+			case ".json":
+				return nil
+			default:
+				return fmt.Errorf("in ValidateCosts(%v):\n%+w", s, fmt.Errorf("file extension is not .json"))
+			}
+		},
+		Usage: "user provided json table of substitution, insertion, and deletion costs by universal POS tag used by the posDistance merge strategy. if unset, same tag swaps and determiner/adverb edits are cheap and verb edits are expensive",
+	}
+	lemma cli.StringFlag = cli.StringFlag{
+		Name:  "lemma",
+		Value: "en",
+		Validator: func(s string) error {
+			if _, ok := Stemmers[s]; ok {
+				return nil
+			}
+			_, err := os.Open(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateLemma(%v):\n%+w", s, err)
+			}
+			switch filepath.Ext(s) {
+			case ".json":
+				return nil
+			default:
+				return fmt.Errorf("in ValidateLemma(%v):\n%+w", s, fmt.Errorf("lemma must be one of ['en'] or a .json file"))
+			}
+		},
+		Usage: "lemmatizer used by the lemma merge strategy and lemmaVocab. one of ['en'] (porter stemmer) or a user provided json file mapping word forms to lemmas, with unlisted words stemmed",
+	}
+	weighting cli.StringFlag = cli.StringFlag{
+		Name:  "weighting",
+		Value: "tf",
+		Validator: func(s string) error {
+			if slices.Contains(tfidfWeightings, s) {
+				return nil
+			}
+			return fmt.Errorf("in ValidateWeighting(%v):\n%+w", s, fmt.Errorf("weighting must be one of %v", tfidfWeightings))
+		},
+		Usage: "term weighting used by the tfidf and charTfidf merge strategies. one of ['tf', 'sublinear', 'bm25']",
+	}
+	lemmaVocab cli.BoolFlag = cli.BoolFlag{
+		Name:  "lemmaVocab",
+		Value: false,
+		Usage: "build tfidf vocabulary and embeddings over lemmas rather than tokens",
+	}
+	wnSenses cli.IntFlag = cli.IntFlag{
+		Name:  "wnSenses",
+		Value: 1,
+		Validator: func(i int) error {
+			if i < 0 {
+				return fmt.Errorf("in ValidateWnSenses(%v):\n%+w", i, fmt.Errorf("wnSenses must be a positive number"))
+			}
+			return nil
+		},
+		Usage: "number of WordNet senses considered per word, most frequent first. 0 considers all senses",
+	}
 
 	factorN cli.IntFlag = cli.IntFlag{
 		Name:  "factorN",
@@ -153,6 +502,67 @@ var (
 		},
 		Usage: "user provided json file containing synonyms. Overrides the value of expand flag if provided",
 	}
+	wnSyn cli.StringFlag = cli.StringFlag{
+		Name:  "wnSyn",
+		Usage: "comma separated words and/or parts of speech (noun, verb, adj, adv) in the corpus to expand with WordNet synonyms. requires wordnet",
+	}
+	wnDepth cli.IntFlag = cli.IntFlag{
+		Name:  "wnDepth",
+		Value: 0,
+		Validator: func(i int) error {
+			if i < 0 {
+				return fmt.Errorf("in ValidateWnDepth(%v):\n%+w", i, fmt.Errorf("wnDepth must be a positive number"))
+			}
+			return nil
+		},
+		Usage: "number of hypernym and similarity links followed when collecting WordNet synonyms. 0 uses only each word's own synsets",
+	}
+
+	spoken cli.StringFlag = cli.StringFlag{
+		Name: "spoken",
+		Validator: func(s string) error {
+			if _, ok := SpokenTables[s]; ok {
+				return nil
+			}
+			_, err := os.Open(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateSpoken(%v):\n%+w", s, err)
+			}
+			switch filepath.Ext(s) {
+			case ".json":
+				return nil
+			default:
+				return fmt.Errorf("in ValidateSpoken(%v):\n%+w", s, fmt.Errorf("spoken must be one of ['en'] or a .json file"))
+			}
+		},
+		Usage: "expand numbers, currency, ordinals, times, abbreviations, and symbols to spoken forms, tagging spoken forms with their written forms in the grammar. one of ['en'] or a user provided json table",
+	}
+	classes cli.BoolFlag = cli.BoolFlag{
+		Name:  "classes",
+		Value: false,
+		Usage: "replace numbers, spelled out numbers, money, dates, times, and emails with references to class rules before merging",
+	}
+	classFile cli.StringFlag = cli.StringFlag{
+		Name: "classFile",
+		Validator: func(s string) error {
+			_, err := os.Open(s)
+			if err != nil {
+				return fmt.Errorf("in ValidateClassFile(%v):\n%+w", s, err)
+			}
+			switch filepath.Ext(s) {
+			case ".json":
+				return nil
+			default:
+				return fmt.Errorf("in ValidateClassFile(%v):\n%+w", s, fmt.Errorf("file extension is not .json"))
+			}
+		},
+		Usage: "user provided json file mapping class names to regular expressions. matches are replaced with references to class rules before merging, ahead of any default classes",
+	}
+	entities cli.BoolFlag = cli.BoolFlag{
+		Name:  "entities",
+		Value: false,
+		Usage: "replace named entities found by the prose entity chunker with references to an entity class rule before merging",
+	}
 
 	merge1 cli.BoolFlag = cli.BoolFlag{
 		Name:  "merge1",
@@ -175,6 +585,29 @@ var (
 		Usage: "apply factoring after rule merging",
 	}
 
+	workers cli.IntFlag = cli.IntFlag{
+		Name:  "workers",
+		Value: 1,
+		Validator: func(i int) error {
+			if i < 1 {
+				return fmt.Errorf("in ValidateWorkers(%v):\n%+w", i, fmt.Errorf("workers must be at least 1"))
+			}
+			return nil
+		},
+		Usage: "number of goroutines used for tagging, tokenizing, and chunking the corpus. output is identical for any number of workers",
+	}
+	cacheSize cli.IntFlag = cli.IntFlag{
+		Name:  "cacheSize",
+		Value: 100000,
+		Validator: func(i int) error {
+			if i < 0 {
+				return fmt.Errorf("in ValidateCacheSize(%v):\n%+w", i, fmt.Errorf("cache size must be a positive number"))
+			}
+			return nil
+		},
+		Usage: "maximum number of entries held in each of the tagging and similarity caches. 0 disables caching",
+	}
+
 	logging cli.BoolFlag = cli.BoolFlag{
 		Name:  "log",
 		Value: false,
@@ -198,6 +631,14 @@ var (
 
 	nilLogger    *log.Logger = log.New(io.Discard, "", log.LstdFlags)
 	stdoutLogger *log.Logger = log.New(os.Stdout, "INFO:", log.LstdFlags|log.Lmicroseconds|log.Llongfile)
+
+	// shared across all taggers and equality functions set up for a command
+	tagCache      *Cache[[2]string, tagResult]
+	equalityCache *Cache[[2]string, cachedMatch]
+	// set up by the explain-novel command, records the similarity of each match made by threshold equality functions
+	similarities *Similarities
+	// set up by the embed merge strategy, holds any endpoint error raised during merging
+	embeddingClient *EmbeddingClient
 )
 
 func NewFileLogger(p string) (*log.Logger, error) {
@@ -215,10 +656,78 @@ func prepareContext(ctx context.Context, cmd *cli.Command) (context.Context, err
 	}
 
 	cmd.Set("inFile", cmd.Args().Get(0))
+	tagCache = NewCache[[2]string, tagResult]("tag", cmd.Int("cacheSize"))
+	equalityCache = NewCache[[2]string, cachedMatch]("equality", cmd.Int("cacheSize"))
+
+	return ctx, nil
+}
+
+// Sets the pipeline and corpus files before the run command is run, followed by the options set for the whole pipeline
+func preparePipeline(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	if cmd.Args().Get(1) == "" {
+		cli.ShowSubcommandHelpAndExit(cmd, 0)
+	}
+
+	err := cmd.Set("pipeline", cmd.Args().Get(0))
+	if err != nil {
+		return ctx, fmt.Errorf("in preparePipeline():\n%+w", err)
+	}
+	cmd.Set("inFile", cmd.Args().Get(1))
+	p, err := ReadPipeline(cmd.String("pipeline"))
+	if err != nil {
+		return ctx, fmt.Errorf("in preparePipeline():\n%+w", err)
+	}
+	_, err = setOptions(cmd, p.Options)
+	if err != nil {
+		return ctx, fmt.Errorf("in preparePipeline():\n%+w", err)
+	}
+	tagCache = NewCache[[2]string, tagResult]("tag", cmd.Int("cacheSize"))
+	equalityCache = NewCache[[2]string, cachedMatch]("equality", cmd.Int("cacheSize"))
+
+	return ctx, nil
+}
+
+// Sets the plan file before the apply-plan command is run, followed by the corpus and options the plan was made with
+// a corpus file given after the plan replaces the corpus of the plan, and options given on the command line take precedence over the options of the plan
+func preparePlan(ctx context.Context, cmd *cli.Command) (context.Context, error) {
+	if cmd.Args().Get(0) == "" {
+		cli.ShowSubcommandHelpAndExit(cmd, 0)
+	}
+
+	err := cmd.Set("plan", cmd.Args().Get(0))
+	if err != nil {
+		return ctx, fmt.Errorf("in preparePlan():\n%+w", err)
+	}
+	p, err := ReadPlan(cmd.String("plan"))
+	if err != nil {
+		return ctx, fmt.Errorf("in preparePlan():\n%+w", err)
+	}
+	cmd.Set("inFile", p.InFile)
+	if cmd.Args().Get(1) != "" {
+		cmd.Set("inFile", cmd.Args().Get(1))
+	}
+	o := make(map[string]any)
+	for k, v := range p.Options {
+		if !cmd.IsSet(k) {
+			o[k] = v
+		}
+	}
+	_, err = setOptions(cmd, o)
+	if err != nil {
+		return ctx, fmt.Errorf("in preparePlan():\n%+w", err)
+	}
+	tagCache = NewCache[[2]string, tagResult]("tag", cmd.Int("cacheSize"))
+	equalityCache = NewCache[[2]string, cachedMatch]("equality", cmd.Int("cacheSize"))
 
 	return ctx, nil
 }
 
+// Logs hit and miss counts of the shared caches
+func logCaches(l *log.Logger) {
+	tagCache.log(l)
+	equalityCache.log(l)
+}
+
 // Helper func to read from corpus file, filter, and normalize
 func readInfile(cmd *cli.Command) ([]Text, error) {
 	var (
@@ -227,7 +736,6 @@ func readInfile(cmd *cli.Command) ([]Text, error) {
 		scanner   *bufio.Scanner
 		texts     []Text
 		tokenizer Tokenizer = setTokenizer(cmd)
-		model     *tag.PerceptronTagger
 		tagger    SyntacticTagger
 	)
 
@@ -238,43 +746,81 @@ func readInfile(cmd *cli.Command) ([]Text, error) {
 	defer file.Close()
 
 	scanner = bufio.NewScanner(file)
-	texts = ReadTexts(scanner)
+	texts = ReadCorpus(scanner)
+	logger, err := setLogger(cmd)
+	if err != nil {
+		return texts, fmt.Errorf("in readInFile():\n%+w", err)
+	}
+	if cmd.String("spoken") != "" {
+		tbl, err := setSpoken(cmd)
+		if err != nil {
+			return texts, fmt.Errorf("in readInFile():\n%+w", err)
+		}
+		texts = SpokenNormalize(texts, tbl, logger)
+	}
 	if cmd.Float64("filter") != 0.0 {
-		model = tag.NewPerceptronTagger()
-		tagger = NewSyntacticTagger(model, tokenizer)
-		texts = FilterTexts(texts, tagger, cmd.Float64("filter"))
+		tagger, err = setTagger(cmd)
+		if err != nil {
+			return texts, fmt.Errorf("in readInFile():\n%+w", err)
+		}
+		texts = FilterTexts(texts, tagger, cmd.Float64("filter"), cmd.Int("workers"))
 	}
-
-	for i := range texts {
-		texts[i].text = tokenizer.normalize(texts[i].text)
+	patterns, err := setClasses(cmd)
+	if err != nil {
+		return texts, fmt.Errorf("in readInFile():\n%+w", err)
+	}
+	// protected texts are only matched as text here, so no class rules are needed to resolve references
+	con, err := setConstraints(cmd, []Text{})
+	if err != nil {
+		return texts, fmt.Errorf("in readInFile():\n%+w", err)
 	}
+	texts = ClassFactor(texts, patterns, con, logger)
+
+	// class values become alternatives of class rules, so they are normalized like the texts holding them
+	texts = ParallelMap(texts, cmd.Int("workers"), func(t Text) Text {
+		t.text = tokenizer.normalize(t.text)
+		if t.classes != nil {
+			classes := make(map[string][]string, len(t.classes))
+			for k, v := range t.classes {
+				for i := range v {
+					classes[k] = append(classes[k], tokenizer.normalize(v[i]))
+				}
+			}
+			t.classes = classes
+		}
+		return t
+	})
 
 	return texts, err
 }
 
 // Helper function to apply chunking strategy to texts and convert to rules
-func applyChunking(texts []Text, cmd *cli.Command) []Rule {
+func applyChunking(texts []Text, cmd *cli.Command) ([]Rule, error) {
 	var (
 		chunks      []string
 		rules       []Rule
-		tokenizer   Tokenizer               = setTokenizer(cmd)
-		chunkfunc   TransitionSplitFunction = setChunk(cmd)
-		transitions                         = CollectTransitions(texts, chunkfunc)
+		tokenizer   Tokenizer = setTokenizer(cmd)
+		transitions Transitions
 	)
 
-	for i := range texts {
-		tokens := tokenizer.tokenize(texts[i].text)
-		texts[i].chunk = TransitionChunk(tokens, tokens, transitions, cmd.Float("prob"))
+	chunkfunc, err := setChunk(cmd)
+	if err != nil {
+		return rules, fmt.Errorf("in applyChunking():\n%+w", err)
 	}
+	transitions = CollectTransitions(texts, chunkfunc, cmd.Int("workers"))
+
+	texts = ParallelMap(texts, cmd.Int("workers"), func(t Text) Text {
+		tokens := tokenizer.tokenize(t.text)
+		t.chunk = TransitionChunk(tokens, tokens, transitions, cmd.Float("prob"))
+		return t
+	})
 	chunks = CollectChunks(texts)
-	for i := range texts {
-		texts[i] = ToTriplet(texts[i], chunks)
-	}
+	texts = ParallelMap(texts, cmd.Int("workers"), func(t Text) Text { return ToTriplet(t, chunks) })
 	for i := range texts {
 		rules = append(rules, ToRule(texts[i]))
 	}
 
-	return rules
+	return rules, nil
 }
 
 // Sets logging behavior based on cli flags
@@ -301,87 +847,556 @@ func setTokenizer(cmd *cli.Command) Tokenizer {
 	return NewWordTokenizer()
 }
 
+// Sets POS and constituency tagging behavior based on cli flags
+func setTagger(cmd *cli.Command) (SyntacticTagger, error) {
+	var (
+		tokenizer = setTokenizer(cmd)
+		model     = tag.NewPerceptronTagger()
+		tagger    = NewSyntacticTagger(model, tokenizer).WithCache(tagCache)
+	)
+
+	switch cmd.String("tagMap") {
+	case "":
+		return tagger, nil
+	case "universal":
+		return tagger.WithTagMap(UniversalTagMap), nil
+	default:
+		m, err := ReadTagMap(cmd.String("tagMap"))
+		if err != nil {
+			return tagger, fmt.Errorf("in setTagger():\n%+w", err)
+		}
+		return tagger.WithTagMap(m), nil
+	}
+}
+
+// Sets spoken form normalization table based on cli flags
+func setSpoken(cmd *cli.Command) (SpokenTable, error) {
+	tbl, ok := SpokenTables[cmd.String("spoken")]
+	if ok {
+		return tbl, nil
+	}
+	tbl, err := ReadSpokenTable(cmd.String("spoken"))
+	if err != nil {
+		return tbl, fmt.Errorf("in setSpoken():\n%+w", err)
+	}
+
+	return tbl, nil
+}
+
+// Sets pattern classes based on cli flags
+// user provided classes are applied first, followed by the default classes and named entities
+func setClasses(cmd *cli.Command) ([]PatternClass, error) {
+	var patterns []PatternClass
+
+	if cmd.String("classFile") != "" {
+		c, err := ReadClasses(cmd.String("classFile"))
+		if err != nil {
+			return patterns, fmt.Errorf("in setClasses():\n%+w", err)
+		}
+		patterns = append(patterns, c...)
+	}
+	if cmd.Bool("classes") {
+		patterns = append(patterns, DefaultClasses...)
+	}
+	if cmd.Bool("entities") {
+		tagger, err := setTagger(cmd)
+		if err != nil {
+			return patterns, fmt.Errorf("in setClasses():\n%+w", err)
+		}
+		patterns = append(patterns, EntityClass("entity", tagger))
+	}
+
+	return patterns, nil
+}
+
 // Sets text chunking behavior based on cli flags
-func setChunk(cmd *cli.Command) TransitionSplitFunction {
+func setChunk(cmd *cli.Command) (TransitionSplitFunction, error) {
 	tokenizer := setTokenizer(cmd)
 	switch cmd.String("chunk") {
 	case "posTag":
-		model := tag.NewPerceptronTagger()
-		tagger := NewSyntacticTagger(model, tokenizer)
-		return POSSplit(tagger)
+		tagger, err := setTagger(cmd)
+		if err != nil {
+			return TokenSplit(tokenizer), fmt.Errorf("in setChunk():\n%+w", err)
+		}
+		return POSSplit(tagger), nil
 	case "conTag":
-		model := tag.NewPerceptronTagger()
-		tagger := NewSyntacticTagger(model, tokenizer)
-		return ConstituencySplit(tagger)
+		tagger, err := setTagger(cmd)
+		if err != nil {
+			return TokenSplit(tokenizer), fmt.Errorf("in setChunk():\n%+w", err)
+		}
+		return ConstituencySplit(tagger), nil
 	default:
-		return TokenSplit(tokenizer)
+		return TokenSplit(tokenizer), nil
 	}
 }
 
 // Sets rule merging behavior based on cli flags
-func setMerge(cmd *cli.Command) (EqualityFunction, error) {
+// merge is either a single strategy or an expression combining strategies with AND, OR, NOT, and per strategy thresholds
+// all strategies other than literal matching are wrapped in the shared equality cache
+// texts and their chunked rules are used to fit corpus level models and precompute vectors for each expression group
+func setMerge(cmd *cli.Command, texts []Text, rules []Rule) (EqualityFunction, error) {
 	logger, err := setLogger(cmd)
 	if err != nil {
 		return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setMerge():\n%+w", err)
 	}
-	switch cmd.String("merge") {
+	expr, err := ParseMergeExpression(cmd.String("merge"))
+	if err != nil {
+		return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setMerge():\n%+w", err)
+	}
+
+	if expr.op == "" {
+		eq, err := setStrategy(cmd, expr.name, expr.similarity(cmd.Float64("sim")), texts, rules, logger)
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setMerge():\n%+w", err)
+		}
+		if expr.name == "literal" {
+			return eq, nil
+		}
+		return CachedEqual(func(e1, e2 []string) (bool, string) { return eq(e1, e2), expr.String() }, equalityCache, logger), nil
+	}
+
+	// sub-criteria are not logged individually, the expression logs which of them matched
+	eq, err := ExpressionEqual(expr, func(name string, sim float64) (EqualityFunction, error) {
+		return setStrategy(cmd, name, sim, texts, rules, nilLogger)
+	}, cmd.Float64("sim"), logger)
+	if err != nil {
+		return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setMerge():\n%+w", err)
+	}
+
+	return CachedEqual(eq, equalityCache, logger), nil
+}
+
+// Reads negative examples based on cli flags, normalized like the corpus, with class rules collected from texts available to references
+// if no negatives file is provided, the negatives are empty and accept every merge
+func setNegatives(cmd *cli.Command, texts []Text) (*Negatives, error) {
+	var (
+		err       error
+		s         []string
+		tokenizer = setTokenizer(cmd)
+	)
+
+	if cmd.String("negatives") == "" {
+		return NewNegatives(s, tokenizer, []Rule{}), nil
+	}
+	s, err = ReadNegatives(cmd.String("negatives"))
+	if err != nil {
+		return NewNegatives([]string{}, tokenizer, []Rule{}), fmt.Errorf("in setNegatives():\n%+w", err)
+	}
+	if cmd.String("spoken") != "" {
+		tbl, err := setSpoken(cmd)
+		if err != nil {
+			return NewNegatives([]string{}, tokenizer, []Rule{}), fmt.Errorf("in setNegatives():\n%+w", err)
+		}
+		for i := range s {
+			s[i], _ = SpokenForm(s[i], tbl)
+		}
+	}
+
+	return NewNegatives(s, tokenizer, ClassRules(texts)), nil
+}
+
+// Reads protected phrases and cannot-link pairs based on cli flags, normalized like the corpus, with class rules collected from texts available to references
+// if no constraints file is provided, the constraints are empty and accept every merge
+func setConstraints(cmd *cli.Command, texts []Text) (*Constraints, error) {
+	var (
+		err       error
+		spec      ConstraintSpec
+		tokenizer = setTokenizer(cmd)
+	)
+
+	if cmd.String("constraints") == "" {
+		return NewConstraints(spec, tokenizer, []Rule{}), nil
+	}
+	spec, err = ReadConstraints(cmd.String("constraints"))
+	if err != nil {
+		return NewConstraints(ConstraintSpec{}, tokenizer, []Rule{}), fmt.Errorf("in setConstraints():\n%+w", err)
+	}
+	if cmd.String("spoken") != "" {
+		tbl, err := setSpoken(cmd)
+		if err != nil {
+			return NewConstraints(ConstraintSpec{}, tokenizer, []Rule{}), fmt.Errorf("in setConstraints():\n%+w", err)
+		}
+		for i := range spec.Protected {
+			spec.Protected[i], _ = SpokenForm(spec.Protected[i], tbl)
+		}
+		for i := range spec.CannotLink {
+			for j := range spec.CannotLink[i] {
+				spec.CannotLink[i][j], _ = SpokenForm(spec.CannotLink[i][j], tbl)
+			}
+		}
+	}
+
+	return NewConstraints(spec, tokenizer, ClassRules(texts)), nil
+}
+
+// Splits held-out texts from the corpus based on cli flags, returning the texts to build the grammar from
+// if heldOut is unset, no texts are held out and the held-out set is empty
+func setHeldOut(cmd *cli.Command, texts []Text) ([]Text, *HeldOut) {
+	if cmd.Float64("heldOut") == 0 {
+		return texts, NewHeldOut([]Text{}, setTokenizer(cmd))
+	}
+	build, held := SplitHeldOut(texts, cmd.Float64("heldOut"))
+
+	return build, NewHeldOut(held, setTokenizer(cmd))
+}
+
+// Sets the guards checked by the merge functions for each combination of matched slots based on cli flags
+// merges breaking constraints are rejected ahead of all other guards, merges producing negatives are rejected, and the maxNovel and maxNovelGrammar flags guard every merge with a budget of novel productions relative to the productions of rules
+// with held-out utterances, single slot merges with novel productions must also cover held-out utterances not covered by rules
+func setMergeGuards(cmd *cli.Command, rules []Rule, con *Constraints, neg *Negatives, held *HeldOut, logger *log.Logger) map[string][]MergeGuard {
+	var (
+		m      = make(map[string][]MergeGuard)
+		budget *NovelBudget
+	)
+
+	if cmd.Float64("maxNovel") != 0 || cmd.Float64("maxNovelGrammar") != 0 {
+		var corpus int
+		for i := range rules {
+			corpus += Productions(rules[i])
+		}
+		budget = NewNovelBudget(cmd.Float64("maxNovel"), cmd.Float64("maxNovelGrammar"), corpus)
+	}
+	if held.Len() != 0 {
+		held.Cover(rules)
+	}
+	for _, slots := range mergeSlots {
+		if con.Len() != 0 {
+			m[slots] = append(m[slots], con.Guard(logger))
+		}
+		if neg.Len() != 0 {
+			m[slots] = append(m[slots], neg.Guard(logger))
+		}
+		switch {
+		case held.Len() != 0 && len(slots) == 1:
+			m[slots] = append(m[slots], held.Guard(budget, logger))
+		case budget != nil:
+			m[slots] = append(m[slots], budget.Guard(logger))
+		}
+	}
+
+	return m
+}
+
+// Adds a guard recording the productions not in the corpus introduced by each merge to the guards set for each combination of matched slots
+// the guards come after all other guards, so that only accepted merges are recorded
+func setExplainGuards(cmd *cli.Command, guards map[string][]MergeGuard, x *NovelExplainer, logger *log.Logger) (map[string][]MergeGuard, error) {
+	expr, err := ParseMergeExpression(cmd.String("merge"))
+	if err != nil {
+		return guards, fmt.Errorf("in setExplainGuards():\n%+w", err)
+	}
+	for _, slots := range mergeSlots {
+		guards[slots] = append(guards[slots], x.Guard(fmt.Sprintf("Merge%s", slots), expr.String(), slots, logger))
+	}
+
+	return guards, nil
+}
+
+// Adds the guards of a merge plan to the guards set for each combination of matched slots based on cli flags
+// apply-plan rejects merges not accepted in the plan ahead of all other guards, while other commands propose the merges accepted by all other guards in a new plan
+// returns a nil plan if no plan file is set
+func setPlan(cmd *cli.Command, guards map[string][]MergeGuard, logger *log.Logger) (*MergePlan, map[string][]MergeGuard, error) {
+	if cmd.String("plan") == "" {
+		return nil, guards, nil
+	}
+	if cmd.Name == "apply-plan" {
+		p, err := ReadPlan(cmd.String("plan"))
+		if err != nil {
+			return p, guards, fmt.Errorf("in setPlan():\n%+w", err)
+		}
+		for _, slots := range mergeSlots {
+			guards[slots] = append([]MergeGuard{p.Guard(fmt.Sprintf("Merge%s", slots), logger)}, guards[slots]...)
+		}
+		return p, guards, nil
+	}
+
+	expr, err := ParseMergeExpression(cmd.String("merge"))
+	if err != nil {
+		return nil, guards, fmt.Errorf("in setPlan():\n%+w", err)
+	}
+	similarities = NewSimilarities()
+	p := NewMergePlan(cmd)
+	for _, slots := range mergeSlots {
+		guards[slots] = append(guards[slots], p.Propose(fmt.Sprintf("Merge%s", slots), expr.String(), slots, logger))
+	}
+
+	return p, guards, nil
+}
+
+// Sets the merge functions for each combination of matched slots based on cli flags, each checking the guards set for its slots
+// by default neighbouring rules are merged after sorting, with literal matching grouping rules by hashed slot keys wherever the sort would place them together
+// the cluster and block flags compare all pairs of rules, or all pairs within each block, instead
+func setMergeFunctions(cmd *cli.Command, guards map[string][]MergeGuard) (map[string]MergeFunction, error) {
+	var (
+		m       = map[string]MergeFunction{"PR": MergePR, "PS": MergePS, "RS": MergeRS, "P": MergeP, "R": MergeR, "S": MergeS}
+		linkage = cmd.String("cluster")
+		block   = NoBlocking
+	)
+
+	expr, err := ParseMergeExpression(cmd.String("merge"))
+	if err != nil {
+		return m, fmt.Errorf("in setMergeFunctions():\n%+w", err)
+	}
+	switch cmd.String("block") {
+	case "token":
+		block = TokenBlocking(setTokenizer(cmd))
+	case "minhash":
+		block = MinHashBlocking(3)
+	case "length":
+		block = LengthBlocking(setTokenizer(cmd), 1)
+	}
+	if cmd.String("block") != "" && linkage == "" {
+		linkage = "single"
+	}
+
+	for slots := range m {
+		switch {
+		// literal matches are transitive, so every linkage gives the same clusters as grouping by key
+		case expr.op == "" && expr.name == "literal" && (linkage != "" || !slices.Contains([]string{"R", "S"}, slots)):
+			m[slots] = HashMerge(slots)
+		case linkage != "":
+			m[slots] = ClusterMerge(slots, linkage, block)
+		}
+	}
+
+	for slots, f := range m {
+		if len(guards[slots]) == 0 {
+			continue
+		}
+		m[slots] = func(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
+			return f(r, e, l, append(g, guards[slots]...)...)
+		}
+	}
+
+	return m, nil
+}
+
+// Sets up a single named merge strategy with similarity threshold sim
+func setStrategy(cmd *cli.Command, name string, sim float64, texts []Text, rules []Rule, logger *log.Logger) (EqualityFunction, error) {
+	var err error
+
+	switch name {
 	case "charDistance":
-		return CharacterLevenshteinThreshold(cmd.Float64("sim"), logger), nil
+		return CharacterLevenshteinThreshold(sim, logger), nil
 	case "tokenDistance":
-		return TokenLevenshteinThreshold(cmd.Float64("sim"), logger), nil
+		return TokenLevenshteinThreshold(sim, logger), nil
 	case "tfidf":
 		tokenizer := setTokenizer(cmd)
-		texts, err := readInfile(cmd)
+		if cmd.Bool("lemmaVocab") {
+			lem, err := setLemmatizer(cmd)
+			if err != nil {
+				return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+			}
+			tokenizer = NewLemmaTokenizer(tokenizer, lem)
+		}
+		m := NewTFIDFModel(texts, tokenizer, cmd.String("weighting"), cmd.Int("workers"))
+		m.Precompute(slotStrings(rules), cmd.Int("workers"))
+		return TFIDFCosineThreshold(sim, m, logger), nil
+	case "charTfidf":
+		if cmd.Int("ngramMin") > cmd.Int("ngramMax") {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", fmt.Errorf("ngramMin must not be greater than ngramMax"))
+		}
+		tokenizer := NewCharNGramTokenizer(setTokenizer(cmd), cmd.Int("ngramMin"), cmd.Int("ngramMax"))
+		m := NewTFIDFModel(texts, tokenizer, cmd.String("weighting"), cmd.Int("workers"))
+		m.Precompute(slotStrings(rules), cmd.Int("workers"))
+		return TFIDFCosineThreshold(sim, m, logger), nil
+	case "jaroWinkler":
+		return JaroWinklerThreshold(sim, logger), nil
+	case "jaccard":
+		return TokenJaccardThreshold(sim, logger), nil
+	case "dice":
+		return SorensenDiceThreshold(sim, logger), nil
+	case "ngram":
+		return CharacterNGramThreshold(sim, cmd.Int("ngram"), logger), nil
+	case "lcs":
+		return LCSRatioThreshold(sim, logger), nil
+	case "vectors":
+		if cmd.String("vectors") == "" {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", fmt.Errorf("vectors merge strategy requires a vectors file"))
+		}
+		w, err := LoadWordVectors(cmd.String("vectors"))
 		if err != nil {
-			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setMerge():\n%+w", err)
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
 		}
-		v := CollectVocab(texts, tokenizer)
-		idf := CollectIDF(texts, tokenizer)
-		return TFIDFCosineThreshold(cmd.Float64("sim"), v, tokenizer, idf, logger), nil
-	case "posTag":
 		tokenizer := setTokenizer(cmd)
-		model := tag.NewPerceptronTagger()
-		tagger := NewSyntacticTagger(model, tokenizer)
+		var weights map[string]float64
+		if cmd.Bool("sif") {
+			weights = CollectSIFWeights(texts, tokenizer, 0.001, cmd.Int("workers"))
+		}
+		return WordVectorCosineThreshold(sim, w, tokenizer, weights, logger), nil
+	case "embed":
+		embeddingClient, err = NewEmbeddingClient(cmd.String("endpoint"), cmd.String("embedModel"), cmd.Int("embedBatch"), cmd.String("embedCache"))
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+		}
+		// embeds every expression group in the corpus up front, which batches requests and fails early if the endpoint is unreachable
+		_, err = embeddingClient.Embed(slotStrings(rules))
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+		}
+		return EndpointCosineThreshold(sim, embeddingClient, logger), nil
+	case "lemma":
+		lem, err := setLemmatizer(cmd)
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+		}
+		return LemmaEqual(setTokenizer(cmd), lem, logger), nil
+	case "posDistance":
+		tagger, err := setTagger(cmd)
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+		}
+		costs := DefaultEditCosts
+		if cmd.String("costs") != "" {
+			costs, err = ReadEditCosts(cmd.String("costs"))
+			if err != nil {
+				return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+			}
+		}
+		return POSWeightedThreshold(sim, tagger, costs, logger), nil
+	case "soundex":
+		return SoundexEqual(logger), nil
+	case "metaphone":
+		return MetaphoneEqual(logger), nil
+	case "phoneme":
+		if cmd.String("cmudict") == "" {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", fmt.Errorf("phoneme merge strategy requires a cmudict file"))
+		}
+		pron, err := ReadPronunciations(cmd.String("cmudict"))
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+		}
+		return PhonemeThreshold(sim, pron, logger), nil
+	case "wordnet":
+		w, err := setWordNet(cmd)
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+		}
+		tagger, err := setTagger(cmd)
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+		}
+		return WordNetEqual(w, tagger, cmd.Int("wnSenses"), logger), nil
+	case "posTag":
+		tagger, err := setTagger(cmd)
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+		}
 		return POSTagEqual(tagger, logger), nil
 	case "conTag":
-		tokenizer := setTokenizer(cmd)
-		model := tag.NewPerceptronTagger()
-		tagger := NewSyntacticTagger(model, tokenizer)
+		tagger, err := setTagger(cmd)
+		if err != nil {
+			return func(e1, e2 []string) bool { return false }, fmt.Errorf("in setStrategy():\n%+w", err)
+		}
 		return ConstituencyTagEqual(tagger, logger), nil
 	default:
 		return LiteralEqual(logger), nil
 	}
 }
 
+// Collects the joined prefix, root, and suffix of each rule, leaving out empty expression groups
+func slotStrings(r []Rule) []string {
+	var out []string
+
+	for i := range r {
+		for _, s := range []string{strings.Join(r[i].pre, " "), strings.Join(r[i].root, " "), strings.Join(r[i].suf, " ")} {
+			if s != "" {
+				out = append(out, s)
+			}
+		}
+	}
+
+	return out
+}
+
+// Reports any error raised by the embeddings endpoint during merging
+func checkEndpoint() error {
+	if embeddingClient == nil {
+		return nil
+	}
+	err := embeddingClient.Err()
+	if err != nil {
+		return fmt.Errorf("in checkEndpoint():\n%+w", err)
+	}
+
+	return nil
+}
+
 // Sets rule factoring behavior based on cli flags
 func setFactor(cmd *cli.Command) (FactorFunction, error) {
 	logger, err := setLogger(cmd)
 	if err != nil {
-		return func(r []Rule) []Rule { return r }, fmt.Errorf("in setFactor():\n%+w", err)
+		return func(r []Rule, g ...MergeGuard) []Rule { return r }, fmt.Errorf("in setFactor():\n%+w", err)
 	}
 	if cmd.Bool("conFactor") {
-		tokenizer := setTokenizer(cmd)
-		model := tag.NewPerceptronTagger()
-		tagger := NewSyntacticTagger(model, tokenizer)
+		tagger, err := setTagger(cmd)
+		if err != nil {
+			return func(r []Rule, g ...MergeGuard) []Rule { return r }, fmt.Errorf("in setFactor():\n%+w", err)
+		}
 		return ConstituencyFactor(tagger, cmd.Int("factorN"), logger), nil
 	}
 	return ExpressionFactor(cmd.Int("factorN"), logger), nil
 }
 
 // Sets synonym expansion behavior based on cli flags
-func setSynonyms(cmd *cli.Command) (FactorFunction, error) {
+// synonyms from synFile and from WordNet are combined
+func setSynonyms(cmd *cli.Command, texts []Text) (FactorFunction, error) {
 	logger, err := setLogger(cmd)
 	if err != nil {
-		return func(r []Rule) []Rule { return r }, fmt.Errorf("in setSynonyms():\n%+w", err)
+		return func(r []Rule, g ...MergeGuard) []Rule { return r }, fmt.Errorf("in setSynonyms():\n%+w", err)
 	}
-	if cmd.String("synFile") == "" {
-		return func(r []Rule) []Rule { return r }, nil
+	if cmd.String("synFile") == "" && cmd.String("wnSyn") == "" {
+		return func(r []Rule, g ...MergeGuard) []Rule { return r }, nil
 	}
-	syn, err := ReadSynonyms(cmd.String("synFile"))
-	if err != nil {
-		return func(r []Rule) []Rule { return r }, fmt.Errorf("in setSynonyms():\n%+w", err)
+	syn := Synonyms{}
+	if cmd.String("synFile") != "" {
+		syn, err = ReadSynonyms(cmd.String("synFile"))
+		if err != nil {
+			return func(r []Rule, g ...MergeGuard) []Rule { return r }, fmt.Errorf("in setSynonyms():\n%+w", err)
+		}
 	}
 	tokenizer := setTokenizer(cmd)
+	if cmd.String("wnSyn") != "" {
+		w, err := setWordNet(cmd)
+		if err != nil {
+			return func(r []Rule, g ...MergeGuard) []Rule { return r }, fmt.Errorf("in setSynonyms():\n%+w", err)
+		}
+		tagger, err := setTagger(cmd)
+		if err != nil {
+			return func(r []Rule, g ...MergeGuard) []Rule { return r }, fmt.Errorf("in setSynonyms():\n%+w", err)
+		}
+		targets := strings.Split(cmd.String("wnSyn"), ",")
+		for k, v := range WordNetSynonyms(w, texts, tagger, targets, cmd.Int("wnSenses"), cmd.Int("wnDepth"), cmd.Int("workers")) {
+			if _, ok := syn[k]; !ok {
+				syn[k] = v
+			}
+		}
+	}
 
 	return SynonymFactor(syn, tokenizer, logger), nil
 }
+
+// Sets the lemmatizer based on cli flags, dictionary lemmatizers fall back to the english stemmer
+func setLemmatizer(cmd *cli.Command) (Lemmatizer, error) {
+	if lem, ok := Stemmers[cmd.String("lemma")]; ok {
+		return lem, nil
+	}
+	lemmas, err := ReadLemmas(cmd.String("lemma"))
+	if err != nil {
+		return PorterStemmer{}, fmt.Errorf("in setLemmatizer():\n%+w", err)
+	}
+
+	return NewDictionaryLemmatizer(lemmas, Stemmers["en"]), nil
+}
+
+// Loads the WordNet database set by the wordnet flag
+func setWordNet(cmd *cli.Command) (*WordNet, error) {
+	if cmd.String("wordnet") == "" {
+		return nil, fmt.Errorf("in setWordNet():\n%+w", fmt.Errorf("wordnet directory is required"))
+	}
+	w, err := LoadWordNet(cmd.String("wordnet"))
+	if err != nil {
+		return nil, fmt.Errorf("in setWordNet():\n%+w", err)
+	}
+
+	return w, nil
+}
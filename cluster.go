@@ -0,0 +1,283 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:16:29 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"slices"
+	"strings"
+)
+
+// Function that merges rules whose expression groups are considered equivalent by an equality function, if all guards allow the merge
+type MergeFunction func(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule
+
+// Combinations of matched slots with a merge function, in the order they are applied
+var mergeSlots = []string{"PR", "PS", "RS", "P", "R", "S"}
+
+// Groups rule indices into blocks based on the matched expression groups of each rule, only pairs of rules sharing a block are compared
+type BlockingFunction func(keys []string) [][]int
+
+// Linkage criteria supported by ClusterMerge
+var linkages = []string{"single", "complete", "average"}
+
+// Places all rules in a single block, comparing every pair
+func NoBlocking(keys []string) [][]int {
+	block := make([]int, len(keys))
+	for i := range keys {
+		block[i] = i
+	}
+
+	return [][]int{block}
+}
+
+// Disjoint set forest over rule indices, with path compression
+type unionFind []int
+
+func newUnionFind(n int) unionFind {
+	u := make(unionFind, n)
+	for i := range u {
+		u[i] = i
+	}
+
+	return u
+}
+
+func (u unionFind) find(i int) int {
+	for u[i] != i {
+		u[i] = u[u[i]]
+		i = u[i]
+	}
+
+	return i
+}
+
+// Joins the sets containing i and j, keeping the smaller index as the set representative
+func (u unionFind) union(i, j int) {
+	i, j = u.find(i), u.find(j)
+	switch {
+	case i < j:
+		u[j] = i
+	case j < i:
+		u[i] = j
+	}
+}
+
+// Collects the sets of the forest, each sorted and ordered by their smallest index
+func (u unionFind) sets() [][]int {
+	var (
+		out   [][]int
+		index = make(map[int]int)
+	)
+
+	for i := range u {
+		root := u.find(i)
+		ind, ok := index[root]
+		if !ok {
+			ind = len(out)
+			index[root] = ind
+			out = append(out, []int{})
+		}
+		out[ind] = append(out[ind], i)
+	}
+
+	return out
+}
+
+// Splits a connected set of rules into clusters by agglomerative clustering over matched pairs
+// complete linkage only joins clusters if all pairs between them match, average linkage if at least half of them do
+// at each step the two clusters with the highest share of matching pairs are joined, ties going to the clusters with the smallest indices
+func agglomerate(set []int, matched map[[2]int]bool, linkage string) [][]int {
+	clusters := make([][]int, len(set))
+	for i := range set {
+		clusters[i] = []int{set[i]}
+	}
+
+	for {
+		var (
+			best      = -1.0
+			bestA     int
+			bestB     int
+			threshold = 0.5
+		)
+		if linkage == "complete" {
+			threshold = 1.0
+		}
+		for a := 0; a < len(clusters); a++ {
+			for b := a + 1; b < len(clusters); b++ {
+				var n int
+				for _, i := range clusters[a] {
+					for _, j := range clusters[b] {
+						if matched[[2]int{min(i, j), max(i, j)}] {
+							n++
+						}
+					}
+				}
+				score := float64(n) / float64(len(clusters[a])*len(clusters[b]))
+				if score >= threshold && score > best {
+					best, bestA, bestB = score, a, b
+				}
+			}
+		}
+		if best < 0 {
+			return clusters
+		}
+		clusters[bestA] = append(clusters[bestA], clusters[bestB]...)
+		slices.Sort(clusters[bestA])
+		clusters = slices.Delete(clusters, bestB, bestB+1)
+	}
+}
+
+// Helper function to combine the alternatives of an expression group across rules
+func unionSlot(r []Rule, slot func(Rule) []string) []string {
+	var out []string
+
+	for i := range r {
+		out = append(out, slot(r[i])...)
+	}
+	slices.Sort(out)
+
+	return slices.Compact(out)
+}
+
+// Merge rules by clustering on equality function matches in the slots named by slots, any combination of P, R, and S
+// all pairs of rules within each block are compared, and clusters are formed with single (union-find), complete, or average linkage
+// rules are put into a canonical order first, so the result does not depend on the order of the input, and rules with identical matched slots are always merged
+// merged rules take their matched slots from the first rule of their cluster and combine the alternatives of their other slots
+// members of a cluster are merged in order, and members rejected by a guard are kept separately
+func ClusterMerge(slots string, linkage string, block BlockingFunction) MergeFunction {
+	var (
+		name   = fmt.Sprintf("ClusterMerge%s", slots)
+		getter = map[rune]func(Rule) []string{
+			'P': func(r Rule) []string { return r.pre },
+			'R': func(r Rule) []string { return r.root },
+			'S': func(r Rule) []string { return r.suf },
+		}
+		matched []func(Rule) []string
+	)
+	for _, s := range slots {
+		matched = append(matched, getter[s])
+	}
+
+	return func(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
+		check := func(r1 Rule, r2 Rule) bool {
+			for i := range matched {
+				if !e(matched[i](r1), matched[i](r2)) {
+					return false
+				}
+			}
+			return true
+		}
+		merge := func(rules []Rule) Rule {
+			rule := Rule{
+				pre:      unionSlot(rules, getter['P']),
+				root:     unionSlot(rules, getter['R']),
+				suf:      unionSlot(rules, getter['S']),
+				isPublic: true,
+				lines:    unionLines(rules...),
+			}
+			if strings.ContainsRune(slots, 'P') {
+				rule.pre = rules[0].pre
+			}
+			if strings.ContainsRune(slots, 'R') {
+				rule.root = rules[0].root
+			}
+			if strings.ContainsRune(slots, 'S') {
+				rule.suf = rules[0].suf
+			}
+			return rule
+		}
+
+		SortPRS(r)
+		// rules with identical matched slots are always merged, and only one of them is compared with other rules
+		var (
+			keys    []string
+			members [][]int
+			index   = make(map[string]int)
+		)
+		for i := range r {
+			k := slotKey(r[i], slots)
+			ind, ok := index[k]
+			if !ok {
+				var g []string
+				for j := range matched {
+					g = append(g, strings.Join(matched[j](r[i]), " "))
+				}
+				ind = len(keys)
+				index[k] = ind
+				keys = append(keys, strings.Join(g, " "))
+				members = append(members, []int{})
+			}
+			members[ind] = append(members[ind], i)
+		}
+
+		var (
+			u     = newUnionFind(len(keys))
+			pairs = make(map[[2]int]bool)
+		)
+		for _, b := range block(keys) {
+			for x := range b {
+				for y := x + 1; y < len(b); y++ {
+					i, j := min(b[x], b[y]), max(b[x], b[y])
+					if _, ok := pairs[[2]int{i, j}]; ok {
+						continue
+					}
+					pairs[[2]int{i, j}] = check(r[members[i][0]], r[members[j][0]])
+					if pairs[[2]int{i, j}] {
+						u.union(i, j)
+					}
+				}
+			}
+		}
+
+		var clusters [][]int
+		for _, set := range u.sets() {
+			if linkage != "single" && len(set) > 2 {
+				clusters = append(clusters, agglomerate(set, pairs, linkage)...)
+				continue
+			}
+			clusters = append(clusters, set)
+		}
+		for i := range clusters {
+			var c []int
+			for _, k := range clusters[i] {
+				c = append(c, members[k]...)
+			}
+			slices.Sort(c)
+			clusters[i] = c
+		}
+		slices.SortFunc(clusters, func(c1, c2 []int) int { return c1[0] - c2[0] })
+
+		out := make([]Rule, 0, len(clusters))
+		for _, c := range clusters {
+			if len(c) == 1 {
+				out = append(out, r[c[0]])
+				continue
+			}
+			var (
+				rule     = r[c[0]]
+				members  = []Rule{rule}
+				rejected []Rule
+			)
+			for _, i := range c[1:] {
+				merged := merge([]Rule{rule, r[i]})
+				if !allowMerge(g, rule, r[i], merged) {
+					rejected = append(rejected, r[i])
+					continue
+				}
+				rule = merged
+				members = append(members, r[i])
+			}
+			if len(members) > 1 {
+				l.Printf("merge function %s replaced %v with new rule %v\n", name, members, rule)
+			}
+			out = append(out, rule)
+			out = append(out, rejected...)
+		}
+
+		return out
+	}
+}
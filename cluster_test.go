@@ -0,0 +1,143 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:16:29 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Matches expression groups ending in the same character
+func lastCharEqual(e1, e2 []string) bool {
+	s1, s2 := strings.Join(e1, " "), strings.Join(e2, " ")
+	return s1 != "" && s2 != "" && s1[len(s1)-1] == s2[len(s2)-1]
+}
+
+// Matches expression groups listed as pairs, a chain in which a~b and b~c but not a~c
+func chainEqual(e1, e2 []string) bool {
+	pairs := map[string]bool{"a": true, "a b": true, "b a": true, "b c": true, "c b": true, "b": true, "c": true}
+	return pairs[strings.Join(slices.Concat(e1, e2), " ")]
+}
+
+func TestNoBlocking(t *testing.T) {
+	assert.Equal(t, [][]int{{}}, NoBlocking([]string{}))
+	assert.Equal(t, [][]int{{0, 1, 2}}, NoBlocking([]string{"a", "b", "c"}))
+}
+
+func Test_unionFind(t *testing.T) {
+	u := newUnionFind(6)
+	u.union(4, 1)
+	u.union(5, 4)
+	u.union(2, 3)
+	u.union(3, 2)
+
+	assert.Equal(t, 1, u.find(5))
+	assert.Equal(t, [][]int{{0}, {1, 4, 5}, {2, 3}}, u.sets())
+}
+
+func Test_agglomerate(t *testing.T) {
+	type args struct {
+		set     []int
+		matched map[[2]int]bool
+		linkage string
+	}
+	chain := map[[2]int]bool{{0, 1}: true, {1, 2}: true}
+	tests := []struct {
+		args args
+		want [][]int
+	}{
+		{args: args{set: []int{0, 1, 2}, matched: chain, linkage: "complete"}, want: [][]int{{0, 1}, {2}}},
+		{args: args{set: []int{0, 1, 2}, matched: chain, linkage: "average"}, want: [][]int{{0, 1, 2}}},
+		{args: args{set: []int{0, 1, 2, 3}, matched: map[[2]int]bool{{0, 1}: true, {2, 3}: true, {1, 2}: true}, linkage: "average"}, want: [][]int{{0, 1}, {2, 3}}},
+		{args: args{set: []int{0, 1, 2}, matched: map[[2]int]bool{{0, 1}: true, {0, 2}: true, {1, 2}: true}, linkage: "complete"}, want: [][]int{{0, 1, 2}}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, agglomerate(tt.args.set, tt.args.matched, tt.args.linkage))
+		})
+	}
+}
+
+func TestClusterMerge(t *testing.T) {
+	type args struct {
+		slots   string
+		linkage string
+		e       EqualityFunction
+		r       []Rule
+	}
+	tests := []struct {
+		args args
+		want []Rule
+	}{
+		{args: args{slots: "P", linkage: "single", e: lastCharEqual, r: []Rule{}}, want: []Rule{}},
+		// ax and bx are not neighbours after sorting
+		{
+			args: args{slots: "P", linkage: "single", e: lastCharEqual, r: []Rule{
+				{pre: []string{"ax"}, root: []string{"1"}, suf: []string{}},
+				{pre: []string{"ay"}, root: []string{"2"}, suf: []string{}},
+				{pre: []string{"bx"}, root: []string{"3"}, suf: []string{"s"}},
+			}},
+			want: []Rule{
+				{pre: []string{"ax"}, root: []string{"1", "3"}, suf: []string{"s"}, isPublic: true},
+				{pre: []string{"ay"}, root: []string{"2"}, suf: []string{}},
+			},
+		},
+		{
+			args: args{slots: "PR", linkage: "single", e: lastCharEqual, r: []Rule{
+				{pre: []string{"ax"}, root: []string{"1"}, suf: []string{"s"}},
+				{pre: []string{"ay"}, root: []string{"1"}, suf: []string{"t"}},
+				{pre: []string{"bx"}, root: []string{"21"}, suf: []string{"u"}},
+			}},
+			want: []Rule{
+				{pre: []string{"ax"}, root: []string{"1"}, suf: []string{"s", "u"}, isPublic: true},
+				{pre: []string{"ay"}, root: []string{"1"}, suf: []string{"t"}},
+			},
+		},
+		{
+			args: args{slots: "S", linkage: "single", e: chainEqual, r: []Rule{
+				{pre: []string{"x"}, root: []string{"1"}, suf: []string{"c"}},
+				{pre: []string{"y"}, root: []string{"2"}, suf: []string{"a"}},
+				{pre: []string{"z"}, root: []string{"3"}, suf: []string{"b"}},
+			}},
+			want: []Rule{
+				{pre: []string{"x", "y", "z"}, root: []string{"1", "2", "3"}, suf: []string{"c"}, isPublic: true},
+			},
+		},
+		{
+			args: args{slots: "S", linkage: "complete", e: chainEqual, r: []Rule{
+				{pre: []string{"x"}, root: []string{"1"}, suf: []string{"c"}},
+				{pre: []string{"y"}, root: []string{"2"}, suf: []string{"a"}},
+				{pre: []string{"z"}, root: []string{"3"}, suf: []string{"b"}},
+			}},
+			want: []Rule{
+				{pre: []string{"x", "z"}, root: []string{"1", "3"}, suf: []string{"c"}, isPublic: true},
+				{pre: []string{"y"}, root: []string{"2"}, suf: []string{"a"}},
+			},
+		},
+		{
+			args: args{slots: "S", linkage: "average", e: chainEqual, r: []Rule{
+				{pre: []string{"x"}, root: []string{"1"}, suf: []string{"c"}},
+				{pre: []string{"y"}, root: []string{"2"}, suf: []string{"a"}},
+				{pre: []string{"z"}, root: []string{"3"}, suf: []string{"b"}},
+			}},
+			want: []Rule{
+				{pre: []string{"x", "y", "z"}, root: []string{"1", "2", "3"}, suf: []string{"c"}, isPublic: true},
+			},
+		},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := ClusterMerge(tt.args.slots, tt.args.linkage, NoBlocking)(slices.Clone(tt.args.r), tt.args.e, nilLogger)
+			assert.Equal(t, tt.want, got)
+			// the result does not depend on input order
+			slices.Reverse(tt.args.r)
+			assert.Equal(t, tt.want, ClusterMerge(tt.args.slots, tt.args.linkage, NoBlocking)(tt.args.r, tt.args.e, nilLogger))
+		})
+	}
+}
@@ -0,0 +1,185 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 03:24:10 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+)
+
+// Phrases pinned so that rules holding them are never merged or factored, and pairs of phrases which must never be linked in one rule
+type ConstraintSpec struct {
+	Protected  []string    `json:"protected"`
+	CannotLink [][2]string `json:"cannotLink"`
+}
+
+// Reads protected phrases and cannot-link pairs from a json file
+func ReadConstraints(p string) (ConstraintSpec, error) {
+	var spec ConstraintSpec
+
+	file, err := os.Open(p)
+	if err != nil {
+		return spec, err
+	}
+	defer file.Close()
+	dec := json.NewDecoder(file)
+	dec.DisallowUnknownFields()
+	err = dec.Decode(&spec)
+
+	return spec, err
+}
+
+// Constraints checked by every merge and factoring step
+// protected phrases match rules producing them as whole utterances, or holding them within an expression, and cannot-link phrases match rules holding them within an expression
+type Constraints struct {
+	*Utterances
+	protected  []string
+	cannotLink [][2]string
+}
+
+// Normalizes protected phrases and cannot-link pairs with tokenizer tok, references in rules are resolved using refs
+func NewConstraints(spec ConstraintSpec, tok Tokenizer, refs []Rule) *Constraints {
+	c := Constraints{Utterances: NewUtterances(spec.Protected, tok, refs)}
+
+	for _, p := range spec.Protected {
+		if p = tok.normalize(p); p != "" && !slices.Contains(c.protected, p) {
+			c.protected = append(c.protected, p)
+		}
+	}
+	for _, l := range spec.CannotLink {
+		a, b := tok.normalize(l[0]), tok.normalize(l[1])
+		if a != "" && b != "" && a != b {
+			c.cannotLink = append(c.cannotLink, [2]string{a, b})
+		}
+	}
+
+	return &c
+}
+
+// Counts protected phrases and cannot-link pairs
+func (c *Constraints) Len() int {
+	return len(c.protected) + len(c.cannotLink)
+}
+
+// Helper function to collect the protected and cannot-link phrases held within the expressions of a rule, callers must hold the lock
+// references are replaced by the phrases held by the referenced rule
+func (c *Constraints) mentions(r Rule, depth int) map[string]bool {
+	var (
+		out     = make(map[string]bool)
+		phrases = slices.Clone(c.protected)
+	)
+
+	for _, l := range c.cannotLink {
+		phrases = append(phrases, l[0], l[1])
+	}
+	for _, g := range [][]string{r.pre, r.root, r.suf} {
+		for a := range slotSet(g) {
+			for _, alt := range splitAlternatives(a) {
+				padded := fmt.Sprintf(" %s ", c.tok.normalize(alt))
+				for _, p := range phrases {
+					if strings.Contains(padded, fmt.Sprintf(" %s ", p)) {
+						out[p] = true
+					}
+				}
+				if !strings.Contains(alt, "<") || depth >= maxReferenceDepth {
+					continue
+				}
+				for _, t := range strings.Fields(alt) {
+					if ref, ok := c.refs[strings.TrimSuffix(strings.TrimPrefix(t, "<"), ">")]; ok {
+						maps.Copy(out, c.mentions(ref, depth+1))
+					}
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// Returns the protected phrase held by a text, or an empty string if the text is not protected
+// a nil Constraints protects nothing
+func (c *Constraints) protectsText(s string) string {
+	if c == nil {
+		return ""
+	}
+	padded := fmt.Sprintf(" %s ", c.tok.normalize(s))
+	for _, p := range c.protected {
+		if strings.Contains(padded, fmt.Sprintf(" %s ", p)) {
+			return p
+		}
+	}
+
+	return ""
+}
+
+// Returns the protected phrase matching a rule, or an empty string if the rule is not protected
+func (c *Constraints) protects(r Rule) string {
+	c.mu.Lock()
+	m := c.mentions(r, 0)
+	c.mu.Unlock()
+	for _, p := range c.protected {
+		if m[p] {
+			return p
+		}
+	}
+	if p := c.Produced(r); len(p) != 0 {
+		return p[0]
+	}
+
+	return ""
+}
+
+// Guard rejecting merges and factoring steps which change a protected rule, or link both phrases of a cannot-link pair in a rule where neither replaced rule linked them
+// non public rules passed as r2 are factored out rules, and are registered so that references to them can be resolved
+func (c *Constraints) Guard(l *log.Logger) MergeGuard {
+	return func(r1, r2, merged Rule) bool {
+		if c.Len() == 0 {
+			return true
+		}
+		replaced := []Rule{r1}
+		if r2.isPublic {
+			replaced = append(replaced, r2)
+		} else {
+			c.mu.Lock()
+			if _, ok := c.refs[r2.name()]; !ok {
+				c.register(r2)
+			}
+			c.mu.Unlock()
+			if slices.Equal(r1.pre, merged.pre) && slices.Equal(r1.root, merged.root) && slices.Equal(r1.suf, merged.suf) {
+				return true
+			}
+		}
+
+		for _, r := range replaced {
+			if p := c.protects(r); p != "" {
+				l.Printf("merge guard %s rejected merging %v and %v, rule %v is protected by %v\n", "Constraints", r1, r2, r, p)
+				return false
+			}
+		}
+
+		var before []map[string]bool
+		c.mu.Lock()
+		for _, r := range replaced {
+			before = append(before, c.mentions(r, 0))
+		}
+		after := c.mentions(merged, 0)
+		c.mu.Unlock()
+		for _, p := range c.cannotLink {
+			if !after[p[0]] || !after[p[1]] || slices.ContainsFunc(before, func(m map[string]bool) bool { return m[p[0]] && m[p[1]] }) {
+				continue
+			}
+			l.Printf("merge guard %s rejected merging %v and %v, new rule %v links %v and %v which must not be linked\n", "Constraints", r1, r2, merged, p[0], p[1])
+			return false
+		}
+
+		return true
+	}
+}
@@ -0,0 +1,77 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 03:24:10 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadConstraints(t *testing.T) {
+	got, err := ReadConstraints("./data/tests/constraints1.json")
+	assert.Nil(t, err)
+	assert.Equal(t, ConstraintSpec{Protected: []string{"I don't have an online account", "premium account"}, CannotLink: [][2]string{{"cancel", "delete"}, {"online account", "user account"}}}, got)
+
+	_, err = ReadConstraints("./data/tests/missing.json")
+	assert.NotNil(t, err)
+	p := filepath.Join(t.TempDir(), "constraints.json")
+	os.WriteFile(p, []byte(`{"pinned": ["premium account"]}`), 0644)
+	_, err = ReadConstraints(p)
+	assert.NotNil(t, err)
+}
+
+func TestNewConstraints(t *testing.T) {
+	c := NewConstraints(ConstraintSpec{Protected: []string{"premium account?", "premium account ?", ""}, CannotLink: [][2]string{{"cancel", "delete"}, {"cancel", "cancel"}, {"", "delete"}}}, NewWordTokenizer(), []Rule{})
+
+	assert.Equal(t, []string{"premium account ?"}, c.protected)
+	assert.Equal(t, [][2]string{{"cancel", "delete"}}, c.cannotLink)
+	assert.Equal(t, 2, c.Len())
+	assert.Equal(t, 0, NewConstraints(ConstraintSpec{}, NewWordTokenizer(), []Rule{}).Len())
+}
+
+func TestConstraints_Guard(t *testing.T) {
+	var (
+		c = NewConstraints(ConstraintSpec{Protected: []string{"can i cancel my bill", "premium account"}, CannotLink: [][2]string{{"cancel", "delete"}}}, NewWordTokenizer(), []Rule{})
+		g = c.Guard(nilLogger)
+		// produces a protected utterance
+		r1 = Rule{pre: []string{"can i cancel"}, root: []string{"my bill"}, suf: []string{}, isPublic: true}
+		r2 = Rule{pre: []string{"can i pay"}, root: []string{"my bill"}, suf: []string{}, isPublic: true}
+		// holds a protected chunk
+		r3 = Rule{pre: []string{"can i open a"}, root: []string{"premium account"}, suf: []string{}, isPublic: true}
+		r4 = Rule{pre: []string{"can i cancel"}, root: []string{"my order"}, suf: []string{}, isPublic: true}
+		r5 = Rule{pre: []string{"can i delete"}, root: []string{"my order"}, suf: []string{}, isPublic: true}
+		r6 = Rule{pre: []string{"can i cancel", "can i delete"}, root: []string{"my invoice"}, suf: []string{}, isPublic: true}
+	)
+
+	assert.False(t, g(r1, r2, Rule{pre: []string{"can i cancel", "can i pay"}, root: []string{"my bill"}, suf: []string{}, isPublic: true}))
+	assert.False(t, g(r2, r3, Rule{pre: []string{"can i open a", "can i pay"}, root: []string{"my bill", "premium account"}, suf: []string{}, isPublic: true}))
+	assert.True(t, g(r2, r4, Rule{pre: []string{"can i cancel", "can i pay"}, root: []string{"my bill", "my order"}, suf: []string{}, isPublic: true}))
+	assert.False(t, g(r4, r5, Rule{pre: []string{"can i cancel", "can i delete"}, root: []string{"my order"}, suf: []string{}, isPublic: true}))
+	// pairs already linked by one of the rules are not linked by the merge
+	assert.True(t, g(r6, r5, Rule{pre: []string{"can i cancel", "can i delete"}, root: []string{"my invoice", "my order"}, suf: []string{}, isPublic: true}))
+	assert.True(t, NewConstraints(ConstraintSpec{}, NewWordTokenizer(), []Rule{}).Guard(nilLogger)(r1, r2, r3))
+}
+
+func TestConstraints_Guard_factor(t *testing.T) {
+	var (
+		c      = NewConstraints(ConstraintSpec{Protected: []string{"premium account"}, CannotLink: [][2]string{{"cancel", "delete"}}}, NewWordTokenizer(), []Rule{})
+		g      = c.Guard(nilLogger)
+		syn    = Rule{pre: []string{}, root: []string{"cancel", "delete"}, suf: []string{}, id: 3}
+		r      = Rule{pre: []string{"can i cancel"}, root: []string{"my order"}, suf: []string{}, isPublic: true}
+		ref    = Rule{pre: []string{"can i <cancel_delete_3>"}, root: []string{"my order"}, suf: []string{}, isPublic: true}
+		pinned = Rule{pre: []string{"can i open a"}, root: []string{"premium account"}, suf: []string{}, isPublic: true}
+		chunk  = Rule{pre: []string{}, root: []string{"can i open a"}, suf: []string{}, id: 4}
+	)
+
+	// factored out rules are resolved when checking the factored rule
+	assert.False(t, g(r, syn, ref))
+	assert.False(t, g(pinned, chunk, Rule{pre: []string{"<can_i_open_a_4>"}, root: []string{"premium account"}, suf: []string{}, isPublic: true}))
+	// rules left unchanged by a factoring step are accepted
+	assert.True(t, g(pinned, syn, pinned))
+}
@@ -6,8 +6,9 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
-	"math"
+	"os"
 	"slices"
 	"strings"
 
@@ -83,104 +84,319 @@ func TokenLevenshtein(s1, s2 []string) float64 {
 	return 1 - (float64(dist) / float64(len(s2)))
 }
 
-// Collect all unique tokens from Texts
-func CollectVocab(t []Text, tok Tokenizer) []string {
-	var tokens []string
-	vocab := []string{}
+// Calculate jaro-winkler similarity from 2 strings
+func JaroWinkler(s1, s2 string) float64 {
+	r1, r2 := []rune(s1), []rune(s2)
 
-	for i := range t {
-		tokens = tok.tokenize(strings.ToLower(t[i].text))
-		vocab = append(vocab, tokens...)
+	switch {
+	case s1 == s2:
+		return 1
+	case len(r1) == 0:
+		return 0
+	case len(r2) == 0:
+		return 0
 	}
-	slices.Sort(vocab)
-	vocab = slices.Compact(vocab)
 
-	return vocab
-}
+	var (
+		window   = max(max(len(r1), len(r2))/2-1, 0)
+		matched1 = make([]bool, len(r1))
+		matched2 = make([]bool, len(r2))
+		matches  float64
+		trans    float64
+	)
 
-// Calculate inverse document frequency scores from Texts
-func CollectIDF(t []Text, tok Tokenizer) map[string]float64 {
-	var tokens []string
-	m := make(map[string]float64)
+	for i := range r1 {
+		for j := max(0, i-window); j < min(len(r2), i+window+1); j++ {
+			if matched2[j] || r1[i] != r2[j] {
+				continue
+			}
+			matched1[i], matched2[j] = true, true
+			matches++
+			break
+		}
+	}
+	if matches == 0 {
+		return 0
+	}
 
-	for i := range t {
-		tokens = tok.tokenize(strings.ToLower(t[i].text))
-		slices.Sort(tokens)
-		tokens = slices.Compact(tokens)
-		for j := range tokens {
-			m[tokens[j]]++
+	j := 0
+	for i := range r1 {
+		if !matched1[i] {
+			continue
+		}
+		for !matched2[j] {
+			j++
+		}
+		if r1[i] != r2[j] {
+			trans++
 		}
+		j++
 	}
 
-	for k := range m {
-		m[k] = math.Log(float64(len(t))/m[k] + 1)
+	jaro := (matches/float64(len(r1)) + matches/float64(len(r2)) + (matches-trans/2)/matches) / 3
+
+	prefix := 0
+	for prefix < min(len(r1), len(r2), 4) && r1[prefix] == r2[prefix] {
+		prefix++
+	}
+
+	return jaro + float64(prefix)*0.1*(1-jaro)
+}
+
+// Helper function to collect the unique tokens in a slice
+func tokenSet(s []string) map[string]bool {
+	m := make(map[string]bool)
+
+	for i := range s {
+		m[s[i]] = true
 	}
 
 	return m
 }
 
-// Calculate count embeddings for text based on provided vocabulary
-func CountEmbed(s string, voc []string, tok Tokenizer) (mat.VecDense, error) {
-	if len(voc) == 0 {
-		return mat.VecDense{}, fmt.Errorf("vocab is empty")
+// Helper function to count the tokens shared between 2 sets
+func intersectionSize(m1, m2 map[string]bool) int {
+	var n int
+
+	for k := range m1 {
+		if m2[k] {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Calculate jaccard similarity between the sets of tokens in 2 slices
+func TokenJaccard(s1, s2 []string) float64 {
+	switch {
+	case slices.Equal(s1, s2):
+		return 1.0
+	case len(s1) == 0:
+		return 0.0
+	case len(s2) == 0:
+		return 0.0
 	}
 
 	var (
-		emb    = mat.NewVecDense(len(voc), nil)
-		tokens = tok.tokenize(strings.ToLower(s))
-		counts = make(map[int]int)
-		err    error
+		m1    = tokenSet(s1)
+		m2    = tokenSet(s2)
+		inter = intersectionSize(m1, m2)
 	)
 
-	for i := range tokens {
-		ind := slices.Index(voc, tokens[i])
-		if ind == -1 {
-			return mat.VecDense{}, fmt.Errorf("token not found in vocab")
-		}
-		counts[ind]++
+	return float64(inter) / float64(len(m1)+len(m2)-inter)
+}
+
+// Calculate sorensen-dice similarity between the sets of tokens in 2 slices
+func SorensenDice(s1, s2 []string) float64 {
+	switch {
+	case slices.Equal(s1, s2):
+		return 1.0
+	case len(s1) == 0:
+		return 0.0
+	case len(s2) == 0:
+		return 0.0
 	}
 
-	for k, v := range counts {
-		emb.SetVec(k, float64(v))
+	var (
+		m1    = tokenSet(s1)
+		m2    = tokenSet(s2)
+		inter = intersectionSize(m1, m2)
+	)
+
+	return 2 * float64(inter) / float64(len(m1)+len(m2))
+}
+
+// Split a string into character n-grams, padded with spaces so word boundaries are included
+func CharacterNGrams(s string, n int) []string {
+	var (
+		runes  = []rune(fmt.Sprint(" ", s, " "))
+		ngrams = []string{}
+	)
+
+	if n < 1 {
+		return ngrams
+	}
+	for i := 0; i+n <= len(runes); i++ {
+		ngrams = append(ngrams, string(runes[i:i+n]))
 	}
 
-	return *emb, err
+	return ngrams
 }
 
-// Transform count embeddings with IDF weights
-func TFIDFTransform(vec mat.VecDense, voc []string, idf map[string]float64) mat.VecDense {
-	collectTF := func(v mat.VecDense) map[string]float64 {
-		var sum float64
-		m := make(map[string]float64)
+// Calculate the Jaccard similarity between the character n-grams of 2 strings
+// unlike the overlap coefficient, a string contained in a longer string does not score 1
+func CharacterNGramOverlap(s1, s2 string, n int) float64 {
+	switch {
+	case s1 == s2:
+		return 1.0
+	case len(s1) == 0:
+		return 0.0
+	case len(s2) == 0:
+		return 0.0
+	}
 
-		for i := range v.Len() {
-			sum += v.AtVec(i)
-		}
+	var (
+		m1 = tokenSet(CharacterNGrams(s1, n))
+		m2 = tokenSet(CharacterNGrams(s2, n))
+	)
+
+	if len(m1) == 0 || len(m2) == 0 {
+		return 0.0
+	}
+
+	inter := intersectionSize(m1, m2)
 
-		for i := range v.Len() {
-			if v.AtVec(i) == 0 {
+	return float64(inter) / float64(len(m1)+len(m2)-inter)
+}
+
+// Helper function to calculate longest common subsequence length from string slice
+func lcsLength(s1, s2 []string) int {
+	prev := make([]int, len(s2)+1)
+
+	for i := 1; i <= len(s1); i++ {
+		curr := make([]int, len(s2)+1)
+		for j := 1; j <= len(s2); j++ {
+			if s1[i-1] == s2[j-1] {
+				curr[j] = prev[j-1] + 1
 				continue
 			}
-			tok := voc[i]
-			count := v.AtVec(i)
-			m[tok] = count / sum
+			curr[j] = max(curr[j-1], prev[j])
 		}
+		prev = curr
+	}
+
+	return prev[len(s2)]
+}
 
-		return m
+// Calculate longest common subsequence ratio from 2 strings
+func LCSRatio(s1, s2 string) float64 {
+	switch {
+	case s1 == s2:
+		return 1
+	case len(s1) == 0:
+		return 0
+	case len(s2) == 0:
+		return 0
 	}
 
-	tf := collectTF(vec)
-	for i := range vec.Len() {
-		if vec.AtVec(i) == 0 {
-			continue
+	var (
+		arr1 = strings.Split(s1, "")
+		arr2 = strings.Split(s2, "")
+	)
+
+	return float64(lcsLength(arr1, arr2)) / float64(max(len(arr1), len(arr2)))
+}
+
+// Costs of token edits by universal POS tag, used by WeightedLevenshtein
+// substitute is keyed by "TAG1 TAG2" pairs, single tags (either token), "same" (both tokens share a tag), and "*"
+// insert and delete are keyed by tag and "*", missing costs default to 1
+type EditCosts struct {
+	Substitute map[string]float64 `json:"substitute"`
+	Insert     map[string]float64 `json:"insert"`
+	Delete     map[string]float64 `json:"delete"`
+}
+
+// Same tag swaps and determiner, adverb, interjection, and punctuation edits are cheap, verb edits are expensive
+var DefaultEditCosts = EditCosts{
+	Substitute: map[string]float64{"same": 0.5, "VERB": 2, "*": 1},
+	Insert:     map[string]float64{"DET": 0.2, "ADV": 0.3, "INTJ": 0.3, "PUNCT": 0.1, "VERB": 2, "*": 1},
+	Delete:     map[string]float64{"DET": 0.2, "ADV": 0.3, "INTJ": 0.3, "PUNCT": 0.1, "VERB": 2, "*": 1},
+}
+
+// Reads a user provided edit cost table from a json file
+func ReadEditCosts(p string) (EditCosts, error) {
+	var err error
+	c := EditCosts{}
+
+	file, err := os.Open(p)
+	if err != nil {
+		return c, err
+	}
+	defer file.Close()
+	dec := json.NewDecoder(file)
+	err = dec.Decode(&c)
+
+	return c, err
+}
+
+// Helper function to look up a per tag cost
+func tagCost(m map[string]float64, tag string) float64 {
+	if c, ok := m[tag]; ok {
+		return c
+	}
+	if c, ok := m["*"]; ok {
+		return c
+	}
+	return 1
+}
+
+func (c EditCosts) substitute(t1, t2 string) float64 {
+	if v, ok := c.Substitute[t1+" "+t2]; ok {
+		return v
+	}
+	if v, ok := c.Substitute[t2+" "+t1]; ok {
+		return v
+	}
+	v1, ok1 := c.Substitute[t1]
+	v2, ok2 := c.Substitute[t2]
+	switch {
+	case ok1 && ok2:
+		return max(v1, v2)
+	case ok1:
+		return v1
+	case ok2:
+		return v2
+	}
+	if v, ok := c.Substitute["same"]; ok && t1 == t2 {
+		return v
+	}
+
+	return tagCost(c.Substitute, "*")
+}
+
+// Calculate weighted levenshtein similarity between 2 slices of tokens with their POS tags
+// the edit distance is normalized by the cost of deleting all of s1 or inserting all of s2, whichever is larger
+func WeightedLevenshtein(s1, s2, t1, t2 []string, c EditCosts) float64 {
+	switch {
+	case slices.Equal(s1, s2):
+		return 1.0
+	case len(s1) == 0:
+		return 0.0
+	case len(s2) == 0:
+		return 0.0
+	}
+
+	prev := make([]float64, len(s2)+1)
+	for j := 1; j <= len(s2); j++ {
+		prev[j] = prev[j-1] + tagCost(c.Insert, t2[j-1])
+	}
+	for i := 1; i <= len(s1); i++ {
+		curr := make([]float64, len(s2)+1)
+		curr[0] = prev[0] + tagCost(c.Delete, t1[i-1])
+		for j := 1; j <= len(s2); j++ {
+			sub := prev[j-1]
+			if s1[i-1] != s2[j-1] {
+				sub += c.substitute(t1[i-1], t2[j-1])
+			}
+			curr[j] = min(sub, prev[j]+tagCost(c.Delete, t1[i-1]), curr[j-1]+tagCost(c.Insert, t2[j-1]))
 		}
-		val := vec.AtVec(i)
-		token := voc[i]
-		weight := tf[token] * idf[token]
-		vec.SetVec(i, val*weight)
+		prev = curr
+	}
+
+	// a weighted distance of 0 means the sequences are equal under the cost table
+	if prev[len(s2)] == 0 {
+		return 1.0
+	}
+	var del, ins float64
+	for i := range t1 {
+		del += tagCost(c.Delete, t1[i])
+	}
+	for i := range t2 {
+		ins += tagCost(c.Insert, t2[i])
 	}
 
-	return vec
+	return max(0, 1-prev[len(s2)]/max(del, ins))
 }
 
 // Calculate similarity between embedding vectors
@@ -6,9 +6,7 @@
 package main
 
 import (
-	"bufio"
 	"math"
-	"os"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -99,170 +97,307 @@ func Test_levenshteinDistance(t *testing.T) {
 	}
 }
 
-func TestCollectVocab(t *testing.T) {
+func TestCosineSimilarity(t *testing.T) {
 	type args struct {
-		f string
+		v1 []float64
+		v2 []float64
 	}
 	tests := []struct {
-		args args
-		want []string
+		args      args
+		want      float64
+		assertion assert.ErrorAssertionFunc
 	}{
-		{args: args{f: "./data/tests/test5.csv"}, want: []string{"account", "an", "don't", "have", "i", "online"}},
-		{args: args{f: "./data/tests/test6.csv"}, want: []string{"?", "a", "about", "account", "accoynt", "agent", "an", "ask", "attempted", "bills", "can", "customer", "don't", "dont", "email", "error", "for", "get", "got", "have", "how", "i", "information", "invoices", "is", "issues", "know", "leave", "make", "me", "message", "my", "notify", "of", "online", "opinion", "payment", "profile", "refund", "service", "show", "some", "status", "tell", "the", "to", "understand", "want", "wat", "when", "where", "with", "you"}},
-		{args: args{f: "./data/tests/test7.csv"}, want: []string{}},
-		{args: args{f: "./data/tests/test8.csv"}, want: []string{}},
-		{args: args{f: "./data/tests/test9.csv"}, want: []string{"?", "a", "account", "address", "an", "and", "arent", "bill", "changes", "check", "customer", "delivery", "do", "don't", "download", "error", "for", "fucking", "get", "have", "helping", "how", "i", "id", "invoice", "is", "item", "know", "like", "make", "message", "modify", "my", "number", "of", "online", "options", "order", "ordered", "payment", "question", "request", "review", "service", "shipping", "the", "to", "ty", "want", "what", "when", "where", "you"}},
-		{args: args{f: "./data/tests/test10.csv"}, want: []string{}},
+		{args: args{v1: []float64{}, v2: []float64{}}, want: 1.0, assertion: assert.NoError},
+		{args: args{v1: []float64{0.0}, v2: []float64{}}, want: 0.0, assertion: assert.Error},
+		{args: args{v1: []float64{}, v2: []float64{0.0}}, want: 0.0, assertion: assert.Error},
+		{args: args{v1: []float64{0.0, 0.0}, v2: []float64{0.0, 0.0}}, want: 1.0, assertion: assert.NoError},
+		{args: args{v1: []float64{0.0, 0.0, 1.0, 0.0, 0.0}, v2: []float64{0.0, 0.0, 1.0, 0.0, 0.0}}, want: 1.0, assertion: assert.NoError},
+		{args: args{v1: []float64{1.0, 2.0, 3.0, 4.0, 5.0}, v2: []float64{6.0, 7.0, 8.0, 9.0, 10.0}}, want: 0.96, assertion: assert.NoError},
+		{args: args{v1: []float64{1.0, 0.0, 0.0, 0.0, 0.0}, v2: []float64{0.0, 0.0, 0.0, 0.0, 0.0}}, want: 0.0, assertion: assert.NoError},
+		{args: args{v1: []float64{2.0, 2.0, 2.0, 2.0, 2.0}, v2: []float64{1.0, 1.0, 1.0, 1.0, 1.0}}, want: 1.0, assertion: assert.NoError},
+		{args: args{v1: []float64{1.0, 1.0, 1.0, 0.0, 0.0}, v2: []float64{-1.0, -1.0, -1.0, 0.0, 0.0}}, want: -1.0, assertion: assert.NoError},
+		{args: args{v1: []float64{0.1, 0.2, 0.3, 0.4, 0.5}, v2: []float64{0.6, 0.7, 0.8, 0.9, 0.0}}, want: 0.71, assertion: assert.NoError},
 	}
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
-			tk := NewWordTokenizer()
-			file, _ := os.Open(tt.args.f)
-			defer file.Close()
-			s := bufio.NewScanner(file)
-			tx := ReadTexts(s)
-			for i, t := range tx {
-				tx[i].text = tk.normalize(t.text)
+			v1 := mat.VecDense{}
+			if len(tt.args.v1) > 0 {
+				v1 = *mat.NewVecDense(len(tt.args.v1), tt.args.v1)
 			}
-			assert.Equal(t, tt.want, CollectVocab(tx, tk))
+			v2 := mat.VecDense{}
+			if len(tt.args.v2) > 0 {
+				v2 = *mat.NewVecDense(len(tt.args.v2), tt.args.v2)
+			}
+			got, err := CosineSimilarity(v1, v2)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, math.Round(got*100)/100)
 		})
 	}
 }
 
-func TestCollectIDF(t *testing.T) {
+func TestJaroWinkler(t *testing.T) {
 	type args struct {
-		f string
+		s1 string
+		s2 string
 	}
 	tests := []struct {
 		args args
-		want map[string]float64
+		want float64
 	}{
-		{args: args{f: "./data/tests/test5.csv"}, want: map[string]float64{"account": 0.69, "an": 0.69, "don't": 0.69, "have": 0.69, "i": 0.69, "online": 0.69}},
-		{args: args{f: "./data/tests/test6.csv"}, want: map[string]float64{"?": 1.32, "a": 1.87, "about": 2.48, "account": 2.48, "accoynt": 2.48, "agent": 2.48, "an": 1.16, "ask": 2.48, "attempted": 2.48, "bills": 2.48, "can": 1.32, "customer": 2.48, "don't": 1.87, "dont": 2.48, "email": 2.48, "error": 2.48, "for": 2.48, "get": 2.48, "got": 2.48, "have": 2.48, "how": 2.48, "i": 0.86, "information": 2.48, "invoices": 2.48, "is": 2.48, "issues": 2.48, "know": 2.48, "leave": 2.48, "make": 2.48, "me": 1.54, "message": 2.48, "my": 1.32, "notify": 2.48, "of": 1.87, "online": 1.87, "opinion": 2.48, "payment": 1.87, "profile": 2.48, "refund": 2.48, "service": 1.87, "show": 1.87, "some": 2.48, "status": 2.48, "tell": 2.48, "the": 1.87, "to": 1.54, "understand": 2.48, "want": 1.54, "wat": 2.48, "when": 2.48, "where": 2.48, "with": 2.48, "you": 1.32}},
-		{args: args{f: "./data/tests/test7.csv"}, want: map[string]float64{}},
-		{args: args{f: "./data/tests/test8.csv"}, want: map[string]float64{}},
-		{args: args{f: "./data/tests/test9.csv"}, want: map[string]float64{"?": 1.87, "a": 1.32, "account": 2.48, "address": 2.48, "an": 1.32, "and": 2.48, "arent": 2.48, "bill": 2.48, "changes": 2.48, "check": 2.48, "customer": 2.48, "delivery": 2.48, "do": 1.87, "don't": 2.48, "download": 2.48, "error": 2.48, "for": 1.87, "fucking": 2.48, "get": 2.48, "have": 1.87, "helping": 2.48, "how": 2.48, "i": 0.74, "id": 2.48, "invoice": 2.48, "is": 2.48, "item": 2.48, "know": 2.48, "like": 2.48, "make": 1.54, "message": 2.48, "modify": 2.48, "my": 1.54, "number": 2.48, "of": 2.48, "online": 2.48, "options": 2.48, "order": 1.87, "ordered": 2.48, "payment": 2.48, "question": 2.48, "request": 2.48, "review": 2.48, "service": 1.87, "shipping": 2.48, "the": 1.87, "to": 0.94, "ty": 2.48, "want": 1.32, "what": 2.48, "when": 2.48, "where": 2.48, "you": 2.48}},
-		{args: args{f: "./data/tests/test10.csv"}, want: map[string]float64{}},
+		{args: args{s1: "", s2: ""}, want: 1.0},
+		{args: args{s1: " ", s2: ""}, want: 0.0},
+		{args: args{s1: "", s2: " "}, want: 0.0},
+		{args: args{s1: "abc", s2: "xyz"}, want: 0.0},
+		{args: args{s1: "martha", s2: "marhta"}, want: 0.9611},
+		{args: args{s1: "dwayne", s2: "duane"}, want: 0.84},
+		{args: args{s1: "send", s2: "sent"}, want: 0.8833},
+		{args: args{s1: "this is a test", s2: "this is a test"}, want: 1.0},
 	}
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
-			tk := NewWordTokenizer()
-			file, _ := os.Open(tt.args.f)
-			defer file.Close()
-			s := bufio.NewScanner(file)
-			tx := ReadTexts(s)
-			for i, t := range tx {
-				tx[i].text = tk.normalize(t.text)
-			}
-			res := CollectIDF(tx, tk)
-			for k, v := range res {
-				res[k] = math.Floor(v*100) / 100
-			}
-			assert.Equal(t, tt.want, res)
+			assert.InDelta(t, tt.want, JaroWinkler(tt.args.s1, tt.args.s2), 0.0001)
 		})
 	}
 }
 
-func TestCountEmbed(t *testing.T) {
+func TestTokenJaccard(t *testing.T) {
+	type args struct {
+		s1 []string
+		s2 []string
+	}
+	tests := []struct {
+		args args
+		want float64
+	}{
+		{args: args{s1: []string{}, s2: []string{}}, want: 1.0},
+		{args: args{s1: []string{"a"}, s2: []string{}}, want: 0.0},
+		{args: args{s1: []string{}, s2: []string{"a"}}, want: 0.0},
+		{args: args{s1: []string{"send", "me"}, s2: []string{"send"}}, want: 0.5},
+		{args: args{s1: []string{"send", "me"}, s2: []string{"me", "send"}}, want: 1.0},
+		{args: args{s1: []string{"a", "a", "b"}, s2: []string{"b", "c"}}, want: 1.0 / 3.0},
+		{args: args{s1: []string{"a"}, s2: []string{"b"}}, want: 0.0},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, TokenJaccard(tt.args.s1, tt.args.s2))
+		})
+	}
+}
+
+func TestSorensenDice(t *testing.T) {
+	type args struct {
+		s1 []string
+		s2 []string
+	}
+	tests := []struct {
+		args args
+		want float64
+	}{
+		{args: args{s1: []string{}, s2: []string{}}, want: 1.0},
+		{args: args{s1: []string{"a"}, s2: []string{}}, want: 0.0},
+		{args: args{s1: []string{}, s2: []string{"a"}}, want: 0.0},
+		{args: args{s1: []string{"send", "me"}, s2: []string{"send"}}, want: 2.0 / 3.0},
+		{args: args{s1: []string{"send", "me"}, s2: []string{"me", "send"}}, want: 1.0},
+		{args: args{s1: []string{"a", "a", "b"}, s2: []string{"b", "c"}}, want: 0.5},
+		{args: args{s1: []string{"a"}, s2: []string{"b"}}, want: 0.0},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, SorensenDice(tt.args.s1, tt.args.s2))
+		})
+	}
+}
+
+func TestCharacterNGrams(t *testing.T) {
 	type args struct {
 		s string
-		v []string
+		n int
 	}
 	tests := []struct {
-		args      args
-		want      []float64
-		assertion assert.ErrorAssertionFunc
+		args args
+		want []string
 	}{
-		{args: args{s: "", v: []string{}}, want: []float64{}, assertion: assert.Error},
-		{args: args{s: "test", v: []string{}}, want: []float64{}, assertion: assert.Error},
-		{args: args{s: "testing", v: []string{"a", "test", "testing", "this", "is", "not", "that", "these", "tested", "."}}, want: []float64{0.0, 0.0, 1.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0, 0.0}, assertion: assert.NoError},
-		{args: args{s: "this is a test.", v: []string{"a", "test", "testing", "this", "is", "not", "that", "these", "tested", "."}}, want: []float64{1.0, 1.0, 0.0, 1.0, 1.0, 0.0, 0.0, 0.0, 0.0, 1.0}, assertion: assert.NoError},
-		{args: args{s: "that is not a not a test...", v: []string{"a", "test", "testing", "this", "is", "not", "that", "these", "tested", "."}}, want: []float64{2.0, 1.0, 0.0, 0.0, 1.0, 2.0, 1.0, 0.0, 0.0, 3.0}, assertion: assert.NoError},
-		{args: args{s: "this is a testt", v: []string{"a", "test", "testing", "this", "is", "not", "that", "these", "tested", "."}}, want: []float64{}, assertion: assert.Error},
-		{args: args{s: "just testing", v: []string{"a", "test", "testing", "this", "is", "not", "that", "these", "tested", "."}}, want: []float64{}, assertion: assert.Error},
+		{args: args{s: "", n: 3}, want: []string{}},
+		{args: args{s: "a", n: 0}, want: []string{}},
+		{args: args{s: "a", n: 2}, want: []string{" a", "a "}},
+		{args: args{s: "send", n: 3}, want: []string{" se", "sen", "end", "nd "}},
+		{args: args{s: "to me", n: 3}, want: []string{" to", "to ", "o m", " me", "me "}},
+		{args: args{s: "é", n: 2}, want: []string{" é", "é "}},
 	}
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
-			tk := NewWordTokenizer()
-			v := mat.VecDense{}
-			if len(tt.want) > 0 {
-				v = *mat.NewVecDense(len(tt.want), tt.want)
-			}
-			got, err := CountEmbed(tt.args.s, tt.args.v, tk)
-			tt.assertion(t, err)
-			assert.Equal(t, v, got)
+			assert.Equal(t, tt.want, CharacterNGrams(tt.args.s, tt.args.n))
 		})
 	}
 }
 
-func TestCosineSimilarity(t *testing.T) {
+func TestCharacterNGramOverlap(t *testing.T) {
 	type args struct {
-		v1 []float64
-		v2 []float64
+		s1 string
+		s2 string
+		n  int
 	}
 	tests := []struct {
-		args      args
-		want      float64
-		assertion assert.ErrorAssertionFunc
+		args args
+		want float64
 	}{
-		{args: args{v1: []float64{}, v2: []float64{}}, want: 1.0, assertion: assert.NoError},
-		{args: args{v1: []float64{0.0}, v2: []float64{}}, want: 0.0, assertion: assert.Error},
-		{args: args{v1: []float64{}, v2: []float64{0.0}}, want: 0.0, assertion: assert.Error},
-		{args: args{v1: []float64{0.0, 0.0}, v2: []float64{0.0, 0.0}}, want: 1.0, assertion: assert.NoError},
-		{args: args{v1: []float64{0.0, 0.0, 1.0, 0.0, 0.0}, v2: []float64{0.0, 0.0, 1.0, 0.0, 0.0}}, want: 1.0, assertion: assert.NoError},
-		{args: args{v1: []float64{1.0, 2.0, 3.0, 4.0, 5.0}, v2: []float64{6.0, 7.0, 8.0, 9.0, 10.0}}, want: 0.96, assertion: assert.NoError},
-		{args: args{v1: []float64{1.0, 0.0, 0.0, 0.0, 0.0}, v2: []float64{0.0, 0.0, 0.0, 0.0, 0.0}}, want: 0.0, assertion: assert.NoError},
-		{args: args{v1: []float64{2.0, 2.0, 2.0, 2.0, 2.0}, v2: []float64{1.0, 1.0, 1.0, 1.0, 1.0}}, want: 1.0, assertion: assert.NoError},
-		{args: args{v1: []float64{1.0, 1.0, 1.0, 0.0, 0.0}, v2: []float64{-1.0, -1.0, -1.0, 0.0, 0.0}}, want: -1.0, assertion: assert.NoError},
-		{args: args{v1: []float64{0.1, 0.2, 0.3, 0.4, 0.5}, v2: []float64{0.6, 0.7, 0.8, 0.9, 0.0}}, want: 0.71, assertion: assert.NoError},
+		{args: args{s1: "", s2: "", n: 3}, want: 1.0},
+		{args: args{s1: "a", s2: "", n: 3}, want: 0.0},
+		{args: args{s1: "", s2: "a", n: 3}, want: 0.0},
+		{args: args{s1: "a", s2: "b", n: 5}, want: 0.0},
+		{args: args{s1: "register", s2: "regisger", n: 3}, want: 5.0 / 11.0},
+		// chunks contained in another chunk score below 1
+		{args: args{s1: "send", s2: "send me", n: 3}, want: 4.0 / 7.0},
+		{args: args{s1: "abc", s2: "xyz", n: 2}, want: 0.0},
 	}
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
-			v1 := mat.VecDense{}
-			if len(tt.args.v1) > 0 {
-				v1 = *mat.NewVecDense(len(tt.args.v1), tt.args.v1)
-			}
-			v2 := mat.VecDense{}
-			if len(tt.args.v2) > 0 {
-				v2 = *mat.NewVecDense(len(tt.args.v2), tt.args.v2)
-			}
-			got, err := CosineSimilarity(v1, v2)
-			tt.assertion(t, err)
-			assert.Equal(t, tt.want, math.Round(got*100)/100)
+			assert.Equal(t, tt.want, CharacterNGramOverlap(tt.args.s1, tt.args.s2, tt.args.n))
 		})
 	}
 }
 
-func TestTFIDFTransform(t *testing.T) {
+func Test_lcsLength(t *testing.T) {
 	type args struct {
-		v []float64
+		s1 []string
+		s2 []string
 	}
 	tests := []struct {
 		args args
-		want []float64
+		want int
 	}{
-		{args: args{v: []float64{}}, want: []float64{}},
-		{args: args{v: []float64{2.0, 1.0}}, want: []float64{1.33, 0.43}},
-		{args: args{v: []float64{0.0, 1.0, 1.0, 1.0, 3.0, 1.0, 4.0, 1.0, 9.0, 1.0}}, want: []float64{0, 0.05, 0.07, 0.07, 0.52, 0.07, 1.22, 0.04, 3.68, 0.05}},
-		{args: args{v: []float64{0.0, 2.0, 1.0, 1.0, 4.0, 1.0, 5.0, 2.0, 6.0, 1.0, 9.0}}, want: []float64{0, 0.16, 0.05, 0.05, 0.64, 0.05, 1.32, 0.12, 1.12, 0.03, 0}},
+		{args: args{s1: []string{}, s2: []string{}}, want: 0},
+		{args: args{s1: []string{"a"}, s2: []string{}}, want: 0},
+		{args: args{s1: []string{"a", "b", "c"}, s2: []string{"a", "c"}}, want: 2},
+		{args: args{s1: []string{"a", "b", "c", "d"}, s2: []string{"b", "x", "d", "a"}}, want: 2},
+		{args: args{s1: []string{"a", "b"}, s2: []string{"c", "d"}}, want: 0},
 	}
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
-			vv := []string{"a", "test", "testing", "this", "is", "not", "that", "these", "tested", ".", ""}
-			idf := map[string]float64{"a": 1.0, "test": 1.29, "testing": 1.69, "this": 1.69, "is": 1.29, "not": 1.69, "that": 1.69, "these": 1.0, "tested": 1.0, ".": 1.2}
-			v1 := mat.VecDense{}
-			if len(tt.args.v) > 0 {
-				v1 = *mat.NewVecDense(len(tt.args.v), tt.args.v)
-			}
-			v2 := mat.VecDense{}
-			if len(tt.want) > 0 {
-				v2 = *mat.NewVecDense(len(tt.want), tt.want)
-			}
-			res := TFIDFTransform(v1, vv, idf)
-			for i := range res.Len() {
-				res.SetVec(i, math.Floor(res.AtVec(i)*100)/100)
+			assert.Equal(t, tt.want, lcsLength(tt.args.s1, tt.args.s2))
+		})
+	}
+}
+
+func TestLCSRatio(t *testing.T) {
+	type args struct {
+		s1 string
+		s2 string
+	}
+	tests := []struct {
+		args args
+		want float64
+	}{
+		{args: args{s1: "", s2: ""}, want: 1.0},
+		{args: args{s1: " ", s2: ""}, want: 0.0},
+		{args: args{s1: "", s2: " "}, want: 0.0},
+		{args: args{s1: "send", s2: "sent"}, want: 0.75},
+		{args: args{s1: "send", s2: "send me"}, want: 4.0 / 7.0},
+		{args: args{s1: "send me", s2: "send"}, want: 4.0 / 7.0},
+		{args: args{s1: "abc", s2: "xyz"}, want: 0.0},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, LCSRatio(tt.args.s1, tt.args.s2))
+		})
+	}
+}
+
+func TestEditCosts_substitute(t *testing.T) {
+	type args struct {
+		t1 string
+		t2 string
+	}
+	costs := EditCosts{Substitute: map[string]float64{"NOUN PROPN": 0.1, "VERB": 2, "ADV": 0.5, "same": 0.5, "*": 1.5}}
+	tests := []struct {
+		c    EditCosts
+		args args
+		want float64
+	}{
+		{c: EditCosts{}, args: args{t1: "NOUN", t2: "VERB"}, want: 1.0},
+		{c: costs, args: args{t1: "NOUN", t2: "PROPN"}, want: 0.1},
+		{c: costs, args: args{t1: "PROPN", t2: "NOUN"}, want: 0.1},
+		{c: costs, args: args{t1: "VERB", t2: "NOUN"}, want: 2.0},
+		{c: costs, args: args{t1: "ADV", t2: "VERB"}, want: 2.0},
+		{c: costs, args: args{t1: "NOUN", t2: "NOUN"}, want: 0.5},
+		{c: costs, args: args{t1: "NOUN", t2: "ADJ"}, want: 1.5},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.c.substitute(tt.args.t1, tt.args.t2))
+		})
+	}
+}
+
+func TestReadEditCosts(t *testing.T) {
+	tests := []struct {
+		p       string
+		want    EditCosts
+		wantErr bool
+	}{
+		{p: "data/tests/costs1.json", want: EditCosts{
+			Substitute: map[string]float64{"NOUN PROPN": 0.1, "same": 0.5},
+			Insert:     map[string]float64{"DET": 0},
+			Delete:     map[string]float64{"DET": 0, "*": 2},
+		}},
+		{p: "data/tests/nonexistent.json", want: EditCosts{}, wantErr: true},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := ReadEditCosts(tt.p)
+			if tt.wantErr {
+				assert.Error(t, err)
+				return
 			}
-			assert.Equal(t, v2, res)
+			assert.Nil(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestWeightedLevenshtein(t *testing.T) {
+	type args struct {
+		s1 []string
+		s2 []string
+		t1 []string
+		t2 []string
+		c  EditCosts
+	}
+	tests := []struct {
+		args args
+		want float64
+	}{
+		{args: args{s1: []string{}, s2: []string{}, t1: []string{}, t2: []string{}, c: DefaultEditCosts}, want: 1.0},
+		{args: args{s1: []string{"a"}, s2: []string{}, t1: []string{"DET"}, t2: []string{}, c: DefaultEditCosts}, want: 0.0},
+		// unit costs match TokenLevenshtein
+		{
+			args: args{s1: []string{"send", "me", "the", "bill"}, s2: []string{"send", "the", "invoice"}, t1: []string{"VERB", "PRON", "DET", "NOUN"}, t2: []string{"VERB", "DET", "NOUN"}, c: EditCosts{}},
+			want: TokenLevenshtein([]string{"send", "me", "the", "bill"}, []string{"send", "the", "invoice"}),
+		},
+		// dropping a determiner is cheap
+		{
+			args: args{s1: []string{"send", "the", "invoice"}, s2: []string{"send", "invoice"}, t1: []string{"VERB", "DET", "NOUN"}, t2: []string{"VERB", "NOUN"}, c: DefaultEditCosts},
+			want: 1 - 0.2/3.2,
+		},
+		// swapping a verb is expensive
+		{
+			args: args{s1: []string{"send", "the", "invoice"}, s2: []string{"pay", "the", "invoice"}, t1: []string{"VERB", "DET", "NOUN"}, t2: []string{"VERB", "DET", "NOUN"}, c: DefaultEditCosts},
+			want: 1 - 2/3.2,
+		},
+		// swapping a noun for another noun is cheap
+		{
+			args: args{s1: []string{"send", "the", "invoice"}, s2: []string{"send", "the", "bill"}, t1: []string{"VERB", "DET", "NOUN"}, t2: []string{"VERB", "DET", "NOUN"}, c: DefaultEditCosts},
+			want: 1 - 0.5/3.2,
+		},
+		// free insertions and deletions leave no distance between any sequences
+		{
+			args: args{s1: []string{"the"}, s2: []string{"a"}, t1: []string{"DET"}, t2: []string{"DET"}, c: EditCosts{Insert: map[string]float64{"*": 0}, Delete: map[string]float64{"*": 0}}},
+			want: 1.0,
+		},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.InDelta(t, tt.want, WeightedLevenshtein(tt.args.s1, tt.args.s2, tt.args.t1, tt.args.t2, tt.args.c), 1e-9)
 		})
 	}
 }
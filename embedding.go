@@ -0,0 +1,287 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:52:39 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bytes"
+	"encoding/binary"
+	"fmt"
+	"log"
+	"math"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Static word vectors read from a memory mapped GloVe, word2vec, or fastText file
+// vectors are parsed from the mapped file on lookup, so only the word index is held in memory
+type WordVectors struct {
+	data   []byte
+	index  map[string]int
+	dim    int
+	binary bool
+	close  func() error
+}
+
+// Loads word vectors from a local file
+// files ending in .bin are read as word2vec binary, all others as text with an optional "count dim" header line
+func LoadWordVectors(p string) (*WordVectors, error) {
+	data, closer, err := mapFile(p)
+	if err != nil {
+		return nil, fmt.Errorf("in LoadWordVectors():\n%+w", err)
+	}
+
+	w := &WordVectors{data: data, index: make(map[string]int), binary: filepath.Ext(p) == ".bin", close: closer}
+	if w.binary {
+		err = w.indexBinary()
+	} else {
+		err = w.indexText()
+	}
+	if err != nil {
+		w.Close()
+		return nil, fmt.Errorf("in LoadWordVectors():\n%+w", err)
+	}
+
+	return w, nil
+}
+
+// Releases the mapped vector file
+func (w *WordVectors) Close() error {
+	if w.close == nil {
+		return nil
+	}
+	err := w.close()
+	w.close = nil
+
+	return err
+}
+
+// Number of dimensions of each vector
+func (w *WordVectors) Dim() int {
+	return w.dim
+}
+
+// Number of words with vectors
+func (w *WordVectors) Len() int {
+	return len(w.index)
+}
+
+// Helper function to read the "count dim" header line used by word2vec and fastText files
+func parseVectorHeader(line []byte) (int, bool) {
+	fields := strings.Fields(string(line))
+	if len(fields) != 2 {
+		return 0, false
+	}
+	if _, err := strconv.Atoi(fields[0]); err != nil {
+		return 0, false
+	}
+	dim, err := strconv.Atoi(fields[1])
+	if err != nil {
+		return 0, false
+	}
+
+	return dim, true
+}
+
+// Records the offset of each word's vector in a text format file
+func (w *WordVectors) indexText() error {
+	offset := 0
+
+	for offset < len(w.data) {
+		end := bytes.IndexByte(w.data[offset:], '\n')
+		if end == -1 {
+			end = len(w.data) - offset
+		}
+		line := w.data[offset : offset+end]
+		start := offset
+		offset += end + 1
+
+		if len(bytes.TrimSpace(line)) == 0 {
+			continue
+		}
+		if start == 0 {
+			if dim, ok := parseVectorHeader(line); ok {
+				w.dim = dim
+				continue
+			}
+		}
+
+		fields := bytes.Fields(line)
+		if w.dim == 0 {
+			w.dim = len(fields) - 1
+		}
+		if len(fields)-1 != w.dim {
+			continue
+		}
+		word := string(fields[0])
+		if _, ok := w.index[word]; !ok {
+			w.index[word] = start
+		}
+	}
+	if w.dim <= 0 {
+		return fmt.Errorf("no vectors found")
+	}
+
+	return nil
+}
+
+// Records the offset of each word's vector in a word2vec binary file
+func (w *WordVectors) indexBinary() error {
+	end := bytes.IndexByte(w.data, '\n')
+	if end == -1 {
+		return fmt.Errorf("binary vector file is missing header")
+	}
+	dim, ok := parseVectorHeader(w.data[:end])
+	if !ok || dim <= 0 {
+		return fmt.Errorf("binary vector file has invalid header %q", w.data[:end])
+	}
+	w.dim = dim
+
+	offset := end + 1
+	for offset < len(w.data) {
+		for offset < len(w.data) && (w.data[offset] == '\n' || w.data[offset] == ' ') {
+			offset++
+		}
+		if offset >= len(w.data) {
+			break
+		}
+		space := bytes.IndexByte(w.data[offset:], ' ')
+		if space == -1 {
+			return fmt.Errorf("binary vector file is truncated")
+		}
+		word := string(w.data[offset : offset+space])
+		offset += space + 1
+		if offset+4*w.dim > len(w.data) {
+			return fmt.Errorf("binary vector file is truncated")
+		}
+		if _, ok := w.index[word]; !ok {
+			w.index[word] = offset
+		}
+		offset += 4 * w.dim
+	}
+
+	return nil
+}
+
+// Looks up the vector for a word, falling back to its lowercase form
+// returns false for out of vocabulary words
+func (w *WordVectors) Vector(s string) ([]float64, bool) {
+	offset, ok := w.index[s]
+	if !ok {
+		offset, ok = w.index[strings.ToLower(s)]
+	}
+	if !ok {
+		return nil, false
+	}
+
+	vec := make([]float64, w.dim)
+	if w.binary {
+		for i := range vec {
+			bits := binary.LittleEndian.Uint32(w.data[offset+4*i:])
+			vec[i] = float64(math.Float32frombits(bits))
+		}
+		return vec, true
+	}
+
+	end := bytes.IndexByte(w.data[offset:], '\n')
+	if end == -1 {
+		end = len(w.data) - offset
+	}
+	fields := bytes.Fields(w.data[offset : offset+end])[1:]
+	for i := range vec {
+		f, err := strconv.ParseFloat(string(fields[i]), 64)
+		if err != nil {
+			return nil, false
+		}
+		vec[i] = f
+	}
+
+	return vec, true
+}
+
+// Calculate the average of the token vectors for a string, weighting each token by weights if provided
+// out of vocabulary tokens are skipped, returns false if no tokens have vectors
+func (w *WordVectors) Embed(s string, tok Tokenizer, weights map[string]float64) (mat.VecDense, bool) {
+	var (
+		emb    = mat.NewVecDense(w.dim, nil)
+		tokens = tok.tokenize(s)
+		total  float64
+	)
+
+	for i := range tokens {
+		vec, ok := w.Vector(tokens[i])
+		if !ok {
+			continue
+		}
+		weight := 1.0
+		if weights != nil {
+			weight, ok = weights[strings.ToLower(tokens[i])]
+			if !ok {
+				weight = 1.0
+			}
+		}
+		emb.AddScaledVec(emb, weight, mat.NewVecDense(w.dim, vec))
+		total += weight
+	}
+	if total == 0 {
+		return mat.VecDense{}, false
+	}
+	emb.ScaleVec(1/total, emb)
+
+	return *emb, true
+}
+
+// Calculate smooth inverse frequency weights a/(a+p(w)) from token frequencies in Texts, tokenizing with n workers
+func CollectSIFWeights(t []Text, tok Tokenizer, a float64, n int) map[string]float64 {
+	var (
+		m     = make(map[string]float64)
+		total float64
+	)
+
+	tokens := ParallelMap(t, n, func(t Text) []string { return tok.tokenize(strings.ToLower(t.text)) })
+	for i := range tokens {
+		for j := range tokens[i] {
+			m[tokens[i][j]]++
+			total++
+		}
+	}
+
+	for k := range m {
+		m[k] = a / (a + m[k]/total)
+	}
+
+	return m
+}
+
+// Compares expression similarity via averaged static word vectors and cosine similarity
+// expressions with no in vocabulary tokens only match literally
+func WordVectorCosineThreshold(thr float64, w *WordVectors, tok Tokenizer, weights map[string]float64, l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		s1, s2 := strings.Join(e1, " "), strings.Join(e2, " ")
+		vec1, ok1 := w.Embed(s1, tok, weights)
+		vec2, ok2 := w.Embed(s2, tok, weights)
+		if !ok1 || !ok2 {
+			if s1 == s2 {
+				l.Printf("equality function %s matched out of vocabulary %v and %v\n", "WordVectorCosineThreshold", e1, e2)
+				return true
+			}
+			return false
+		}
+		sim, err := CosineSimilarity(vec1, vec2)
+		if err != nil {
+			l.Println(err)
+			return false
+		}
+		if sim >= thr {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "WordVectorCosineThreshold", e1, e2, thr, sim)
+			similarities.record("WordVectorCosineThreshold", e1, e2, thr, sim)
+			return true
+		}
+		return false
+	}
+}
@@ -0,0 +1,155 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:52:39 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"gonum.org/v1/gonum/mat"
+)
+
+func TestLoadWordVectors(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		args      args
+		dim       int
+		len       int
+		assertion assert.ErrorAssertionFunc
+	}{
+		{args: args{p: ""}, dim: 0, len: 0, assertion: assert.Error},
+		{args: args{p: "./data/tests/vectors5.txt"}, dim: 0, len: 0, assertion: assert.Error},
+		{args: args{p: "./data/tests/vectors4.bin"}, dim: 0, len: 0, assertion: assert.Error},
+		{args: args{p: "./data/tests/vectors1.txt"}, dim: 3, len: 5, assertion: assert.NoError},
+		{args: args{p: "./data/tests/vectors2.vec"}, dim: 3, len: 2, assertion: assert.NoError},
+		{args: args{p: "./data/tests/vectors3.bin"}, dim: 3, len: 2, assertion: assert.NoError},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			w, err := LoadWordVectors(tt.args.p)
+			tt.assertion(t, err)
+			if err != nil {
+				return
+			}
+			defer w.Close()
+			assert.Equal(t, tt.dim, w.Dim())
+			assert.Equal(t, tt.len, w.Len())
+		})
+	}
+}
+
+func TestWordVectors_Vector(t *testing.T) {
+	type args struct {
+		p string
+		s string
+	}
+	tests := []struct {
+		args  args
+		want  []float64
+		want1 bool
+	}{
+		{args: args{p: "./data/tests/vectors1.txt", s: "cancel"}, want: []float64{1, 0, 0}, want1: true},
+		{args: args{p: "./data/tests/vectors1.txt", s: "Cancel"}, want: []float64{1, 0, 0}, want1: true},
+		{args: args{p: "./data/tests/vectors1.txt", s: "broken"}, want: nil, want1: false},
+		{args: args{p: "./data/tests/vectors1.txt", s: "invoice"}, want: nil, want1: false},
+		{args: args{p: "./data/tests/vectors2.vec", s: "cancel"}, want: []float64{1, 0, 0}, want1: true},
+		{args: args{p: "./data/tests/vectors2.vec", s: "Terminate"}, want: []float64{0.9, 0.1, 0}, want1: true},
+		{args: args{p: "./data/tests/vectors2.vec", s: "terminate"}, want: nil, want1: false},
+		{args: args{p: "./data/tests/vectors3.bin", s: "account"}, want: []float64{0, 1, 0}, want1: true},
+		{args: args{p: "./data/tests/vectors3.bin", s: "my"}, want: nil, want1: false},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			w, err := LoadWordVectors(tt.args.p)
+			assert.NoError(t, err)
+			defer w.Close()
+			got, got1 := w.Vector(tt.args.s)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want1, got1)
+		})
+	}
+}
+
+func TestWordVectors_Embed(t *testing.T) {
+	type args struct {
+		s       string
+		weights map[string]float64
+	}
+	tests := []struct {
+		args  args
+		want  []float64
+		want1 bool
+	}{
+		{args: args{s: "", weights: nil}, want: nil, want1: false},
+		{args: args{s: "invoice", weights: nil}, want: nil, want1: false},
+		{args: args{s: "cancel", weights: nil}, want: []float64{1, 0, 0}, want1: true},
+		{args: args{s: "cancel invoice", weights: nil}, want: []float64{1, 0, 0}, want1: true},
+		{args: args{s: "cancel my account", weights: nil}, want: []float64{1.0 / 3.0, 1.0 / 3.0, 1.0 / 3.0}, want1: true},
+		{args: args{s: "cancel account", weights: map[string]float64{"cancel": 3}}, want: []float64{0.75, 0.25, 0}, want1: true},
+	}
+	w, err := LoadWordVectors("./data/tests/vectors1.txt")
+	assert.NoError(t, err)
+	defer w.Close()
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, got1 := w.Embed(tt.args.s, NewWordTokenizer(), tt.args.weights)
+			assert.Equal(t, tt.want1, got1)
+			if !got1 {
+				return
+			}
+			assert.InDeltaSlice(t, tt.want, mat.Col(nil, 0, &got), 1e-9)
+		})
+	}
+}
+
+func TestCollectSIFWeights(t *testing.T) {
+	type args struct {
+		t []Text
+		a float64
+	}
+	tests := []struct {
+		args args
+		want map[string]float64
+	}{
+		{args: args{t: []Text{}, a: 0.5}, want: map[string]float64{}},
+		{args: args{t: []Text{{text: "a a b"}, {text: "A"}}, a: 1}, want: map[string]float64{"a": 1 / 1.75, "b": 1 / 1.25}},
+		{args: args{t: []Text{{text: "a a a a"}}, a: 1}, want: map[string]float64{"a": 0.5}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, CollectSIFWeights(tt.args.t, NewWordTokenizer(), tt.args.a, 1))
+		})
+	}
+}
+
+func TestWordVectorCosineThreshold(t *testing.T) {
+	type args struct {
+		e1 []string
+		e2 []string
+	}
+	tests := []struct {
+		args args
+		want bool
+	}{
+		{args: args{e1: []string{"cancel"}, e2: []string{"terminate"}}, want: true},
+		{args: args{e1: []string{"cancel"}, e2: []string{"account"}}, want: false},
+		{args: args{e1: []string{"cancel", "my account"}, e2: []string{"terminate", "my account"}}, want: true},
+		{args: args{e1: []string{"invoice"}, e2: []string{"invoice"}}, want: true},
+		{args: args{e1: []string{"invoice"}, e2: []string{"receipt"}}, want: false},
+		{args: args{e1: []string{"invoice"}, e2: []string{"cancel"}}, want: false},
+	}
+	w, err := LoadWordVectors("./data/tests/vectors1.txt")
+	assert.NoError(t, err)
+	defer w.Close()
+	eq := WordVectorCosineThreshold(0.9, w, NewWordTokenizer(), nil, nilLogger)
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, eq(tt.args.e1, tt.args.e2))
+		})
+	}
+}
@@ -0,0 +1,243 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:54:28 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log"
+	"net/http"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"gonum.org/v1/gonum/mat"
+)
+
+// Client for a local OpenAI compatible /v1/embeddings endpoint
+// vectors are cached in memory and, if a cache file is provided, on disk across runs
+type EmbeddingClient struct {
+	url    string
+	model  string
+	batch  int
+	client *http.Client
+
+	mu      sync.Mutex
+	vectors map[string][]float64
+	file    string
+	err     error
+}
+
+type embeddingRequest struct {
+	Model string   `json:"model,omitempty"`
+	Input []string `json:"input"`
+}
+
+type embeddingResponse struct {
+	Data []struct {
+		Index     int       `json:"index"`
+		Embedding []float64 `json:"embedding"`
+	} `json:"data"`
+}
+
+type embeddingCacheEntry struct {
+	Model     string    `json:"model"`
+	Input     string    `json:"input"`
+	Embedding []float64 `json:"embedding"`
+}
+
+// Creates an embedding client sending up to batch inputs per request, reading previously cached vectors from cache if provided
+func NewEmbeddingClient(url string, model string, batch int, cache string) (*EmbeddingClient, error) {
+	c := &EmbeddingClient{
+		url:     url,
+		model:   model,
+		batch:   max(batch, 1),
+		client:  &http.Client{Timeout: 60 * time.Second},
+		vectors: make(map[string][]float64),
+		file:    cache,
+	}
+	if cache == "" {
+		return c, nil
+	}
+
+	f, err := os.Open(cache)
+	if errors.Is(err, os.ErrNotExist) {
+		return c, nil
+	}
+	if err != nil {
+		return c, fmt.Errorf("in NewEmbeddingClient():\n%+w", err)
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	scanner.Buffer(make([]byte, 1024*1024), 64*1024*1024)
+	for scanner.Scan() {
+		var entry embeddingCacheEntry
+		err = json.Unmarshal(scanner.Bytes(), &entry)
+		if err != nil {
+			return c, fmt.Errorf("in NewEmbeddingClient():\n%+w", fmt.Errorf("invalid embedding cache entry in %s: %w", cache, err))
+		}
+		if entry.Model == model {
+			c.vectors[entry.Input] = entry.Embedding
+		}
+	}
+	if err = scanner.Err(); err != nil {
+		return c, fmt.Errorf("in NewEmbeddingClient():\n%+w", err)
+	}
+
+	return c, nil
+}
+
+// First error returned by the endpoint, after which no further requests are sent
+func (c *EmbeddingClient) Err() error {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	return c.err
+}
+
+// Looks up vectors for each input, requesting uncached inputs from the endpoint in batches
+// endpoints reject empty inputs, so they are never requested and have no vector
+func (c *EmbeddingClient) Embed(inputs []string) ([][]float64, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.err != nil {
+		return nil, c.err
+	}
+
+	var (
+		missing []string
+		seen    = make(map[string]bool)
+	)
+	for i := range inputs {
+		if _, ok := c.vectors[inputs[i]]; !ok && !seen[inputs[i]] && inputs[i] != "" {
+			missing = append(missing, inputs[i])
+			seen[inputs[i]] = true
+		}
+	}
+
+	for batch := range slices.Chunk(missing, c.batch) {
+		vecs, err := c.request(batch)
+		if err != nil {
+			c.err = fmt.Errorf("in EmbeddingClient.Embed():\n%+w", err)
+			return nil, c.err
+		}
+		for i := range batch {
+			c.vectors[batch[i]] = vecs[i]
+		}
+		err = c.save(batch)
+		if err != nil {
+			c.err = fmt.Errorf("in EmbeddingClient.Embed():\n%+w", err)
+			return nil, c.err
+		}
+	}
+
+	out := make([][]float64, len(inputs))
+	for i := range inputs {
+		out[i] = c.vectors[inputs[i]]
+	}
+
+	return out, nil
+}
+
+// Sends one batch of inputs to the endpoint
+func (c *EmbeddingClient) request(inputs []string) ([][]float64, error) {
+	body, err := json.Marshal(embeddingRequest{Model: c.model, Input: inputs})
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := c.client.Post(c.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return nil, fmt.Errorf("embedding endpoint %s is unreachable: %w", c.url, err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 512))
+		return nil, fmt.Errorf("embedding endpoint %s returned %s: %s", c.url, resp.Status, strings.TrimSpace(string(msg)))
+	}
+
+	var parsed embeddingResponse
+	err = json.NewDecoder(resp.Body).Decode(&parsed)
+	if err != nil {
+		return nil, fmt.Errorf("embedding endpoint %s returned an invalid response: %w", c.url, err)
+	}
+	if len(parsed.Data) != len(inputs) {
+		return nil, fmt.Errorf("embedding endpoint %s returned %v embeddings for %v inputs", c.url, len(parsed.Data), len(inputs))
+	}
+
+	vecs := make([][]float64, len(inputs))
+	for _, d := range parsed.Data {
+		if d.Index < 0 || d.Index >= len(inputs) || len(d.Embedding) == 0 {
+			return nil, fmt.Errorf("embedding endpoint %s returned an invalid embedding at index %v", c.url, d.Index)
+		}
+		vecs[d.Index] = d.Embedding
+	}
+
+	return vecs, nil
+}
+
+// Appends newly requested vectors to the disk cache
+func (c *EmbeddingClient) save(inputs []string) error {
+	if c.file == "" {
+		return nil
+	}
+
+	f, err := os.OpenFile(c.file, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	enc := json.NewEncoder(f)
+	for i := range inputs {
+		err = enc.Encode(embeddingCacheEntry{Model: c.model, Input: inputs[i], Embedding: c.vectors[inputs[i]]})
+		if err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// Compares expression similarity via endpoint embeddings and cosine similarity
+// empty expressions have no embedding, so they are compared literally
+// endpoint errors are recorded on the client and reported once merging is complete
+func EndpointCosineThreshold(thr float64, c *EmbeddingClient, l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		s1, s2 := strings.Join(e1, " "), strings.Join(e2, " ")
+		if s1 == "" || s2 == "" {
+			if s1 == s2 {
+				l.Printf("equality function %s matched %v and %v, empty expressions\n", "EndpointCosineThreshold", e1, e2)
+			}
+			return s1 == s2
+		}
+		vecs, err := c.Embed([]string{s1, s2})
+		if err != nil {
+			l.Println(err)
+			return false
+		}
+		sim, err := CosineSimilarity(*mat.NewVecDense(len(vecs[0]), vecs[0]), *mat.NewVecDense(len(vecs[1]), vecs[1]))
+		if err != nil {
+			l.Println(err)
+			return false
+		}
+		if sim >= thr {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "EndpointCosineThreshold", e1, e2, thr, sim)
+			similarities.record("EndpointCosineThreshold", e1, e2, thr, sim)
+			return true
+		}
+		return false
+	}
+}
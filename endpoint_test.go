@@ -0,0 +1,155 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:54:28 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Stub embeddings endpoint returning a vector of [len(input), count of "a", count of "b"], recording the size of each request
+// like OpenAI compatible servers, empty inputs are rejected
+func stubEmbeddingServer(batches *[]int) *httptest.Server {
+	return httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var req embeddingRequest
+		err := json.NewDecoder(r.Body).Decode(&req)
+		if err != nil || req.Model == "missing" {
+			http.Error(w, "model not found", http.StatusNotFound)
+			return
+		}
+		if slices.Contains(req.Input, "") {
+			http.Error(w, "input cannot be an empty string", http.StatusBadRequest)
+			return
+		}
+		*batches = append(*batches, len(req.Input))
+		var resp embeddingResponse
+		resp.Data = make([]struct {
+			Index     int       `json:"index"`
+			Embedding []float64 `json:"embedding"`
+		}, len(req.Input))
+		for i, s := range req.Input {
+			resp.Data[i].Index = i
+			resp.Data[i].Embedding = []float64{float64(len(s)), float64(strings.Count(s, "a")), float64(strings.Count(s, "b"))}
+		}
+		json.NewEncoder(w).Encode(resp)
+	}))
+}
+
+func TestEmbeddingClient_Embed(t *testing.T) {
+	type args struct {
+		model  string
+		batch  int
+		inputs [][]string
+	}
+	tests := []struct {
+		args      args
+		want      [][]float64
+		batches   []int
+		assertion assert.ErrorAssertionFunc
+	}{
+		{args: args{model: "m", batch: 2, inputs: [][]string{{}}}, want: [][]float64{}, batches: nil, assertion: assert.NoError},
+		{args: args{model: "m", batch: 2, inputs: [][]string{{"a", "ab", "b"}}}, want: [][]float64{{1, 1, 0}, {2, 1, 1}, {1, 0, 1}}, batches: []int{2, 1}, assertion: assert.NoError},
+		{args: args{model: "m", batch: 64, inputs: [][]string{{"a", "a", "bb"}, {"bb", "a"}}}, want: [][]float64{{2, 0, 2}, {1, 1, 0}}, batches: []int{2}, assertion: assert.NoError},
+		{args: args{model: "m", batch: 64, inputs: [][]string{{"", "a", ""}}}, want: [][]float64{nil, {1, 1, 0}, nil}, batches: []int{1}, assertion: assert.NoError},
+		{args: args{model: "missing", batch: 2, inputs: [][]string{{"a"}}}, want: nil, batches: nil, assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			var batches []int
+			srv := stubEmbeddingServer(&batches)
+			defer srv.Close()
+			c, err := NewEmbeddingClient(srv.URL, tt.args.model, tt.args.batch, "")
+			assert.NoError(t, err)
+			var got [][]float64
+			for i := range tt.args.inputs {
+				got, err = c.Embed(tt.args.inputs[i])
+			}
+			tt.assertion(t, err)
+			assert.Equal(t, err, c.Err())
+			assert.Equal(t, tt.batches, batches)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEmbeddingClient_cache(t *testing.T) {
+	var batches []int
+	srv := stubEmbeddingServer(&batches)
+	defer srv.Close()
+	cache := filepath.Join(t.TempDir(), "embeddings.jsonl")
+
+	c, err := NewEmbeddingClient(srv.URL, "m", 64, cache)
+	assert.NoError(t, err)
+	_, err = c.Embed([]string{"a", "b"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2}, batches)
+
+	c, err = NewEmbeddingClient(srv.URL, "m", 64, cache)
+	assert.NoError(t, err)
+	got, err := c.Embed([]string{"b", "a", "ab"})
+	assert.NoError(t, err)
+	assert.Equal(t, [][]float64{{1, 0, 1}, {1, 1, 0}, {2, 1, 1}}, got)
+	assert.Equal(t, []int{2, 1}, batches)
+
+	c, err = NewEmbeddingClient(srv.URL, "other", 64, cache)
+	assert.NoError(t, err)
+	_, err = c.Embed([]string{"a"})
+	assert.NoError(t, err)
+	assert.Equal(t, []int{2, 1, 1}, batches)
+}
+
+func TestEmbeddingClient_unreachable(t *testing.T) {
+	var batches []int
+	srv := stubEmbeddingServer(&batches)
+	srv.Close()
+
+	c, err := NewEmbeddingClient(srv.URL, "m", 64, "")
+	assert.NoError(t, err)
+	_, err = c.Embed([]string{"a"})
+	assert.ErrorContains(t, err, "is unreachable")
+	eq := EndpointCosineThreshold(0.5, c, nilLogger)
+	assert.False(t, eq([]string{"a"}, []string{"a"}))
+	assert.ErrorContains(t, c.Err(), "is unreachable")
+}
+
+func TestEndpointCosineThreshold(t *testing.T) {
+	type args struct {
+		e1 []string
+		e2 []string
+	}
+	tests := []struct {
+		args args
+		want bool
+	}{
+		{args: args{e1: []string{"a"}, e2: []string{"a"}}, want: true},
+		{args: args{e1: []string{"aa"}, e2: []string{"a"}}, want: true},
+		{args: args{e1: []string{"a"}, e2: []string{"b"}}, want: false},
+		{args: args{e1: []string{"a", "b"}, e2: []string{"b", "a"}}, want: true},
+		{args: args{e1: []string{""}, e2: []string{""}}, want: true},
+		{args: args{e1: []string{""}, e2: []string{"a"}}, want: false},
+		{args: args{e1: []string{"a"}, e2: []string{}}, want: false},
+	}
+	var batches []int
+	srv := stubEmbeddingServer(&batches)
+	defer srv.Close()
+	c, err := NewEmbeddingClient(srv.URL, "m", 64, "")
+	assert.NoError(t, err)
+	eq := EndpointCosineThreshold(0.9, c, nilLogger)
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, eq(tt.args.e1, tt.args.e2))
+			// empty expressions never reach the endpoint, so they raise no error
+			assert.NoError(t, c.Err())
+		})
+	}
+}
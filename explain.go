@@ -0,0 +1,360 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 03:09:12 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// A match of two expression groups by a threshold equality function
+type Match struct {
+	Function   string
+	Similarity float64
+	Threshold  float64
+}
+
+func (m Match) String() string {
+	return fmt.Sprintf("%s similarity %v, threshold %v", m.Function, m.Similarity, m.Threshold)
+}
+
+// Similarities of the expression groups matched by threshold equality functions, kept to explain the merges they enabled
+// a nil set records nothing
+type Similarities struct {
+	mu sync.Mutex
+	m  map[[2]string][]Match
+}
+
+func NewSimilarities() *Similarities {
+	return &Similarities{m: make(map[[2]string][]Match)}
+}
+
+// Records a match of e1 and e2 by equality function name, with similarity sim at threshold t
+func (s *Similarities) record(name string, e1, e2 []string, t float64, sim float64) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	k := pairKey(e1, e2)
+	m := Match{Function: name, Similarity: sim, Threshold: t}
+	if !slices.Contains(s.m[k], m) {
+		s.m[k] = append(s.m[k], m)
+	}
+}
+
+// Lists the matches recorded for e1 and e2
+func (s *Similarities) lookup(e1, e2 []string) []Match {
+	if s == nil {
+		return nil
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	return slices.Clone(s.m[pairKey(e1, e2)])
+}
+
+// One merge or factoring step which introduced productions not found in the corpus
+type NovelStep struct {
+	Step        string   `json:"step"`
+	Rules       []string `json:"rules"`
+	Merged      string   `json:"merged"`
+	Lines       []int    `json:"lines"`
+	Equality    string   `json:"equality,omitempty"`
+	Similarity  []string `json:"similarity,omitempty"`
+	Productions []string `json:"productions"`
+}
+
+// A production not found in the corpus, with the indices of the steps which introduced it
+type NovelProduction struct {
+	Production string `json:"production"`
+	Steps      []int  `json:"steps"`
+}
+
+// Records the productions not found in the corpus introduced by each accepted merge and factoring step
+// productions are compared to the corpus after normalization, keeping class references
+type NovelExplainer struct {
+	mu     sync.Mutex
+	tok    Tokenizer
+	corpus map[string]bool
+	steps  []NovelStep
+	// rules which may be referenced from other rules, keyed by name
+	refs map[string]Rule
+	// alternatives of referenced rules, cleared whenever a rule is registered
+	expanded map[string]map[string]bool
+}
+
+func NewNovelExplainer(t []Text, tok Tokenizer) *NovelExplainer {
+	x := NovelExplainer{tok: tok, corpus: make(map[string]bool), refs: make(map[string]Rule), expanded: make(map[string]map[string]bool)}
+
+	for i := range t {
+		x.corpus[tok.normalize(t[i].text)] = true
+	}
+
+	return &x
+}
+
+// Helper function to collect the alternatives of an expression group, with references to registered rules replaced by their productions
+func (x *NovelExplainer) expand(g []string, depth int) map[string]bool {
+	out := make(map[string]bool)
+
+	for a := range slotSet(g) {
+		for _, alt := range splitAlternatives(a) {
+			if !strings.Contains(alt, "<") {
+				out[alt] = true
+				continue
+			}
+			alts := []string{""}
+			for _, t := range strings.Fields(alt) {
+				var next []string
+				name := strings.TrimSuffix(strings.TrimPrefix(t, "<"), ">")
+				ref, ok := x.refs[name]
+				if !ok || depth >= maxReferenceDepth {
+					for i := range alts {
+						next = append(next, joinExpressions(alts[i], t))
+					}
+					alts = next
+					continue
+				}
+				prods, ok := x.expanded[name]
+				if !ok {
+					prods = make(map[string]bool)
+					for _, p := range x.productions(ref, depth+1) {
+						prods[p] = true
+					}
+					x.expanded[name] = prods
+				}
+				for p := range prods {
+					for i := range alts {
+						next = append(next, joinExpressions(alts[i], p))
+					}
+				}
+				alts = next
+			}
+			for i := range alts {
+				out[alts[i]] = true
+			}
+		}
+	}
+
+	return out
+}
+
+// Helper function to list the productions of a rule, with references to registered rules expanded
+func (x *NovelExplainer) productions(r Rule, depth int) []string {
+	return novelProductions([3]map[string]bool{x.expand(r.pre, depth), x.expand(r.root, depth), x.expand(r.suf, depth)})
+}
+
+// Helper function to list the productions of a merged rule not produced by any of the rules it replaced, each given as expanded expression groups
+// pairs of prefixes and roots are checked against each replaced rule, so only the suffixes completing a novel production are visited
+func novelProductions(merged [3]map[string]bool, replaced ...[3]map[string]bool) []string {
+	var (
+		out        []string
+		candidates = make(map[int][]string)
+	)
+
+	for p := range merged[0] {
+		for m := range merged[1] {
+			var mask int
+			for i := range replaced {
+				if replaced[i][0][p] && replaced[i][1][m] {
+					mask |= 1 << i
+				}
+			}
+			if _, ok := candidates[mask]; !ok {
+				candidates[mask] = []string{}
+				for s := range merged[2] {
+					novel := true
+					for i := range replaced {
+						if mask&(1<<i) != 0 && replaced[i][2][s] {
+							novel = false
+							break
+						}
+					}
+					if novel {
+						candidates[mask] = append(candidates[mask], s)
+					}
+				}
+			}
+			for _, s := range candidates[mask] {
+				out = append(out, joinExpressions(p, m, s))
+			}
+		}
+	}
+
+	return out
+}
+
+// Guard recording the productions not found in the corpus introduced by each merge or factoring step, as step
+// the guard accepts every merge, and should come after all other guards so that only accepted merges are recorded
+// non public rules passed as r2 are factored out rules, and are registered so that references to them can be resolved
+// slots lists the expression groups matched by merge steps, whose recorded similarities are kept with the step
+func (x *NovelExplainer) Guard(step string, equality string, slots string, l *log.Logger) MergeGuard {
+	return func(r1, r2, merged Rule) bool {
+		if r2.isPublic && NovelProductions(r1, r2, merged) == 0 {
+			return true
+		}
+		if !r2.isPublic && slices.Equal(r1.pre, merged.pre) && slices.Equal(r1.root, merged.root) && slices.Equal(r1.suf, merged.suf) {
+			return true
+		}
+
+		x.mu.Lock()
+		defer x.mu.Unlock()
+		replaced := [][3]map[string]bool{{x.expand(r1.pre, 0), x.expand(r1.root, 0), x.expand(r1.suf, 0)}}
+		if r2.isPublic {
+			replaced = append(replaced, [3]map[string]bool{x.expand(r2.pre, 0), x.expand(r2.root, 0), x.expand(r2.suf, 0)})
+		} else if _, ok := x.refs[r2.name()]; !ok {
+			x.refs[r2.name()] = r2
+			clear(x.expanded)
+		}
+
+		var (
+			novel []string
+			seen  = make(map[string]bool)
+		)
+		for _, p := range novelProductions([3]map[string]bool{x.expand(merged.pre, 0), x.expand(merged.root, 0), x.expand(merged.suf, 0)}, replaced...) {
+			p = x.tok.normalize(p)
+			if !x.corpus[p] && !seen[p] {
+				novel = append(novel, p)
+				seen[p] = true
+			}
+		}
+		if len(novel) == 0 {
+			return true
+		}
+		slices.Sort(novel)
+
+		s := NovelStep{
+			Step:        step,
+			Rules:       []string{printRule(r1), printRule(r2)},
+			Merged:      printRule(merged),
+			Lines:       r1.lines,
+			Productions: novel,
+		}
+		if r2.isPublic {
+			s.Lines = unionLines(r1, r2)
+			s.Equality = equality
+			matches := similarities.lookupSlots(r1, r2, slots)
+			for _, c := range slots {
+				for _, m := range matches[c] {
+					s.Similarity = append(s.Similarity, m.String())
+				}
+			}
+		}
+		x.steps = append(x.steps, s)
+		l.Printf("EXPLAIN: step %s of %v and %v introduced %v productions not in the corpus\n", step, r1, r2, len(novel))
+
+		return true
+	}
+}
+
+// Lists the matches recorded for each expression group of r1 and r2 in slots, keyed by slot
+func (s *Similarities) lookupSlots(r1, r2 Rule, slots string) map[rune][]Match {
+	var (
+		out    = make(map[rune][]Match)
+		getter = map[rune]func(Rule) []string{
+			'P': func(r Rule) []string { return r.pre },
+			'R': func(r Rule) []string { return r.root },
+			'S': func(r Rule) []string { return r.suf },
+		}
+	)
+
+	for _, c := range slots {
+		if m := s.lookup(getter[c](r1), getter[c](r2)); len(m) != 0 {
+			out[c] = m
+		}
+	}
+
+	return out
+}
+
+// Productions not found in the corpus and the steps which introduced them
+type NovelReport struct {
+	Corpus      int               `json:"corpus"`
+	Novel       int               `json:"novel"`
+	Productions []NovelProduction `json:"productions"`
+	Steps       []NovelStep       `json:"steps"`
+}
+
+// Collects the productions introduced by all recorded steps, in sorted order
+func (x *NovelExplainer) Report() NovelReport {
+	x.mu.Lock()
+	defer x.mu.Unlock()
+
+	var (
+		report = NovelReport{Corpus: len(x.corpus), Productions: []NovelProduction{}, Steps: slices.Clone(x.steps)}
+		steps  = make(map[string][]int)
+	)
+	for i := range x.steps {
+		for _, p := range x.steps[i].Productions {
+			steps[p] = append(steps[p], i)
+		}
+	}
+	for _, p := range slices.Sorted(maps.Keys(steps)) {
+		report.Productions = append(report.Productions, NovelProduction{Production: p, Steps: steps[p]})
+	}
+	report.Novel = len(report.Productions)
+	if report.Steps == nil {
+		report.Steps = []NovelStep{}
+	}
+
+	return report
+}
+
+// Writes the report as text, listing each step followed by the productions it introduced
+func (r NovelReport) write(w io.Writer) error {
+	var b strings.Builder
+
+	fmt.Fprintf(&b, "%v productions not in the corpus of %v utterances, introduced by %v steps\n", r.Novel, r.Corpus, len(r.Steps))
+	for _, s := range r.Steps {
+		fmt.Fprintf(&b, "\n%s of rules from corpus lines %s\n", s.Step, formatLines(s.Lines))
+		for _, r := range s.Rules {
+			fmt.Fprintf(&b, "rule %s\n", r)
+		}
+		if s.Equality != "" {
+			fmt.Fprintf(&b, "matched by equality function %s", s.Equality)
+			if len(s.Similarity) != 0 {
+				fmt.Fprintf(&b, ", %s", strings.Join(s.Similarity, "; "))
+			}
+			b.WriteString("\n")
+		}
+		fmt.Fprintf(&b, "new rule %s\n", s.Merged)
+		for _, p := range s.Productions {
+			fmt.Fprintf(&b, "\t%s\n", p)
+		}
+	}
+	_, err := io.WriteString(w, b.String())
+
+	return err
+}
+
+// Writes the report as text to stdout, and the full report to a json file if p is not empty
+func (x *NovelExplainer) WriteReport(p string, l *log.Logger) error {
+	report := x.Report()
+	l.Printf("REPORT: %v productions not in the corpus, introduced by %v steps\n", report.Novel, len(report.Steps))
+	err := report.write(os.Stdout)
+	if err != nil {
+		return err
+	}
+	if p == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, b, 0644)
+}
@@ -0,0 +1,119 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 03:09:12 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSimilarities(t *testing.T) {
+	s := NewSimilarities()
+	s.record("CharacterLevenshteinThreshold", []string{"pay"}, []string{"pays"}, 0.7, 0.75)
+	s.record("CharacterLevenshteinThreshold", []string{"pays"}, []string{"pay"}, 0.7, 0.75)
+	s.record("TFIDFCosineThreshold", []string{"pay"}, []string{"pays"}, 0.8, 0.9)
+
+	assert.Equal(t, []Match{{"CharacterLevenshteinThreshold", 0.75, 0.7}, {"TFIDFCosineThreshold", 0.9, 0.8}}, s.lookup([]string{"pays"}, []string{"pay"}))
+	assert.Equal(t, "CharacterLevenshteinThreshold similarity 0.75, threshold 0.7", s.lookup([]string{"pays"}, []string{"pay"})[0].String())
+	assert.Empty(t, s.lookup([]string{"pay"}, []string{"view"}))
+	assert.Equal(t, map[rune][]Match{'R': {{"CharacterLevenshteinThreshold", 0.75, 0.7}, {"TFIDFCosineThreshold", 0.9, 0.8}}}, s.lookupSlots(Rule{pre: []string{"can i"}, root: []string{"pay"}}, Rule{pre: []string{"i"}, root: []string{"pays"}}, "PR"))
+
+	var n *Similarities
+	n.record("CharacterLevenshteinThreshold", []string{"pay"}, []string{"pays"}, 0.7, 0.75)
+	assert.Nil(t, n.lookup([]string{"pay"}, []string{"pays"}))
+}
+
+func Test_novelProductions(t *testing.T) {
+	set := func(s ...string) map[string]bool {
+		m := make(map[string]bool)
+		for i := range s {
+			m[s[i]] = true
+		}
+		return m
+	}
+	tests := []struct {
+		merged   [3]map[string]bool
+		replaced [][3]map[string]bool
+		want     []string
+	}{
+		{merged: [3]map[string]bool{set("a"), set("b"), set("c")}, replaced: [][3]map[string]bool{}, want: []string{"a b c"}},
+		{merged: [3]map[string]bool{set("a"), set("b", "x"), set("c", "y")}, replaced: [][3]map[string]bool{{set("a"), set("b"), set("c")}, {set("a"), set("x"), set("y")}}, want: []string{"a b y", "a x c"}},
+		{merged: [3]map[string]bool{set("a"), set("b", "x"), set("")}, replaced: [][3]map[string]bool{{set("a"), set("b"), set("")}}, want: []string{"a x"}},
+		{merged: [3]map[string]bool{set("a"), set("b"), set("c")}, replaced: [][3]map[string]bool{{set("a"), set("b"), set("c")}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := novelProductions(tt.merged, tt.replaced...)
+			slices.Sort(got)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestNovelExplainer_Guard(t *testing.T) {
+	var (
+		x  = NewNovelExplainer([]Text{{text: "can i pay my bill"}, {text: "can i view my order"}, {text: "can i pay my order"}}, NewWordTokenizer())
+		r1 = Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill"}, isPublic: true, lines: []int{1}}
+		r2 = Rule{pre: []string{"can i"}, root: []string{"view"}, suf: []string{"my order"}, isPublic: true, lines: []int{2}}
+		m  = Rule{pre: []string{"can i"}, root: []string{"pay", "view"}, suf: []string{"my bill", "my order"}, isPublic: true, lines: []int{1, 2}}
+		f  = Rule{pre: []string{}, root: []string{"bill", "invoice"}, suf: []string{}, id: 3}
+	)
+	similarities = NewSimilarities()
+	defer func() { similarities = nil }()
+	similarities.record("TFIDFCosineThreshold", r1.pre, r2.pre, 0.8, 1)
+
+	assert.True(t, x.Guard("MergeP", "tfidf", "P", nilLogger)(r1, r1, r1))
+	assert.Empty(t, x.steps)
+	// can i pay my order is in the corpus, so only can i view my bill is recorded
+	assert.True(t, x.Guard("MergeP", "tfidf", "P", nilLogger)(r1, r2, m))
+	assert.Len(t, x.steps, 1)
+	assert.Equal(t, NovelStep{
+		Step:        "MergeP",
+		Rules:       []string{printRule(r1), printRule(r2)},
+		Merged:      printRule(m),
+		Lines:       []int{1, 2},
+		Equality:    "tfidf",
+		Similarity:  []string{"TFIDFCosineThreshold similarity 1, threshold 0.8"},
+		Productions: []string{"can i view my bill"},
+	}, x.steps[0])
+
+	// factored out rules are resolved, and only their new alternatives are recorded
+	assert.True(t, x.Guard("SynonymFactor", "", "", nilLogger)(r1, f, Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my <bill_invoice_3>"}, isPublic: true, lines: []int{1}}))
+	assert.Len(t, x.steps, 2)
+	assert.Equal(t, []string{"can i pay my invoice"}, x.steps[1].Productions)
+	assert.Equal(t, []int{1}, x.steps[1].Lines)
+	assert.Empty(t, x.steps[1].Equality)
+}
+
+func TestNovelExplainer_Report(t *testing.T) {
+	var (
+		x = NewNovelExplainer([]Text{{text: "can i pay my bill"}}, NewWordTokenizer())
+		p = filepath.Join(t.TempDir(), "novel.json")
+	)
+	x.steps = []NovelStep{
+		{Step: "MergeP", Rules: []string{"a", "b"}, Merged: "c", Lines: []int{1, 2}, Equality: "literal", Productions: []string{"can i view my bill", "can i view my order"}},
+		{Step: "SynonymFactor", Rules: []string{"c", "d"}, Merged: "e", Lines: []int{2}, Productions: []string{"can i view my order"}},
+	}
+
+	want := NovelReport{Corpus: 1, Novel: 2, Productions: []NovelProduction{{Production: "can i view my bill", Steps: []int{0}}, {Production: "can i view my order", Steps: []int{0, 1}}}, Steps: x.steps}
+	assert.Equal(t, want, x.Report())
+	var b strings.Builder
+	assert.Nil(t, x.Report().write(&b))
+	assert.Equal(t, "2 productions not in the corpus of 1 utterances, introduced by 2 steps\n\nMergeP of rules from corpus lines 1-2\nrule a\nrule b\nmatched by equality function literal\nnew rule c\n\tcan i view my bill\n\tcan i view my order\n\nSynonymFactor of rules from corpus lines 2\nrule c\nrule d\nnew rule e\n\tcan i view my order\n", b.String())
+
+	assert.Nil(t, x.WriteReport(p, nilLogger))
+	f, _ := os.ReadFile(p)
+	var got NovelReport
+	assert.Nil(t, json.Unmarshal(f, &got))
+	assert.Equal(t, want, got)
+	assert.Equal(t, NovelReport{Productions: []NovelProduction{}, Steps: []NovelStep{}}, NewNovelExplainer([]Text{}, NewWordTokenizer()).Report())
+}
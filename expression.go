@@ -0,0 +1,252 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:01:54 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"fmt"
+	"log"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Parsed -merge expression, either a named strategy (op == "") or AND, OR, NOT over sub-expressions
+type mergeExpr struct {
+	op        string
+	name      string
+	threshold float64
+	args      []*mergeExpr
+}
+
+var mergeExprTokens = regexp.MustCompile(`\(|\)|>=|[0-9]*\.?[0-9]+|[A-Za-z]+|\S`)
+
+// Parses a -merge expression such as "literal OR (tokenDistance>=0.8 AND posTag)"
+// operators are case insensitive, NOT binds tighter than AND, which binds tighter than OR
+// strategies without a threshold use -sim, and an empty expression is literal matching
+func ParseMergeExpression(s string) (*mergeExpr, error) {
+	if strings.TrimSpace(s) == "" {
+		return &mergeExpr{name: "literal", threshold: -1}, nil
+	}
+
+	p := &mergeExprParser{tokens: mergeExprTokens.FindAllString(s, -1)}
+	expr, err := p.or()
+	if err != nil {
+		return nil, fmt.Errorf("in ParseMergeExpression(%v):\n%+w", s, err)
+	}
+	if p.i < len(p.tokens) {
+		return nil, fmt.Errorf("in ParseMergeExpression(%v):\n%+w", s, fmt.Errorf("unexpected %q", p.tokens[p.i]))
+	}
+
+	return expr, nil
+}
+
+type mergeExprParser struct {
+	tokens []string
+	i      int
+}
+
+// Returns the next token if it matches any of s (case insensitively), advancing past it
+func (p *mergeExprParser) accept(s ...string) bool {
+	if p.i >= len(p.tokens) {
+		return false
+	}
+	for i := range s {
+		if strings.EqualFold(p.tokens[p.i], s[i]) {
+			p.i++
+			return true
+		}
+	}
+	return false
+}
+
+func (p *mergeExprParser) or() (*mergeExpr, error) {
+	return p.binary("OR", p.and)
+}
+
+func (p *mergeExprParser) and() (*mergeExpr, error) {
+	return p.binary("AND", p.not)
+}
+
+// Parses operands separated by op, flattening repeated operators into one node
+func (p *mergeExprParser) binary(op string, operand func() (*mergeExpr, error)) (*mergeExpr, error) {
+	first, err := operand()
+	if err != nil {
+		return nil, err
+	}
+	args := []*mergeExpr{first}
+	for p.accept(op) {
+		next, err := operand()
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, next)
+	}
+	if len(args) == 1 {
+		return first, nil
+	}
+
+	return &mergeExpr{op: op, args: args}, nil
+}
+
+func (p *mergeExprParser) not() (*mergeExpr, error) {
+	if p.accept("NOT") {
+		arg, err := p.not()
+		if err != nil {
+			return nil, err
+		}
+		return &mergeExpr{op: "NOT", args: []*mergeExpr{arg}}, nil
+	}
+
+	return p.atom()
+}
+
+func (p *mergeExprParser) atom() (*mergeExpr, error) {
+	if p.i >= len(p.tokens) {
+		return nil, fmt.Errorf("unexpected end of expression")
+	}
+	if p.accept("(") {
+		expr, err := p.or()
+		if err != nil {
+			return nil, err
+		}
+		if !p.accept(")") {
+			return nil, fmt.Errorf("missing closing parenthesis")
+		}
+		return expr, nil
+	}
+
+	name := p.tokens[p.i]
+	if !slices.Contains(mergeStrategies, name) {
+		return nil, fmt.Errorf("unknown merge strategy %q, must be one of %v", name, mergeStrategies)
+	}
+	p.i++
+
+	expr := &mergeExpr{name: name, threshold: -1}
+	if p.accept(">=") {
+		if slices.Contains(exactStrategies, name) {
+			return nil, fmt.Errorf("merge strategy %s does not take a threshold", name)
+		}
+		if p.i >= len(p.tokens) {
+			return nil, fmt.Errorf("missing threshold for %s", name)
+		}
+		t, err := strconv.ParseFloat(p.tokens[p.i], 64)
+		if err != nil || t < 0 || t > 1 {
+			return nil, fmt.Errorf("threshold for %s must be a number between 0 and 1, got %q", name, p.tokens[p.i])
+		}
+		p.i++
+		expr.threshold = t
+	}
+
+	return expr, nil
+}
+
+// Threshold for a strategy, or sim if none was given
+func (e *mergeExpr) similarity(sim float64) float64 {
+	if e.threshold < 0 {
+		return sim
+	}
+	return e.threshold
+}
+
+func (e *mergeExpr) String() string {
+	switch e.op {
+	case "":
+		if e.threshold < 0 {
+			return e.name
+		}
+		return fmt.Sprintf("%s>=%v", e.name, e.threshold)
+	case "NOT":
+		return fmt.Sprintf("NOT %v", e.args[0].parenthesize())
+	default:
+		var args []string
+		for i := range e.args {
+			args = append(args, e.args[i].parenthesize())
+		}
+		return strings.Join(args, fmt.Sprintf(" %s ", e.op))
+	}
+}
+
+// Helper function to wrap AND and OR sub-expressions in parentheses when printing
+func (e *mergeExpr) parenthesize() string {
+	if e.op == "AND" || e.op == "OR" {
+		return fmt.Sprintf("(%v)", e)
+	}
+	return e.String()
+}
+
+// Combines named strategies into one equality function following a parsed -merge expression
+// leaf builds the equality function for a strategy name and threshold, strategies without a threshold use sim
+// each match is logged and returned with the sub-criteria which caused it
+func ExpressionEqual(expr *mergeExpr, leaf func(name string, sim float64) (EqualityFunction, error), sim float64, l *log.Logger) (explainedEqual, error) {
+	eval, err := compileMergeExpr(expr, leaf, sim)
+	if err != nil {
+		return func(e1, e2 []string) (bool, string) { return false, "" }, fmt.Errorf("in ExpressionEqual():\n%+w", err)
+	}
+
+	return func(e1, e2 []string) (bool, string) {
+		ok, reason := eval(e1, e2)
+		if ok {
+			l.Printf("equality function %s matched %v and %v, expression %v, matched by %s\n", "ExpressionEqual", e1, e2, expr, reason)
+		}
+		return ok, reason
+	}, nil
+}
+
+// Equality function which also reports the sub-criteria that decided the result
+type explainedEqual func(e1, e2 []string) (bool, string)
+
+func compileMergeExpr(expr *mergeExpr, leaf func(name string, sim float64) (EqualityFunction, error), sim float64) (explainedEqual, error) {
+	if expr.op == "" {
+		eq, err := leaf(expr.name, expr.similarity(sim))
+		if err != nil {
+			return nil, err
+		}
+		name := expr.String()
+		return func(e1, e2 []string) (bool, string) { return eq(e1, e2), name }, nil
+	}
+
+	var args []explainedEqual
+	for i := range expr.args {
+		arg, err := compileMergeExpr(expr.args[i], leaf, sim)
+		if err != nil {
+			return nil, err
+		}
+		args = append(args, arg)
+	}
+
+	switch expr.op {
+	case "NOT":
+		return func(e1, e2 []string) (bool, string) {
+			ok, reason := args[0](e1, e2)
+			return !ok, fmt.Sprintf("NOT %s", reason)
+		}, nil
+	case "AND":
+		return func(e1, e2 []string) (bool, string) {
+			var reasons []string
+			for i := range args {
+				ok, reason := args[i](e1, e2)
+				if !ok {
+					return false, reason
+				}
+				reasons = append(reasons, reason)
+			}
+			return true, fmt.Sprintf("(%s)", strings.Join(reasons, " AND "))
+		}, nil
+	default:
+		return func(e1, e2 []string) (bool, string) {
+			var reasons []string
+			for i := range args {
+				ok, reason := args[i](e1, e2)
+				if ok {
+					return true, reason
+				}
+				reasons = append(reasons, reason)
+			}
+			return false, fmt.Sprintf("(%s)", strings.Join(reasons, " OR "))
+		}, nil
+	}
+}
@@ -0,0 +1,107 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:01:54 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bytes"
+	"fmt"
+	"log"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseMergeExpression(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		args      args
+		want      string
+		assertion assert.ErrorAssertionFunc
+	}{
+		{args: args{s: ""}, want: "literal", assertion: assert.NoError},
+		{args: args{s: "tfidf"}, want: "tfidf", assertion: assert.NoError},
+		{args: args{s: "tfidf>=0.5"}, want: "tfidf>=0.5", assertion: assert.NoError},
+		{args: args{s: "literal OR tokenDistance>=.8"}, want: "literal OR tokenDistance>=0.8", assertion: assert.NoError},
+		{args: args{s: "literal or (tokenDistance >= 0.8 and posTag)"}, want: "literal OR (tokenDistance>=0.8 AND posTag)", assertion: assert.NoError},
+		{args: args{s: "literal OR tokenDistance AND posTag"}, want: "literal OR (tokenDistance AND posTag)", assertion: assert.NoError},
+		{args: args{s: "(literal OR tokenDistance) AND NOT NOT posTag"}, want: "(literal OR tokenDistance) AND NOT NOT posTag", assertion: assert.NoError},
+		{args: args{s: "NOT (literal OR dice)"}, want: "NOT (literal OR dice)", assertion: assert.NoError},
+		{args: args{s: "a OR b OR c"}, want: "", assertion: assert.Error},
+		{args: args{s: "literal OR"}, want: "", assertion: assert.Error},
+		{args: args{s: "(literal OR dice"}, want: "", assertion: assert.Error},
+		{args: args{s: "literal dice"}, want: "", assertion: assert.Error},
+		{args: args{s: "dice>="}, want: "", assertion: assert.Error},
+		{args: args{s: "dice>=1.5"}, want: "", assertion: assert.Error},
+		{args: args{s: "dice>=posTag"}, want: "", assertion: assert.Error},
+		{args: args{s: "posTag>=0.5"}, want: "", assertion: assert.Error},
+		{args: args{s: "tfidf OR literal>=0.3"}, want: "", assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := ParseMergeExpression(tt.args.s)
+			tt.assertion(t, err)
+			if err != nil {
+				return
+			}
+			assert.Equal(t, tt.want, got.String())
+		})
+	}
+}
+
+func TestExpressionEqual(t *testing.T) {
+	type args struct {
+		expr string
+		e1   []string
+		e2   []string
+	}
+	tests := []struct {
+		args args
+		want bool
+		log  string
+	}{
+		{args: args{expr: "literal", e1: []string{"a b"}, e2: []string{"a b"}}, want: true, log: "matched by literal"},
+		{args: args{expr: "literal OR tokenDistance", e1: []string{"a b c"}, e2: []string{"a b d"}}, want: false, log: ""},
+		{args: args{expr: "literal OR tokenDistance>=0.6", e1: []string{"a b c"}, e2: []string{"a b d"}}, want: true, log: "matched by tokenDistance>=0.6"},
+		{args: args{expr: "tokenDistance>=0.6 AND charDistance>=0.9", e1: []string{"a b c"}, e2: []string{"a b d"}}, want: false, log: ""},
+		{args: args{expr: "tokenDistance>=0.6 AND charDistance", e1: []string{"a b c"}, e2: []string{"a b d"}}, want: true, log: "matched by (tokenDistance>=0.6 AND charDistance)"},
+		{args: args{expr: "tokenDistance>=0.6 AND NOT literal", e1: []string{"a b c"}, e2: []string{"a b c"}}, want: false, log: ""},
+		{args: args{expr: "NOT (literal OR charDistance>=0.9)", e1: []string{"a b c"}, e2: []string{"x y z"}}, want: true, log: "matched by NOT (literal OR charDistance>=0.9)"},
+	}
+	leaf := func(name string, sim float64) (EqualityFunction, error) {
+		switch name {
+		case "literal":
+			return LiteralEqual(nilLogger), nil
+		case "tokenDistance":
+			return TokenLevenshteinThreshold(sim, nilLogger), nil
+		case "charDistance":
+			return CharacterLevenshteinThreshold(sim, nilLogger), nil
+		default:
+			return nil, fmt.Errorf("unsupported strategy %s", name)
+		}
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			var buf bytes.Buffer
+			expr, err := ParseMergeExpression(tt.args.expr)
+			assert.NoError(t, err)
+			eq, err := ExpressionEqual(expr, leaf, 0.8, log.New(&buf, "", 0))
+			assert.NoError(t, err)
+			got, reason := eq(tt.args.e1, tt.args.e2)
+			assert.Equal(t, tt.want, got)
+			if tt.want {
+				assert.Contains(t, buf.String(), tt.log)
+				assert.Equal(t, tt.log, "matched by "+reason)
+			} else {
+				assert.Empty(t, buf.String())
+			}
+		})
+	}
+	expr, _ := ParseMergeExpression("literal OR posTag")
+	_, err := ExpressionEqual(expr, leaf, 0.8, nilLogger)
+	assert.Error(t, err)
+}
@@ -16,11 +16,12 @@ import (
 )
 
 // Function that abstracts one or more rules out from a slice of rules based on some condition (frequency/content)
-type FactorFunction func(r []Rule) []Rule
+// guards are passed each rule, the factored out rule, and the rule after factoring, and rules are left unchanged if any guard rejects the step
+type FactorFunction func(r []Rule, g ...MergeGuard) []Rule
 
 // Factor expressions to rules based on their frequency (matched by literal match)
 func ExpressionFactor(f int, l *log.Logger) FactorFunction {
-	return func(rules []Rule) []Rule {
+	return func(rules []Rule, g ...MergeGuard) []Rule {
 		getCounts := func(r []Rule) map[string]int {
 			counts := make(map[string]int)
 			slices.SortStableFunc(r, func(i, j Rule) int {
@@ -90,7 +91,12 @@ func ExpressionFactor(f int, l *log.Logger) FactorFunction {
 				f := Rule{pre: []string{}, root: []string{n}, suf: []string{}, isPublic: false, id: len(rules) + 1}
 				l.Printf("FACTOR: factor function %s extracted %v to new rule\n", "ExpressionFactor", f.print(f.name()))
 				for i := range rules {
-					rules[i] = factor(rules[i], f)
+					if rule := factor(rules[i], f); allowMerge(g, rules[i], f, rule) {
+						if references(rule, f) {
+							f.lines = unionLines(f, rules[i])
+						}
+						rules[i] = rule
+					}
 				}
 				rules = append(rules, f)
 			}
@@ -102,7 +108,7 @@ func ExpressionFactor(f int, l *log.Logger) FactorFunction {
 
 // Factor expressions to rules based on their frequency (matched by constituency tags)
 func ConstituencyFactor(tag SyntacticTagger, f int, l *log.Logger) FactorFunction {
-	return func(rules []Rule) []Rule {
+	return func(rules []Rule, g ...MergeGuard) []Rule {
 		getCounts := func(r []Rule) map[string]int {
 			counts := make(map[string]int)
 			slices.SortStableFunc(r, func(i, j Rule) int {
@@ -211,7 +217,12 @@ func ConstituencyFactor(tag SyntacticTagger, f int, l *log.Logger) FactorFunctio
 				f := Rule{pre: []string{}, root: syns[n], suf: []string{}, isPublic: false, id: len(rules) + 1}
 				l.Printf("FACTOR: factor function %s extracted %v to new rule\n", "ConstituencyFactor", f.print(f.name()))
 				for i := range rules {
-					rules[i] = factor(rules[i], f)
+					if rule := factor(rules[i], f); allowMerge(g, rules[i], f, rule) {
+						if references(rule, f) {
+							f.lines = unionLines(f, rules[i])
+						}
+						rules[i] = rule
+					}
 				}
 				rules = append(rules, f)
 			}
@@ -222,7 +233,7 @@ func ConstituencyFactor(tag SyntacticTagger, f int, l *log.Logger) FactorFunctio
 
 // Factor expressions based on user provided synonyms, regardless of frequency
 func SynonymFactor(syn Synonyms, tok Tokenizer, l *log.Logger) FactorFunction {
-	return func(rules []Rule) []Rule {
+	return func(rules []Rule, g ...MergeGuard) []Rule {
 		scanSubseq := func(s1, s2 []string) ([]int, bool) {
 			var res []int
 
@@ -304,7 +315,12 @@ func SynonymFactor(syn Synonyms, tok Tokenizer, l *log.Logger) FactorFunction {
 				if !strings.Contains(strings.Join([]string{strings.Join(rules[i].pre, ""), strings.Join(rules[i].root, ""), strings.Join(rules[i].suf, "")}, " "), k) {
 					continue
 				}
-				rules[i] = factor(rules[i], f, tok)
+				if rule := factor(rules[i].clone(), f, tok); allowMerge(g, rules[i], f, rule) {
+					if references(rule, f) {
+						f.lines = unionLines(f, rules[i])
+					}
+					rules[i] = rule
+				}
 			}
 			rules = append(rules, f)
 		}
@@ -313,6 +329,21 @@ func SynonymFactor(syn Synonyms, tok Tokenizer, l *log.Logger) FactorFunction {
 
 }
 
+// Helper function to check if a rule references rule f, so that a factored out rule is built from the corpus lines of the rules referencing it
+func references(r Rule, f Rule) bool {
+	ref := fmt.Sprintf("<%s>", f.name())
+
+	for _, g := range [][]string{r.pre, r.root, r.suf} {
+		for i := range g {
+			if strings.Contains(g[i], ref) {
+				return true
+			}
+		}
+	}
+
+	return false
+}
+
 // Set of main term and synonyms
 type Synonyms map[string][]string
 
@@ -71,7 +71,7 @@ func TestExpressionFactor(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -124,7 +124,7 @@ func TestSynonymFactor(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -178,7 +178,7 @@ func TestConstituencyFactor(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -200,3 +200,25 @@ func TestConstituencyFactor(t *testing.T) {
 		})
 	}
 }
+
+func TestFactor_lines(t *testing.T) {
+	tests := []struct {
+		f FactorFunction
+	}{
+		{f: ExpressionFactor(1, nilLogger)},
+		{f: SynonymFactor(Synonyms{"my bill": {"my invoice"}}, NewWordTokenizer(), nilLogger)},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			rules := []Rule{
+				{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill"}, isPublic: true, id: 0, lines: []int{3}},
+				{pre: []string{"can i"}, root: []string{"view"}, suf: []string{"my bill"}, isPublic: true, id: 1, lines: []int{1, 5}},
+				{pre: []string{"how do i"}, root: []string{"cancel"}, suf: []string{"my order"}, isPublic: true, id: 2, lines: []int{2}},
+			}
+			res := tt.f(rules)
+			f := res[len(res)-1]
+			assert.False(t, f.isPublic)
+			assert.Equal(t, []int{1, 3, 5}, f.lines)
+		})
+	}
+}
@@ -6,17 +6,31 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
+	"maps"
 	"os"
+	"regexp"
 	"slices"
 	"strings"
 
 	"github.com/urfave/cli/v3"
 )
 
+// Matches rule references within a printed expression
+var ruleReference = regexp.MustCompile(`<[^>]*>`)
+
 // Struct to handle grammar export
 type Grammar struct {
 	Rules []Rule
+	// texts holding written forms of spoken form expansions, keyed by corpus line, their written forms are emitted as tags on the alternatives taken from them
+	Tags map[int]Text
+	// pipeline the grammar was built by, recorded in the header
+	Pipeline string
+	// write the corpus lines of each rule as comments above the rule
+	comments bool
+	// corpus lines of each rule written, keyed by rule name
+	sources map[string][]int
 }
 
 // Constructs grammar headers including configuration and jsgf declarations
@@ -42,22 +56,181 @@ func (g *Grammar) frontMatter(c *cli.Command) string {
 		b.WriteString(fmt.Sprintf("\"%s\":%v, ", f.Names()[0], f.Get()))
 	}
 
-	b.WriteString("}\n\n")
+	b.WriteString("}\n")
+	if g.Pipeline != "" {
+		b.WriteString(fmt.Sprintf("#pipeline: %s\n", g.Pipeline))
+	}
+	b.WriteString("\n")
 	b.WriteString("grammar main;\n\n")
 
 	return b.String()
 }
 
+// Returns a function attaching written forms as JSGF tags to the spoken forms found in the expansion of a printed rule
+// each alternative is only tagged with the written forms of the texts it was taken from, among the texts of the corpus lines of the rule
+// longer spoken forms take precedence over spoken forms they contain
+func (g *Grammar) tagger() func(Rule, string) string {
+	if len(g.Tags) == 0 {
+		return func(r Rule, s string) string { return s }
+	}
+
+	var (
+		patterns    = make(map[string]*regexp.Regexp)
+		alternative = regexp.MustCompile(`[^()\[\]|;]+`)
+		escape      = strings.NewReplacer(`\`, `\\`, "{", `\{`, "}", `\}`)
+	)
+
+	return func(r Rule, s string) string {
+		var texts []Text
+
+		for _, l := range r.lines {
+			if t, ok := g.Tags[l]; ok && !slices.ContainsFunc(texts, func(u Text) bool { return u.text == t.text }) {
+				texts = append(texts, t)
+			}
+		}
+		name, expr, found := strings.Cut(s, " =")
+		if !found || len(texts) == 0 {
+			return s
+		}
+
+		expr = alternative.ReplaceAllStringFunc(expr, func(alt string) string {
+			var (
+				tags = make(map[string]string)
+				alts []string
+			)
+
+			for _, t := range texts {
+				if !takenFrom(alt, t) {
+					continue
+				}
+				for k, v := range t.written {
+					if _, ok := tags[k]; !ok {
+						tags[k] = v
+					}
+				}
+			}
+			if len(tags) == 0 {
+				return alt
+			}
+
+			keys := slices.Collect(maps.Keys(tags))
+			slices.SortStableFunc(keys, func(i, j string) int {
+				if len(i) == len(j) {
+					return strings.Compare(i, j)
+				}
+				return len(j) - len(i)
+			})
+			for _, k := range keys {
+				alts = append(alts, regexp.QuoteMeta(k))
+			}
+			// rule references are matched first so that they are skipped over
+			pattern := fmt.Sprintf(`<[^>]*>|\b(?:%s)\b`, strings.Join(alts, "|"))
+			re, ok := patterns[pattern]
+			if !ok {
+				re = regexp.MustCompile(pattern)
+				patterns[pattern] = re
+			}
+
+			return re.ReplaceAllStringFunc(alt, func(m string) string {
+				if strings.HasPrefix(m, "<") {
+					return m
+				}
+				return fmt.Sprintf("(%s) {%s}", m, escape.Replace(tags[m]))
+			})
+		})
+		return fmt.Sprint(name, " =", expr)
+	}
+}
+
+// Helper function to check if an alternative of a printed rule was taken from a text, either from its content or from the values of its class references
+// rule references within the alternative match any content
+func takenFrom(alt string, t Text) bool {
+	var (
+		padded = fmt.Sprintf(" %s ", t.text)
+		found  = false
+	)
+
+	for _, p := range ruleReference.Split(alt, -1) {
+		p = strings.TrimSpace(p)
+		if p == "" {
+			continue
+		}
+		if !strings.Contains(padded, fmt.Sprintf(" %s ", p)) {
+			found = false
+			break
+		}
+		found = true
+	}
+	if found {
+		return true
+	}
+	for _, v := range t.classes {
+		if slices.Contains(v, strings.TrimSpace(alt)) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Records the corpus lines of a rule under the name it is written with, returning them as a comment to write above the rule if comments are set
+func (g *Grammar) source(r Rule) string {
+	if len(r.lines) == 0 {
+		return ""
+	}
+	if g.sources == nil {
+		g.sources = make(map[string][]int)
+	}
+	g.sources[r.name()] = r.lines
+	if !g.comments {
+		return ""
+	}
+
+	return fmt.Sprintf("// lines %s\n", formatLines(r.lines))
+}
+
+// Formats sorted line numbers, joining consecutive lines into ranges
+func formatLines(l []int) string {
+	var s []string
+
+	for i := 0; i < len(l); i++ {
+		j := i
+		for j+1 < len(l) && l[j+1] == l[j]+1 {
+			j++
+		}
+		if j == i {
+			s = append(s, fmt.Sprint(l[i]))
+			continue
+		}
+		s = append(s, fmt.Sprintf("%v-%v", l[i], l[j]))
+		i = j
+	}
+
+	return strings.Join(s, ", ")
+}
+
+// Writes the corpus lines of each rule written to a json file, keyed by rule name
+func (g *Grammar) writeSources(p string) error {
+	b, err := json.MarshalIndent(g.sources, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, b, 0644)
+}
+
 // Constructs grammar body with all non-factored rules set as public
 func (g *Grammar) body() string {
 	var b strings.Builder
+	var tag = g.tagger()
 	slices.SortStableFunc(g.Rules, func(i, j Rule) int {
 		return strings.Compare(i.print(""), j.print(""))
 	})
 
 	for _, rule := range g.Rules {
 		if rule.isPublic && !rule.isEmpty() {
-			b.WriteString(rule.print(rule.name()))
+			b.WriteString(g.source(rule))
+			b.WriteString(tag(rule, rule.print(rule.name())))
 			b.WriteString("\n")
 		}
 	}
@@ -65,7 +238,8 @@ func (g *Grammar) body() string {
 
 	for _, rule := range g.Rules {
 		if !rule.isPublic && !rule.isEmpty() {
-			b.WriteString(rule.print(rule.name()))
+			b.WriteString(g.source(rule))
+			b.WriteString(tag(rule, rule.print(rule.name())))
 			b.WriteString("\n")
 		}
 	}
@@ -77,6 +251,7 @@ func (g *Grammar) body() string {
 func (g *Grammar) bodyMain() string {
 	var b strings.Builder
 	var main = Rule{isPublic: true}
+	var tag = g.tagger()
 
 	for _, rule := range g.Rules {
 		if !rule.isPublic {
@@ -96,7 +271,8 @@ func (g *Grammar) bodyMain() string {
 			continue
 		}
 		rule.isPublic = false
-		b.WriteString(rule.print(rule.name()))
+		b.WriteString(g.source(rule))
+		b.WriteString(tag(rule, rule.print(rule.name())))
 		b.WriteString("\n")
 	}
 	b.WriteString("\n")
@@ -104,7 +280,7 @@ func (g *Grammar) bodyMain() string {
 	return strings.TrimSpace(b.String())
 }
 
-// Writes grammar to file or stdout
+// Writes grammar to file or stdout, along with the corpus lines of each rule if a provenance file is set
 func (g *Grammar) write(c *cli.Command) error {
 	var (
 		err       error
@@ -113,30 +289,25 @@ func (g *Grammar) write(c *cli.Command) error {
 		printMain = c.Bool("main")
 	)
 
+	g.comments = c.Bool("provenance")
 	b.WriteString(g.frontMatter(c))
-
-	switch {
-	case out == "" && !printMain:
-		b.WriteString(g.body())
-		fmt.Println(b.String())
-		return nil
-	case out == "" && printMain:
+	if printMain {
 		b.WriteString(g.bodyMain())
-		fmt.Println(b.String())
-		return nil
-	case !printMain:
+	} else {
 		b.WriteString(g.body())
+	}
+	// the grammar is written first, so a bad provenance file path does not lose it
+	if out == "" {
+		fmt.Println(b.String())
+	} else {
 		err = os.WriteFile(out, []byte(b.String()), 0644)
 		if err != nil {
 			return err
 		}
-	case printMain:
-		b.WriteString(g.bodyMain())
-		err = os.WriteFile(out, []byte(b.String()), 0644)
-		if err != nil {
-			return err
-		}
-	default:
 	}
+	if c.String("provenanceFile") != "" {
+		err = g.writeSources(c.String("provenanceFile"))
+	}
+
 	return err
 }
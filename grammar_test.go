@@ -7,7 +7,10 @@ package main
 
 import (
 	"bufio"
+	"encoding/json"
 	"os"
+	"path/filepath"
+	"slices"
 	"testing"
 
 	"github.com/stretchr/testify/assert"
@@ -44,7 +47,7 @@ func TestGrammar_body(t *testing.T) {
 				t.text = tk.normalize(t.text)
 				tx[i] = t
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -95,7 +98,7 @@ func TestGrammar_bodyMain(t *testing.T) {
 				t.text = tk.normalize(t.text)
 				tx[i] = t
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -146,4 +149,94 @@ func TestGrammar_frontMatter(t *testing.T) {
 			assert.Equal(t, tt.want, g.frontMatter(&c))
 		})
 	}
+
+	g := Grammar{Pipeline: `{"stages":[]}`}
+	assert.Contains(t, g.frontMatter(&cli.Command{}), "}\n#pipeline: {\"stages\":[]}\n\ngrammar main;")
+}
+
+func TestGrammar_tagger(t *testing.T) {
+	type args struct {
+		t map[int]Text
+		r Rule
+		s string
+	}
+	tests := []struct {
+		args args
+		want string
+	}{
+		{args: args{t: map[int]Text{}, r: Rule{lines: []int{1}}, s: "public <three> = (three);"}, want: "public <three> = (three);"},
+		{args: args{t: map[int]Text{1: {text: "three", written: map[string]string{"three": "3"}}}, r: Rule{lines: []int{1}}, s: "public <three> = (three|four);"}, want: "public <three> = ((three) {3}|four);"},
+		{args: args{t: map[int]Text{1: {text: "send me three", written: map[string]string{"three": "3"}}, 2: {text: "send me three hundred dollars", written: map[string]string{"three hundred dollars": "$300"}}}, r: Rule{lines: []int{1, 2}}, s: "<a> = (send me) (three hundred dollars|three);"}, want: "<a> = (send me) ((three hundred dollars) {$300}|(three) {3});"},
+		{args: args{t: map[int]Text{1: {text: "i want three", written: map[string]string{"three": "3"}}}, r: Rule{lines: []int{1}}, s: "<a> = (threes|<three>|<three_2>) [three];"}, want: "<a> = (threes|<three>|<three_2>) [(three) {3}];"},
+		{args: args{t: map[int]Text{1: {text: "brace", written: map[string]string{"brace": "{x}"}}}, r: Rule{lines: []int{1}}, s: "<a> = (brace);"}, want: "<a> = ((brace) {\\{x\\}});"},
+		// alternatives are only tagged with the written forms of the texts they were taken from
+		{args: args{t: map[int]Text{1: {text: "call one now", written: map[string]string{"one": "1"}}}, r: Rule{lines: []int{1, 2}}, s: "public <a> = (call one now|which one do you want);"}, want: "public <a> = (call (one) {1} now|which one do you want);"},
+		{args: args{t: map[int]Text{1: {text: "call one now", written: map[string]string{"one": "1"}}}, r: Rule{lines: []int{2}}, s: "<a> = (call) (one);"}, want: "<a> = (call) (one);"},
+		{args: args{t: map[int]Text{1: {text: "call one now", written: map[string]string{"one": "1"}}}, r: Rule{lines: []int{1}}, s: "<a> = (<b> one now|<b> one later);"}, want: "<a> = (<b> (one) {1} now|<b> one later);"},
+		{args: args{t: map[int]Text{1: {text: "send <number> now", classes: map[string][]string{"number": {"two"}}, written: map[string]string{"two": "2"}}}, r: Rule{lines: []int{1}}, s: "<number> = (two|three);"}, want: "<number> = ((two) {2}|three);"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			g := Grammar{Tags: tt.args.t}
+			assert.Equal(t, tt.want, g.tagger()(tt.args.r, tt.args.s))
+		})
+	}
+}
+
+func Test_formatLines(t *testing.T) {
+	tests := []struct {
+		l    []int
+		want string
+	}{
+		{l: []int{}, want: ""},
+		{l: []int{4}, want: "4"},
+		{l: []int{1, 2, 3, 7}, want: "1-3, 7"},
+		{l: []int{1, 3, 4, 6, 7, 8}, want: "1, 3-4, 6-8"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, formatLines(tt.l))
+		})
+	}
+}
+
+func TestGrammar_source(t *testing.T) {
+	var (
+		rules = []Rule{
+			{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"<my_bill_3>"}, isPublic: true, id: 1, lines: []int{1, 2, 4}},
+			{pre: []string{}, root: []string{"my bill"}, suf: []string{}, id: 3, lines: []int{1, 2}},
+			{pre: []string{}, root: []string{"hi"}, suf: []string{}, isPublic: true, id: 2},
+		}
+		p = filepath.Join(t.TempDir(), "lines.json")
+	)
+
+	g := Grammar{Rules: slices.Clone(rules), comments: true}
+	assert.Equal(t, "// lines 1-2, 4\npublic <pay_1> = (can i) (pay) (<my_bill_3>);\npublic <hi_2> = (hi);\n\n// lines 1-2\n<my_bill_3> = (my bill);", g.body())
+	assert.Equal(t, map[string][]int{"pay_1": {1, 2, 4}, "my_bill_3": {1, 2}}, g.sources)
+	assert.Nil(t, g.writeSources(p))
+	b, _ := os.ReadFile(p)
+	var got map[string][]int
+	assert.Nil(t, json.Unmarshal(b, &got))
+	assert.Equal(t, g.sources, got)
+
+	g = Grammar{Rules: slices.Clone(rules)}
+	assert.NotContains(t, g.bodyMain(), "// lines")
+	assert.Len(t, g.sources, 2)
+}
+
+func TestGrammar_write(t *testing.T) {
+	var (
+		out = filepath.Join(t.TempDir(), "out.jsgf")
+		c   = cli.Command{Flags: []cli.Flag{
+			&cli.StringFlag{Name: "outFile", Value: out},
+			&cli.StringFlag{Name: "provenanceFile", Value: filepath.Join(t.TempDir(), "missing", "lines.json")},
+		}}
+		g = Grammar{Rules: []Rule{{pre: []string{}, root: []string{"hi"}, suf: []string{}, isPublic: true, lines: []int{1}}}}
+	)
+
+	// the grammar is still written when the provenance file can not be
+	assert.Error(t, g.write(&c))
+	b, err := os.ReadFile(out)
+	assert.NoError(t, err)
+	assert.Contains(t, string(b), "public <hi> = (hi);")
 }
@@ -0,0 +1,187 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:47:12 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"encoding/json"
+	"hash/fnv"
+	"log"
+	"os"
+	"slices"
+	"sync"
+)
+
+// Splits texts into build and held-out portions, holding out a share frac of texts
+// texts are assigned by a hash of their content, so the split does not depend on corpus order
+func SplitHeldOut(t []Text, frac float64) ([]Text, []Text) {
+	var build, held []Text
+
+	for i := range t {
+		h := fnv.New32a()
+		h.Write([]byte(t[i].text))
+		if float64(h.Sum32()%10000) < frac*10000 {
+			held = append(held, t[i])
+			continue
+		}
+		build = append(build, t[i])
+	}
+
+	return build, held
+}
+
+// One merge accepted for covering held-out utterances not covered before
+type HeldOutMerge struct {
+	Rules      []string `json:"rules"`
+	Merged     string   `json:"merged"`
+	Novel      int      `json:"novel"`
+	Utterances []string `json:"utterances"`
+}
+
+// Held-out utterances which single slot merges must cover to be accepted, with the merges each utterance justified
+type HeldOut struct {
+	mu      sync.Mutex
+	u       *Utterances
+	covered map[string]bool
+	before  int
+	merges  []HeldOutMerge
+}
+
+// Collects held-out utterances from texts, which are matched as they are, keeping class references
+func NewHeldOut(t []Text, tok Tokenizer) *HeldOut {
+	var s []string
+
+	for i := range t {
+		s = append(s, t[i].text)
+	}
+
+	return &HeldOut{u: NewUtterances(s, tok, []Rule{}), covered: make(map[string]bool)}
+}
+
+func (h *HeldOut) Len() int {
+	return h.u.Len()
+}
+
+// Marks the held-out utterances produced by rules as covered, before any merges are made
+func (h *HeldOut) Cover(rules []Rule) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for i := range rules {
+		for _, p := range h.u.Produced(rules[i]) {
+			h.covered[p] = true
+		}
+	}
+	h.before = len(h.covered)
+}
+
+// Guard rejecting merges with novel productions unless the merged rule covers held-out utterances not already covered, and fits within budget if budget is not nil
+// accepted merges mark their held-out utterances as covered, so later merges must be justified by other utterances
+func (h *HeldOut) Guard(budget *NovelBudget, l *log.Logger) MergeGuard {
+	return func(r1, r2, merged Rule) bool {
+		novel := NovelProductions(r1, r2, merged)
+		if novel == 0 {
+			return true
+		}
+
+		h.mu.Lock()
+		defer h.mu.Unlock()
+		var gained []string
+		for _, p := range h.u.Produced(merged) {
+			if !h.covered[p] {
+				gained = append(gained, p)
+			}
+		}
+		if len(gained) == 0 {
+			l.Printf("merge guard %s rejected merging %v and %v, %v novel productions cover no new held-out utterances\n", "HeldOut", r1, r2, novel)
+			return false
+		}
+		if budget != nil {
+			budget.mu.Lock()
+			defer budget.mu.Unlock()
+			if !budget.fits(r1, r2, merged, novel, l) {
+				return false
+			}
+			budget.novel += novel
+		}
+		for i := range gained {
+			h.covered[gained[i]] = true
+		}
+		h.merges = append(h.merges, HeldOutMerge{
+			Rules:      []string{printRule(r1), printRule(r2)},
+			Merged:     printRule(merged),
+			Novel:      novel,
+			Utterances: gained,
+		})
+		l.Printf("merge guard %s accepted merging %v and %v, justified by held-out utterances %v\n", "HeldOut", r1, r2, gained)
+
+		return true
+	}
+}
+
+// Helper function to print a rule without sorting its expression groups in place
+func printRule(r Rule) string {
+	c := r.clone()
+	return c.print(c.name())
+}
+
+// Summary of held-out coverage and the merges justified by held-out utterances
+type HeldOutReport struct {
+	HeldOut int            `json:"heldOut"`
+	Before  int            `json:"coveredBefore"`
+	After   int            `json:"coveredAfter"`
+	Missed  []string       `json:"missed"`
+	Merges  []HeldOutMerge `json:"merges"`
+}
+
+// Checks the held-out utterances covered by the finished grammar
+// only non public rules are referenced, and class rules are not expanded, as held-out utterances keep their class references
+func (h *HeldOut) Report(rules []Rule) HeldOutReport {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	var (
+		covered = make(map[string]bool)
+		report  = HeldOutReport{HeldOut: h.u.Len(), Before: h.before, Missed: []string{}, Merges: h.merges}
+	)
+	h.u.mu.Lock()
+	h.u.register(slices.DeleteFunc(slices.Clone(rules), func(r Rule) bool { return r.isPublic || r.label != "" })...)
+	h.u.mu.Unlock()
+	for i := range rules {
+		if !rules[i].isPublic {
+			continue
+		}
+		for _, p := range h.u.Produced(rules[i]) {
+			covered[p] = true
+		}
+	}
+	for _, t := range h.u.texts {
+		if !covered[t] {
+			report.Missed = append(report.Missed, t)
+		}
+	}
+	report.After = len(covered)
+	if report.Merges == nil {
+		report.Merges = []HeldOutMerge{}
+	}
+
+	return report
+}
+
+// Logs a summary of the held-out report for the finished grammar, and writes the full report to a json file if p is not empty
+func (h *HeldOut) WriteReport(p string, rules []Rule, l *log.Logger) error {
+	report := h.Report(rules)
+	l.Printf("REPORT: held-out utterances covered %v of %v before merging, %v of %v after, %v merges justified\n", report.Before, report.HeldOut, report.After, report.HeldOut, len(report.Merges))
+	if p == "" {
+		return nil
+	}
+
+	b, err := json.MarshalIndent(report, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	return os.WriteFile(p, b, 0644)
+}
@@ -0,0 +1,85 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:47:12 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"path/filepath"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSplitHeldOut(t *testing.T) {
+	var texts []Text
+	for _, s := range alternatives("can i pay bill ", 1000) {
+		texts = append(texts, Text{text: s})
+	}
+
+	build, held := SplitHeldOut(texts, 0.2)
+	assert.Len(t, slices.Concat(build, held), 1000)
+	assert.InDelta(t, 200, len(held), 40)
+	// the split does not depend on corpus order
+	slices.Reverse(texts)
+	_, reversed := SplitHeldOut(texts, 0.2)
+	slices.Reverse(reversed)
+	assert.Equal(t, held, reversed)
+
+	build, held = SplitHeldOut(texts, 0)
+	assert.Len(t, build, 1000)
+	assert.Empty(t, held)
+}
+
+func TestHeldOut_Guard(t *testing.T) {
+	var (
+		h  = NewHeldOut([]Text{{text: "can i pay now"}, {text: "i want to cancel my order"}}, NewWordTokenizer())
+		r1 = Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill"}, isPublic: true}
+		r2 = Rule{pre: []string{"i want to"}, root: []string{"pay"}, suf: []string{"now"}, isPublic: true}
+		r3 = Rule{pre: []string{"can i"}, root: []string{"cancel"}, suf: []string{"my order"}, isPublic: true}
+		r4 = Rule{pre: []string{"i want to"}, root: []string{"cancel"}, suf: []string{"my bill"}, isPublic: true}
+	)
+	h.Cover([]Rule{r1, r2, r3, r4})
+	g := h.Guard(nil, nilLogger)
+
+	// merges without novel productions need no held-out utterances
+	assert.True(t, g(r1, r1, r1))
+	assert.True(t, g(r1, r2, Rule{pre: []string{"can i", "i want to"}, root: []string{"pay"}, suf: []string{"my bill", "now"}, isPublic: true}))
+	// the held-out utterance is already covered by the previous merge
+	assert.False(t, g(r1, r2, Rule{pre: []string{"can i", "i want to"}, root: []string{"pay"}, suf: []string{"my bill", "now"}, isPublic: true}))
+	assert.False(t, g(r3, r4, Rule{pre: []string{"can i", "i want to"}, root: []string{"cancel"}, suf: []string{"my bill"}, isPublic: true}))
+	assert.Len(t, h.merges, 1)
+	assert.Equal(t, []string{"can i pay now"}, h.merges[0].Utterances)
+	assert.Equal(t, 2, h.merges[0].Novel)
+
+	// merges covering held-out utterances are still subject to the budget
+	h = NewHeldOut([]Text{{text: "i want to cancel my order"}}, NewWordTokenizer())
+	assert.False(t, h.Guard(NewNovelBudget(1, 0, 4), nilLogger)(r3, r4, Rule{pre: []string{"can i", "i want to"}, root: []string{"cancel"}, suf: []string{"my bill", "my order"}, isPublic: true}))
+	assert.True(t, h.Guard(NewNovelBudget(2, 0, 4), nilLogger)(r3, r4, Rule{pre: []string{"can i", "i want to"}, root: []string{"cancel"}, suf: []string{"my bill", "my order"}, isPublic: true}))
+}
+
+func TestHeldOut_Report(t *testing.T) {
+	var (
+		h     = NewHeldOut([]Text{{text: "can i pay now ?"}, {text: "pay <NUM> dollars"}, {text: "i want to cancel my order"}}, NewWordTokenizer())
+		rules = []Rule{
+			{pre: []string{"can i"}, root: []string{"<pay|view_3>"}, suf: []string{"now?"}, isPublic: true},
+			{pre: []string{}, root: []string{"pay|view"}, suf: []string{}, id: 3},
+			{pre: []string{"pay"}, root: []string{"<NUM>"}, suf: []string{"dollars"}, isPublic: true},
+			{pre: []string{}, root: []string{"5"}, suf: []string{}, label: "NUM"},
+		}
+		p = filepath.Join(t.TempDir(), "report.json")
+	)
+	h.Cover(rules[2:3])
+
+	want := HeldOutReport{HeldOut: 3, Before: 1, After: 2, Missed: []string{"i want to cancel my order"}, Merges: []HeldOutMerge{}}
+	assert.Equal(t, want, h.Report(rules))
+	assert.Nil(t, h.WriteReport(p, rules, nilLogger))
+	b, _ := os.ReadFile(p)
+	var got HeldOutReport
+	assert.Nil(t, json.Unmarshal(b, &got))
+	assert.Equal(t, want, got)
+}
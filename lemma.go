@@ -0,0 +1,281 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:00:26 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"os"
+	"slices"
+	"strings"
+)
+
+type Lemmatizer interface {
+	// Reduces a lowercase word to its lemma or stem
+	lemmatize(s string) string
+}
+
+// Stemmers by language code
+var Stemmers = map[string]Lemmatizer{
+	"en": PorterStemmer{},
+}
+
+// English stemmer implementing the Porter (1980) suffix stripping algorithm
+type PorterStemmer struct{}
+
+// A suffix replacement, applied if the remaining stem satisfies cond
+type porterRule struct {
+	suffix  string
+	replace string
+	cond    func(stem []byte) bool
+}
+
+// Helper function to check if the letter at i is a consonant, where y is a consonant at the start of a word or after a vowel
+func porterConsonant(b []byte, i int) bool {
+	switch b[i] {
+	case 'a', 'e', 'i', 'o', 'u':
+		return false
+	case 'y':
+		return i == 0 || !porterConsonant(b, i-1)
+	default:
+		return true
+	}
+}
+
+// Counts the vowel-consonant sequences in a stem
+func porterMeasure(b []byte) int {
+	var (
+		m      int
+		vowels bool
+	)
+
+	for i := range b {
+		if !porterConsonant(b, i) {
+			vowels = true
+			continue
+		}
+		if vowels {
+			m++
+			vowels = false
+		}
+	}
+
+	return m
+}
+
+// Checks if a stem contains a vowel
+func porterVowel(b []byte) bool {
+	for i := range b {
+		if !porterConsonant(b, i) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Checks if a stem ends with a double consonant
+func porterDouble(b []byte) bool {
+	n := len(b)
+	return n > 1 && b[n-1] == b[n-2] && porterConsonant(b, n-1)
+}
+
+// Checks if a stem ends consonant-vowel-consonant, where the last consonant is not w, x, or y
+func porterCVC(b []byte) bool {
+	n := len(b)
+	if n < 3 || !porterConsonant(b, n-1) || porterConsonant(b, n-2) || !porterConsonant(b, n-3) {
+		return false
+	}
+
+	return !strings.ContainsRune("wxy", rune(b[n-1]))
+}
+
+var (
+	porterM0 = func(b []byte) bool { return porterMeasure(b) > 0 }
+	porterM1 = func(b []byte) bool { return porterMeasure(b) > 1 }
+	// ion is only removed after s or t
+	porterIon = func(b []byte) bool {
+		return porterMeasure(b) > 1 && len(b) > 0 && (b[len(b)-1] == 's' || b[len(b)-1] == 't')
+	}
+
+	porterStep2 = []porterRule{
+		{"ational", "ate", porterM0}, {"tional", "tion", porterM0}, {"enci", "ence", porterM0}, {"anci", "ance", porterM0},
+		{"izer", "ize", porterM0}, {"bli", "ble", porterM0}, {"alli", "al", porterM0}, {"entli", "ent", porterM0},
+		{"eli", "e", porterM0}, {"ousli", "ous", porterM0}, {"ization", "ize", porterM0}, {"ation", "ate", porterM0},
+		{"ator", "ate", porterM0}, {"alism", "al", porterM0}, {"iveness", "ive", porterM0}, {"fulness", "ful", porterM0},
+		{"ousness", "ous", porterM0}, {"aliti", "al", porterM0}, {"iviti", "ive", porterM0}, {"biliti", "ble", porterM0},
+		{"logi", "log", porterM0},
+	}
+	porterStep3 = []porterRule{
+		{"icate", "ic", porterM0}, {"ative", "", porterM0}, {"alize", "al", porterM0}, {"iciti", "ic", porterM0},
+		{"ical", "ic", porterM0}, {"ful", "", porterM0}, {"ness", "", porterM0},
+	}
+	porterStep4 = []porterRule{
+		{"al", "", porterM1}, {"ance", "", porterM1}, {"ence", "", porterM1}, {"er", "", porterM1}, {"ic", "", porterM1},
+		{"able", "", porterM1}, {"ible", "", porterM1}, {"ant", "", porterM1}, {"ement", "", porterM1}, {"ment", "", porterM1},
+		{"ent", "", porterM1}, {"ion", "", porterIon}, {"ou", "", porterM1}, {"ism", "", porterM1},
+		{"ate", "", porterM1}, {"iti", "", porterM1}, {"ous", "", porterM1}, {"ive", "", porterM1}, {"ize", "", porterM1},
+	}
+)
+
+// Applies the rule with the longest suffix matching b, if its condition holds
+func porterApply(b []byte, rules []porterRule) []byte {
+	var match *porterRule
+
+	for i := range rules {
+		if strings.HasSuffix(string(b), rules[i].suffix) && (match == nil || len(rules[i].suffix) > len(match.suffix)) {
+			match = &rules[i]
+		}
+	}
+	if match == nil {
+		return b
+	}
+	stem := b[:len(b)-len(match.suffix)]
+	if !match.cond(stem) {
+		return b
+	}
+
+	return append(stem, match.replace...)
+}
+
+func (PorterStemmer) lemmatize(s string) string {
+	if len(s) <= 2 {
+		return s
+	}
+	b := []byte(s)
+
+	// step 1a, plurals
+	switch {
+	case strings.HasSuffix(s, "sses"), strings.HasSuffix(s, "ies"):
+		b = b[:len(b)-2]
+	case strings.HasSuffix(s, "ss"):
+	case strings.HasSuffix(s, "s"):
+		b = b[:len(b)-1]
+	}
+
+	// step 1b, past tense and gerunds
+	cleanup := false
+	switch {
+	case strings.HasSuffix(string(b), "eed"):
+		if porterMeasure(b[:len(b)-3]) > 0 {
+			b = b[:len(b)-1]
+		}
+	case strings.HasSuffix(string(b), "ed") && porterVowel(b[:len(b)-2]):
+		b, cleanup = b[:len(b)-2], true
+	case strings.HasSuffix(string(b), "ing") && porterVowel(b[:len(b)-3]):
+		b, cleanup = b[:len(b)-3], true
+	}
+	if cleanup {
+		switch {
+		case strings.HasSuffix(string(b), "at"), strings.HasSuffix(string(b), "bl"), strings.HasSuffix(string(b), "iz"):
+			b = append(b, 'e')
+		case porterDouble(b) && !strings.ContainsRune("lsz", rune(b[len(b)-1])):
+			b = b[:len(b)-1]
+		case porterMeasure(b) == 1 && porterCVC(b):
+			b = append(b, 'e')
+		}
+	}
+
+	// step 1c
+	if b[len(b)-1] == 'y' && porterVowel(b[:len(b)-1]) {
+		b[len(b)-1] = 'i'
+	}
+
+	// steps 2-4, derivational suffixes
+	b = porterApply(b, porterStep2)
+	b = porterApply(b, porterStep3)
+	b = porterApply(b, porterStep4)
+
+	// step 5, final e and ll
+	if b[len(b)-1] == 'e' {
+		m := porterMeasure(b[:len(b)-1])
+		if m > 1 || (m == 1 && !porterCVC(b[:len(b)-1])) {
+			b = b[:len(b)-1]
+		}
+	}
+	if porterMeasure(b) > 1 && porterDouble(b) && b[len(b)-1] == 'l' {
+		b = b[:len(b)-1]
+	}
+
+	return string(b)
+}
+
+// Lemmatizer looking up words in a dictionary of word forms, passing the result through a fallback lemmatizer if provided
+// so listed forms (mice -> mouse) and unlisted forms (mouse) reduce to the same stem
+type DictionaryLemmatizer struct {
+	lemmas   map[string]string
+	fallback Lemmatizer
+}
+
+func NewDictionaryLemmatizer(lemmas map[string]string, fallback Lemmatizer) DictionaryLemmatizer {
+	return DictionaryLemmatizer{lemmas, fallback}
+}
+
+func (d DictionaryLemmatizer) lemmatize(s string) string {
+	if lemma, ok := d.lemmas[s]; ok {
+		s = lemma
+	}
+	if d.fallback != nil {
+		return d.fallback.lemmatize(s)
+	}
+
+	return s
+}
+
+// Reads a json file mapping word forms to their lemmas
+func ReadLemmas(p string) (map[string]string, error) {
+	var err error
+	lemmas := make(map[string]string)
+
+	file, err := os.Open(p)
+	if err != nil {
+		return lemmas, err
+	}
+	defer file.Close()
+	dec := json.NewDecoder(file)
+	err = dec.Decode(&lemmas)
+
+	return lemmas, err
+}
+
+// Tokenizer which replaces each lowercased token with its lemma, used to build vocabularies and embeddings over lemmas
+type lemmaTokenizer struct {
+	Tokenizer
+	lem Lemmatizer
+}
+
+func NewLemmaTokenizer(tok Tokenizer, lem Lemmatizer) lemmaTokenizer {
+	return lemmaTokenizer{tok, lem}
+}
+
+func (tok lemmaTokenizer) tokenize(s string) []string {
+	tokens := tok.Tokenizer.tokenize(s)
+
+	for i := range tokens {
+		tokens[i] = tok.lem.lemmatize(strings.ToLower(tokens[i]))
+	}
+
+	return tokens
+}
+
+func (tok lemmaTokenizer) normalize(s string) string {
+	return strings.Join(tok.tokenize(s), " ")
+}
+
+// Compares expressions for matching sequences of lemmas
+func LemmaEqual(tok Tokenizer, lem Lemmatizer, l *log.Logger) EqualityFunction {
+	lemmas := NewLemmaTokenizer(tok, lem)
+
+	return func(e1, e2 []string) bool {
+		s1 := lemmas.tokenize(strings.Join(e1, " "))
+		s2 := lemmas.tokenize(strings.Join(e2, " "))
+		if slices.Equal(s1, s2) {
+			l.Printf("equality function %s matched %v and %v, lemmas %v\n", "LemmaEqual", e1, e2, s1)
+			return true
+		}
+		return false
+	}
+}
@@ -0,0 +1,151 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:00:26 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestPorterStemmer_lemmatize(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		args args
+		want string
+	}{
+		{args: args{s: ""}, want: ""},
+		{args: args{s: "is"}, want: "is"},
+		{args: args{s: "caresses"}, want: "caress"},
+		{args: args{s: "ponies"}, want: "poni"},
+		{args: args{s: "cats"}, want: "cat"},
+		{args: args{s: "agreed"}, want: "agre"},
+		{args: args{s: "motoring"}, want: "motor"},
+		{args: args{s: "sing"}, want: "sing"},
+		{args: args{s: "conflated"}, want: "conflat"},
+		{args: args{s: "hopping"}, want: "hop"},
+		{args: args{s: "falling"}, want: "fall"},
+		{args: args{s: "filing"}, want: "file"},
+		{args: args{s: "happy"}, want: "happi"},
+		{args: args{s: "relational"}, want: "relat"},
+		{args: args{s: "conditional"}, want: "condit"},
+		{args: args{s: "digitizer"}, want: "digit"},
+		{args: args{s: "hopefulness"}, want: "hope"},
+		{args: args{s: "sensibiliti"}, want: "sensibl"},
+		{args: args{s: "electrical"}, want: "electr"},
+		{args: args{s: "adoption"}, want: "adopt"},
+		{args: args{s: "replacement"}, want: "replac"},
+		{args: args{s: "controll"}, want: "control"},
+		{args: args{s: "generalizations"}, want: "gener"},
+		{args: args{s: "oscillators"}, want: "oscil"},
+		{args: args{s: "sending"}, want: "send"},
+		{args: args{s: "invoices"}, want: "invoic"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, PorterStemmer{}.lemmatize(tt.args.s))
+		})
+	}
+}
+
+func TestDictionaryLemmatizer_lemmatize(t *testing.T) {
+	type args struct {
+		s        string
+		fallback Lemmatizer
+	}
+	tests := []struct {
+		args args
+		want string
+	}{
+		{args: args{s: "went", fallback: nil}, want: "go"},
+		{args: args{s: "sending", fallback: nil}, want: "sending"},
+		{args: args{s: "went", fallback: PorterStemmer{}}, want: "go"},
+		{args: args{s: "mice", fallback: nil}, want: "mouse"},
+		{args: args{s: "mice", fallback: PorterStemmer{}}, want: "mous"},
+		{args: args{s: "mouse", fallback: PorterStemmer{}}, want: "mous"},
+		{args: args{s: "sending", fallback: PorterStemmer{}}, want: "send"},
+	}
+	lemmas, err := ReadLemmas("./data/tests/lemmas1.json")
+	assert.NoError(t, err)
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, NewDictionaryLemmatizer(lemmas, tt.args.fallback).lemmatize(tt.args.s))
+		})
+	}
+}
+
+func TestReadLemmas(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		args      args
+		want      map[string]string
+		assertion assert.ErrorAssertionFunc
+	}{
+		{args: args{p: ""}, want: map[string]string{}, assertion: assert.Error},
+		{args: args{p: "./data/tests/lemmas1.json"}, want: map[string]string{"went": "go", "gone": "go", "mice": "mouse"}, assertion: assert.NoError},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := ReadLemmas(tt.args.p)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func Test_lemmaTokenizer_tokenize(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		args args
+		want []string
+	}{
+		{args: args{s: ""}, want: []string{}},
+		{args: args{s: "Sending me the invoices."}, want: []string{"send", "me", "the", "invoic", "."}},
+		{args: args{s: "mice went"}, want: []string{"mous", "go"}},
+	}
+	lemmas, err := ReadLemmas("./data/tests/lemmas1.json")
+	assert.NoError(t, err)
+	tok := NewLemmaTokenizer(NewWordTokenizer(), NewDictionaryLemmatizer(lemmas, PorterStemmer{}))
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, tok.tokenize(tt.args.s))
+		})
+	}
+}
+
+func TestLemmaEqual(t *testing.T) {
+	type args struct {
+		e1 []string
+		e2 []string
+	}
+	tests := []struct {
+		args args
+		want bool
+	}{
+		{args: args{e1: []string{"send me the invoice"}, e2: []string{"sending me the invoices"}}, want: true},
+		{args: args{e1: []string{"send", "me"}, e2: []string{"Sends me"}}, want: true},
+		{args: args{e1: []string{"send me the invoice"}, e2: []string{"send me an invoice"}}, want: false},
+		{args: args{e1: []string{"send"}, e2: []string{"send me"}}, want: false},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, LemmaEqual(NewWordTokenizer(), PorterStemmer{}, nilLogger)(tt.args.e1, tt.args.e2))
+		})
+	}
+}
+
+func TestTFIDFModel_lemmas(t *testing.T) {
+	tok := NewLemmaTokenizer(NewWordTokenizer(), PorterStemmer{})
+	m := NewTFIDFModel([]Text{{text: "send the invoice"}, {text: "sending invoices"}}, tok, "tf", 1)
+	assert.Equal(t, map[string]int{"invoic": 0, "send": 1, "the": 2}, m.index)
+	assert.Equal(t, []float64{2, 2, 1}, m.df)
+}
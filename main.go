@@ -32,8 +32,13 @@ func main() {
 					&inFile,
 					&outFile,
 					&printMain,
+					&provenance,
+					&provenanceFile,
 					&preTokenized,
 					&chunk,
+					&tagMap,
+					&cacheSize,
+					&workers,
 				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					var (
@@ -50,10 +55,18 @@ func main() {
 						return err
 					}
 
-					rules = applyChunking(texts, cmd)
+					rules, err = applyChunking(texts, cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
 					rules = SetIDs(rules)
 					g = Grammar{Rules: rules}
-					g.write(cmd)
+					err = g.write(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
 
 					return nil
 				},
@@ -69,8 +82,18 @@ func main() {
 					&inFile,
 					&outFile,
 					&printMain,
+					&provenance,
+					&provenanceFile,
 					&preTokenized,
 					&chunk,
+					&spoken,
+					&classes,
+					&classFile,
+					&entities,
+					&tagMap,
+					&constraints,
+					&cacheSize,
+					&workers,
 					&prob,
 					&factorN,
 					&logging,
@@ -83,6 +106,7 @@ func main() {
 						g      Grammar
 						err    error
 						logger *log.Logger
+						con    *Constraints
 					)
 
 					logger, err = setLogger(cmd)
@@ -96,15 +120,30 @@ func main() {
 						return err
 					}
 
-					rules = applyChunking(texts, cmd)
-					rules = MergePR(rules, LiteralEqual(logger), logger)
-					rules = MergePS(rules, LiteralEqual(logger), logger)
-					rules = MergeRS(rules, LiteralEqual(logger), logger)
-					rules = MergeMisc(rules, LiteralEqual(logger), logger)
+					rules, err = applyChunking(texts, cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					con, err = setConstraints(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					rules = HashMerge("PR")(rules, LiteralEqual(logger), logger, con.Guard(logger))
+					rules = HashMerge("PS")(rules, LiteralEqual(logger), logger, con.Guard(logger))
+					rules = HashMerge("RS")(rules, LiteralEqual(logger), logger, con.Guard(logger))
+					rules = MergeMisc(rules, LiteralEqual(logger), logger, con.Guard(logger))
 					rules = SetIDs(rules)
-					rules = ExpressionFactor(cmd.Int("factor"), logger)(rules)
-					g = Grammar{Rules: rules}
-					g.write(cmd)
+					rules = ExpressionFactor(cmd.Int("factor"), logger)(rules, con.Guard(logger))
+					rules = append(rules, ClassRules(texts)...)
+					logCaches(logger)
+					g = Grammar{Rules: rules, Tags: CollectWritten(texts)}
+					err = g.write(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
 
 					return nil
 				},
@@ -120,12 +159,46 @@ func main() {
 					&inFile,
 					&outFile,
 					&printMain,
+					&provenance,
+					&provenanceFile,
 					&preTokenized,
 					&chunk,
+					&spoken,
+					&classes,
+					&classFile,
+					&entities,
+					&tagMap,
+					&cacheSize,
+					&workers,
 					&prob,
 					&factorN,
 					&merge,
 					&similarity,
+					&cluster,
+					&block,
+					&maxNovel,
+					&maxNovelGrammar,
+					&constraints,
+					&negatives,
+					&heldOut,
+					&heldOutReport,
+					&plan,
+					&ngram,
+					&ngramMin,
+					&ngramMax,
+					&vectors,
+					&sif,
+					&endpoint,
+					&embedModel,
+					&embedBatch,
+					&embedCache,
+					&wordnet,
+					&wnSenses,
+					&cmudict,
+					&costs,
+					&lemma,
+					&weighting,
+					&lemmaVocab,
 					&conFactor,
 					&filterQuantile,
 					&logging,
@@ -133,47 +206,116 @@ func main() {
 				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					var (
-						texts   []Text
-						rules   []Rule
-						g       Grammar
-						err     error
-						logger  *log.Logger
-						eqfunc  EqualityFunction
-						facfunc FactorFunction
+						texts      []Text
+						rules      []Rule
+						g          Grammar
+						err        error
+						logger     *log.Logger
+						eqfunc     EqualityFunction
+						mergefuncs map[string]MergeFunction
+						facfunc    FactorFunction
+						con        *Constraints
+						neg        *Negatives
+						held       *HeldOut
+						guards     map[string][]MergeGuard
+						mergePlan  *MergePlan
 					)
 					logger, err = setLogger(cmd)
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-					eqfunc, err = setMerge(cmd)
+					facfunc, err = setFactor(cmd)
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-					facfunc, err = setFactor(cmd)
+					texts, err = readInfile(cmd)
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-					texts, err = readInfile(cmd)
+					texts, held = setHeldOut(cmd, texts)
+
+					rules, err = applyChunking(texts, cmd)
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-
-					rules = applyChunking(texts, cmd)
-					rules = MergePR(rules, eqfunc, logger)
-					rules = MergePS(rules, eqfunc, logger)
-					rules = MergeRS(rules, eqfunc, logger)
-					rules = MergeP(rules, eqfunc, logger)
-					rules = MergeR(rules, eqfunc, logger)
-					rules = MergeS(rules, eqfunc, logger)
-					rules = MergeMisc(rules, eqfunc, logger)
+					eqfunc, err = setMerge(cmd, texts, rules)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					neg, err = setNegatives(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					con, err = setConstraints(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					mergePlan, guards, err = setPlan(cmd, setMergeGuards(cmd, rules, con, neg, held, logger), logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					mergefuncs, err = setMergeFunctions(cmd, guards)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					rules = mergefuncs["PR"](rules, eqfunc, logger)
+					rules = mergefuncs["PS"](rules, eqfunc, logger)
+					rules = mergefuncs["RS"](rules, eqfunc, logger)
+					rules = mergefuncs["P"](rules, eqfunc, logger)
+					rules = mergefuncs["R"](rules, eqfunc, logger)
+					rules = mergefuncs["S"](rules, eqfunc, logger)
+					if mergePlan != nil {
+						logCaches(logger)
+						// a plan built while the endpoint was failing would be missing merges
+						err = checkEndpoint()
+						if err != nil {
+							logger.Printf("Error: %v", err)
+							return err
+						}
+						err = mergePlan.Write(cmd.String("plan"), logger)
+						if err != nil {
+							logger.Printf("Error: %v", err)
+							return err
+						}
+						return nil
+					}
+					rules = MergeMisc(rules, eqfunc, logger, con.Guard(logger))
 					rules = SetIDs(rules)
-					rules = facfunc(rules)
-					g = Grammar{Rules: rules}
-					g.write(cmd)
+					rules = facfunc(rules, con.Guard(logger), neg.Guard(logger))
+					rules = append(rules, ClassRules(texts)...)
+					logCaches(logger)
+					err = neg.Check(rules, logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					if held.Len() != 0 {
+						err = held.WriteReport(cmd.String("heldOutReport"), rules, logger)
+						if err != nil {
+							logger.Printf("Error: %v", err)
+							return err
+						}
+					}
+					err = checkEndpoint()
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					g = Grammar{Rules: rules, Tags: CollectWritten(texts)}
+					err = g.write(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
 
 					return nil
 				},
@@ -189,28 +331,70 @@ func main() {
 					&inFile,
 					&outFile,
 					&printMain,
+					&provenance,
+					&provenanceFile,
 					&preTokenized,
 					&chunk,
+					&spoken,
+					&classes,
+					&classFile,
+					&entities,
+					&tagMap,
+					&cacheSize,
+					&workers,
 					&prob,
 					&factorN,
 					&merge,
 					&similarity,
+					&cluster,
+					&block,
+					&maxNovel,
+					&maxNovelGrammar,
+					&constraints,
+					&negatives,
+					&heldOut,
+					&heldOutReport,
+					&plan,
+					&ngram,
+					&ngramMin,
+					&ngramMax,
+					&vectors,
+					&sif,
+					&endpoint,
+					&embedModel,
+					&embedBatch,
+					&embedCache,
+					&wordnet,
+					&wnSenses,
+					&cmudict,
+					&costs,
+					&lemma,
+					&weighting,
+					&lemmaVocab,
 					&conFactor,
 					&filterQuantile,
 					&synFile,
+					&wnSyn,
+					&wnDepth,
 					&logging,
 					&logFile,
 				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					var (
-						texts   []Text
-						rules   []Rule
-						g       Grammar
-						err     error
-						logger  *log.Logger
-						eqfunc  EqualityFunction
-						facfunc FactorFunction
-						synfunc FactorFunction
+						texts      []Text
+						rules      []Rule
+						g          Grammar
+						err        error
+						logger     *log.Logger
+						eqfunc     EqualityFunction
+						mergefuncs map[string]MergeFunction
+						facfunc    FactorFunction
+						con        *Constraints
+						neg        *Negatives
+						held       *HeldOut
+						guards     map[string][]MergeGuard
+						mergePlan  *MergePlan
+						synfunc    FactorFunction
 					)
 
 					logger, err = setLogger(cmd)
@@ -218,7 +402,179 @@ func main() {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-					eqfunc, err = setMerge(cmd)
+					facfunc, err = setFactor(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					texts, err = readInfile(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					synfunc, err = setSynonyms(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					texts, held = setHeldOut(cmd, texts)
+
+					rules, err = applyChunking(texts, cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					eqfunc, err = setMerge(cmd, texts, rules)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					neg, err = setNegatives(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					con, err = setConstraints(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					mergePlan, guards, err = setPlan(cmd, setMergeGuards(cmd, rules, con, neg, held, logger), logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					mergefuncs, err = setMergeFunctions(cmd, guards)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					rules = mergefuncs["PR"](rules, eqfunc, logger)
+					rules = mergefuncs["PS"](rules, eqfunc, logger)
+					rules = mergefuncs["RS"](rules, eqfunc, logger)
+					rules = mergefuncs["P"](rules, eqfunc, logger)
+					rules = mergefuncs["R"](rules, eqfunc, logger)
+					rules = mergefuncs["S"](rules, eqfunc, logger)
+					if mergePlan != nil {
+						logCaches(logger)
+						// a plan built while the endpoint was failing would be missing merges
+						err = checkEndpoint()
+						if err != nil {
+							logger.Printf("Error: %v", err)
+							return err
+						}
+						err = mergePlan.Write(cmd.String("plan"), logger)
+						if err != nil {
+							logger.Printf("Error: %v", err)
+							return err
+						}
+						return nil
+					}
+					rules = MergeMisc(rules, eqfunc, logger, con.Guard(logger))
+					rules = SetIDs(rules)
+					rules = facfunc(rules, con.Guard(logger), neg.Guard(logger))
+					rules = synfunc(rules, con.Guard(logger), neg.Guard(logger))
+					rules = append(rules, ClassRules(texts)...)
+					logCaches(logger)
+					err = neg.Check(rules, logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					if held.Len() != 0 {
+						err = held.WriteReport(cmd.String("heldOutReport"), rules, logger)
+						if err != nil {
+							logger.Printf("Error: %v", err)
+							return err
+						}
+					}
+					err = checkEndpoint()
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					g = Grammar{Rules: rules, Tags: CollectWritten(texts)}
+					err = g.write(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:                  "explain-novel",
+				Usage:                 "Build a grammar as extrapolate does, or as interpolate does without synonym options, and report each production not found in the source corpus along with the merge or factoring step which introduced it.",
+				UsageText:             "c2g explain-novel [OPTIONS] example.txt",
+				EnableShellCompletion: true,
+				Suggest:               true,
+				Before:                prepareContext,
+				Flags: []cli.Flag{
+					&inFile,
+					&novelReport,
+					&preTokenized,
+					&chunk,
+					&spoken,
+					&classes,
+					&classFile,
+					&entities,
+					&tagMap,
+					&cacheSize,
+					&workers,
+					&prob,
+					&factorN,
+					&merge,
+					&similarity,
+					&cluster,
+					&block,
+					&maxNovel,
+					&maxNovelGrammar,
+					&constraints,
+					&negatives,
+					&heldOut,
+					&ngram,
+					&ngramMin,
+					&ngramMax,
+					&vectors,
+					&sif,
+					&endpoint,
+					&embedModel,
+					&embedBatch,
+					&embedCache,
+					&wordnet,
+					&wnSenses,
+					&cmudict,
+					&costs,
+					&lemma,
+					&weighting,
+					&lemmaVocab,
+					&conFactor,
+					&filterQuantile,
+					&synFile,
+					&wnSyn,
+					&wnDepth,
+					&logging,
+					&logFile,
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					var (
+						texts      []Text
+						rules      []Rule
+						err        error
+						logger     *log.Logger
+						eqfunc     EqualityFunction
+						mergefuncs map[string]MergeFunction
+						guards     map[string][]MergeGuard
+						facfunc    FactorFunction
+						con        *Constraints
+						neg        *Negatives
+						held       *HeldOut
+						synfunc    FactorFunction
+						explainer  *NovelExplainer
+					)
+
+					logger, err = setLogger(cmd)
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
@@ -228,7 +584,162 @@ func main() {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-					synfunc, err = setSynonyms(cmd)
+					texts, err = readInfile(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					synfunc, err = setSynonyms(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					explainer = NewNovelExplainer(texts, setTokenizer(cmd))
+					similarities = NewSimilarities()
+					texts, held = setHeldOut(cmd, texts)
+
+					rules, err = applyChunking(texts, cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					eqfunc, err = setMerge(cmd, texts, rules)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					neg, err = setNegatives(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					con, err = setConstraints(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					guards, err = setExplainGuards(cmd, setMergeGuards(cmd, rules, con, neg, held, logger), explainer, logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					mergefuncs, err = setMergeFunctions(cmd, guards)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					rules = mergefuncs["PR"](rules, eqfunc, logger)
+					rules = mergefuncs["PS"](rules, eqfunc, logger)
+					rules = mergefuncs["RS"](rules, eqfunc, logger)
+					rules = mergefuncs["P"](rules, eqfunc, logger)
+					rules = mergefuncs["R"](rules, eqfunc, logger)
+					rules = mergefuncs["S"](rules, eqfunc, logger)
+					rules = MergeMisc(rules, eqfunc, logger, con.Guard(logger))
+					rules = SetIDs(rules)
+					facname := "ExpressionFactor"
+					if cmd.Bool("conFactor") {
+						facname = "ConstituencyFactor"
+					}
+					rules = facfunc(rules, con.Guard(logger), neg.Guard(logger), explainer.Guard(facname, "", "", logger))
+					synfunc(rules, con.Guard(logger), neg.Guard(logger), explainer.Guard("SynonymFactor", "", "", logger))
+					logCaches(logger)
+					err = checkEndpoint()
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					err = explainer.WriteReport(cmd.String("novelReport"), logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:                  "apply-plan",
+				Usage:                 "Create a grammar from a plan written by interpolate or extrapolate, making only the merges accepted in the plan. The corpus and options of the plan are used unless given again. This mode may produce outputs not found in the source corpus.",
+				UsageText:             "c2g apply-plan [OPTIONS] plan.json [example.txt]",
+				EnableShellCompletion: true,
+				Suggest:               true,
+				Before:                preparePlan,
+				Flags: []cli.Flag{
+					&plan,
+					&inFile,
+					&outFile,
+					&printMain,
+					&provenance,
+					&provenanceFile,
+					&preTokenized,
+					&chunk,
+					&spoken,
+					&classes,
+					&classFile,
+					&entities,
+					&tagMap,
+					&cacheSize,
+					&workers,
+					&prob,
+					&factorN,
+					&merge,
+					&similarity,
+					&cluster,
+					&block,
+					&maxNovel,
+					&maxNovelGrammar,
+					&constraints,
+					&negatives,
+					&heldOut,
+					&heldOutReport,
+					&ngram,
+					&ngramMin,
+					&ngramMax,
+					&vectors,
+					&sif,
+					&endpoint,
+					&embedModel,
+					&embedBatch,
+					&embedCache,
+					&wordnet,
+					&wnSenses,
+					&cmudict,
+					&costs,
+					&lemma,
+					&weighting,
+					&lemmaVocab,
+					&conFactor,
+					&filterQuantile,
+					&synFile,
+					&wnSyn,
+					&wnDepth,
+					&logging,
+					&logFile,
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					var (
+						texts      []Text
+						rules      []Rule
+						g          Grammar
+						err        error
+						logger     *log.Logger
+						eqfunc     EqualityFunction
+						mergefuncs map[string]MergeFunction
+						facfunc    FactorFunction
+						con        *Constraints
+						neg        *Negatives
+						held       *HeldOut
+						guards     map[string][]MergeGuard
+						mergePlan  *MergePlan
+						synfunc    FactorFunction
+					)
+
+					logger, err = setLogger(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					facfunc, err = setFactor(cmd)
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
@@ -238,20 +749,79 @@ func main() {
 						logger.Printf("Error: %v", err)
 						return err
 					}
+					synfunc, err = setSynonyms(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					texts, held = setHeldOut(cmd, texts)
 
-					rules = applyChunking(texts, cmd)
-					rules = MergePR(rules, eqfunc, logger)
-					rules = MergePS(rules, eqfunc, logger)
-					rules = MergeRS(rules, eqfunc, logger)
-					rules = MergeP(rules, eqfunc, logger)
-					rules = MergeR(rules, eqfunc, logger)
-					rules = MergeS(rules, eqfunc, logger)
-					rules = MergeMisc(rules, eqfunc, logger)
+					rules, err = applyChunking(texts, cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					eqfunc, err = setMerge(cmd, texts, rules)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					neg, err = setNegatives(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					con, err = setConstraints(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					mergePlan, guards, err = setPlan(cmd, setMergeGuards(cmd, rules, con, neg, held, logger), logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					mergefuncs, err = setMergeFunctions(cmd, guards)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					rules = mergefuncs["PR"](rules, eqfunc, logger)
+					rules = mergefuncs["PS"](rules, eqfunc, logger)
+					rules = mergefuncs["RS"](rules, eqfunc, logger)
+					rules = mergefuncs["P"](rules, eqfunc, logger)
+					rules = mergefuncs["R"](rules, eqfunc, logger)
+					rules = mergefuncs["S"](rules, eqfunc, logger)
+					mergePlan.Log(logger)
+					rules = MergeMisc(rules, eqfunc, logger, con.Guard(logger))
 					rules = SetIDs(rules)
-					rules = facfunc(rules)
-					rules = synfunc(rules)
-					g = Grammar{Rules: rules}
-					g.write(cmd)
+					rules = facfunc(rules, con.Guard(logger), neg.Guard(logger))
+					rules = synfunc(rules, con.Guard(logger), neg.Guard(logger))
+					rules = append(rules, ClassRules(texts)...)
+					logCaches(logger)
+					err = neg.Check(rules, logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					if held.Len() != 0 {
+						err = held.WriteReport(cmd.String("heldOutReport"), rules, logger)
+						if err != nil {
+							logger.Printf("Error: %v", err)
+							return err
+						}
+					}
+					err = checkEndpoint()
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					g = Grammar{Rules: rules, Tags: CollectWritten(texts)}
+					err = g.write(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
 
 					return nil
 				},
@@ -267,15 +837,50 @@ func main() {
 					&inFile,
 					&outFile,
 					&printMain,
+					&provenance,
+					&provenanceFile,
 					&preTokenized,
 					&chunk,
+					&spoken,
+					&classes,
+					&classFile,
+					&entities,
+					&tagMap,
+					&cacheSize,
+					&workers,
 					&prob,
 					&factorN,
 					&merge,
 					&similarity,
+					&cluster,
+					&block,
+					&maxNovel,
+					&maxNovelGrammar,
+					&constraints,
+					&negatives,
+					&heldOut,
+					&heldOutReport,
+					&ngram,
+					&ngramMin,
+					&ngramMax,
+					&vectors,
+					&sif,
+					&endpoint,
+					&embedModel,
+					&embedBatch,
+					&embedCache,
+					&wordnet,
+					&wnSenses,
+					&cmudict,
+					&costs,
+					&lemma,
+					&weighting,
+					&lemmaVocab,
 					&conFactor,
 					&filterQuantile,
 					&synFile,
+					&wnSyn,
+					&wnDepth,
 					&merge1,
 					&merge2,
 					&mergeMisc,
@@ -285,14 +890,18 @@ func main() {
 				},
 				Action: func(ctx context.Context, cmd *cli.Command) error {
 					var (
-						texts   []Text
-						rules   []Rule
-						g       Grammar
-						err     error
-						logger  *log.Logger
-						eqfunc  EqualityFunction
-						facfunc FactorFunction
-						synfunc FactorFunction
+						texts      []Text
+						rules      []Rule
+						g          Grammar
+						err        error
+						logger     *log.Logger
+						eqfunc     EqualityFunction
+						mergefuncs map[string]MergeFunction
+						facfunc    FactorFunction
+						con        *Constraints
+						neg        *Negatives
+						held       *HeldOut
+						synfunc    FactorFunction
 					)
 
 					logger, err = setLogger(cmd)
@@ -300,48 +909,176 @@ func main() {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-					eqfunc, err = setMerge(cmd)
+					facfunc, err = setFactor(cmd)
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-					facfunc, err = setFactor(cmd)
+					texts, err = readInfile(cmd)
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-					synfunc, err = setSynonyms(cmd)
+					synfunc, err = setSynonyms(cmd, texts)
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-					texts, err = readInfile(cmd)
+					texts, held = setHeldOut(cmd, texts)
+
+					rules, err = applyChunking(texts, cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					eqfunc, err = setMerge(cmd, texts, rules)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					neg, err = setNegatives(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					con, err = setConstraints(cmd, texts)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					mergefuncs, err = setMergeFunctions(cmd, setMergeGuards(cmd, rules, con, neg, held, logger))
 					if err != nil {
 						logger.Printf("Error: %v", err)
 						return err
 					}
-
-					rules = applyChunking(texts, cmd)
 					if cmd.Bool("merge2") {
-						rules = MergePR(rules, eqfunc, logger)
-						rules = MergePS(rules, eqfunc, logger)
-						rules = MergeRS(rules, eqfunc, logger)
+						rules = mergefuncs["PR"](rules, eqfunc, logger)
+						rules = mergefuncs["PS"](rules, eqfunc, logger)
+						rules = mergefuncs["RS"](rules, eqfunc, logger)
 					}
 					if cmd.Bool("merge1") {
-						rules = MergeP(rules, eqfunc, logger)
-						rules = MergeR(rules, eqfunc, logger)
-						rules = MergeS(rules, eqfunc, logger)
+						rules = mergefuncs["P"](rules, eqfunc, logger)
+						rules = mergefuncs["R"](rules, eqfunc, logger)
+						rules = mergefuncs["S"](rules, eqfunc, logger)
 					}
 					if cmd.Bool("mergemisc") {
-						rules = MergeMisc(rules, eqfunc, logger)
+						rules = MergeMisc(rules, eqfunc, logger, con.Guard(logger))
 					}
 					rules = SetIDs(rules)
 					if cmd.Bool("factor") {
-						rules = facfunc(rules)
+						rules = facfunc(rules, con.Guard(logger), neg.Guard(logger))
+					}
+					rules = synfunc(rules, con.Guard(logger), neg.Guard(logger))
+					rules = append(rules, ClassRules(texts)...)
+					logCaches(logger)
+					err = neg.Check(rules, logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					if held.Len() != 0 {
+						err = held.WriteReport(cmd.String("heldOutReport"), rules, logger)
+						if err != nil {
+							logger.Printf("Error: %v", err)
+							return err
+						}
+					}
+					err = checkEndpoint()
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					g = Grammar{Rules: rules, Tags: CollectWritten(texts)}
+					err = g.write(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+
+					return nil
+				},
+			},
+			{
+				Name:                  "run",
+				Usage:                 "Create a grammar by running the stages of a yaml or json pipeline in order, each stage with its own options. The pipeline is recorded in the grammar header. This mode may produce outputs not found in the source corpus.",
+				UsageText:             "c2g run [OPTIONS] pipeline.yaml example.txt",
+				EnableShellCompletion: true,
+				Suggest:               true,
+				Before:                preparePipeline,
+				Flags: []cli.Flag{
+					&pipeline,
+					&inFile,
+					&outFile,
+					&printMain,
+					&provenance,
+					&provenanceFile,
+					&preTokenized,
+					&chunk,
+					&spoken,
+					&classes,
+					&classFile,
+					&entities,
+					&tagMap,
+					&cacheSize,
+					&workers,
+					&prob,
+					&factorN,
+					&merge,
+					&similarity,
+					&cluster,
+					&block,
+					&maxNovel,
+					&maxNovelGrammar,
+					&constraints,
+					&negatives,
+					&heldOut,
+					&heldOutReport,
+					&ngram,
+					&ngramMin,
+					&ngramMax,
+					&vectors,
+					&sif,
+					&endpoint,
+					&embedModel,
+					&embedBatch,
+					&embedCache,
+					&wordnet,
+					&wnSenses,
+					&cmudict,
+					&costs,
+					&lemma,
+					&weighting,
+					&lemmaVocab,
+					&conFactor,
+					&filterQuantile,
+					&synFile,
+					&wnSyn,
+					&wnDepth,
+					&logging,
+					&logFile,
+				},
+				Action: func(ctx context.Context, cmd *cli.Command) error {
+					var (
+						p      Pipeline
+						err    error
+						logger *log.Logger
+					)
+
+					logger, err = setLogger(cmd)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					p, err = ReadPipeline(cmd.String("pipeline"))
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
+					}
+					err = p.Run(cmd, logger)
+					if err != nil {
+						logger.Printf("Error: %v", err)
+						return err
 					}
-					rules = synfunc(rules)
-					g = Grammar{Rules: rules}
-					g.write(cmd)
 
 					return nil
 				},
@@ -6,7 +6,6 @@
 package main
 
 import (
-	"fmt"
 	"log"
 	"slices"
 	"strings"
@@ -69,6 +68,7 @@ func CharacterLevenshteinThreshold(t float64, l *log.Logger) EqualityFunction {
 		sim := CharacterLevenshtein(strings.Join(e1, " "), strings.Join(e2, " "))
 		if sim >= t {
 			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "CharacterLevenshteinThreshold", e1, e2, t, sim)
+			similarities.record("CharacterLevenshteinThreshold", e1, e2, t, sim)
 			return true
 		}
 		return false
@@ -83,34 +83,93 @@ func TokenLevenshteinThreshold(t float64, l *log.Logger) EqualityFunction {
 		sim := TokenLevenshtein(e1, e2)
 		if sim >= t {
 			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "TokenLevenshteinThreshold", e1, e2, t, sim)
+			similarities.record("TokenLevenshteinThreshold", e1, e2, t, sim)
 			return true
 		}
 		return false
 	}
 }
 
-// Compares expression similarity via tfidf embeddings and cosine similarity
-func TFIDFCosineThreshold(thr float64, voc []string, tok Tokenizer, idf map[string]float64, l *log.Logger) EqualityFunction {
+// Compares expression similarity via jaro-winkler similarity
+func JaroWinklerThreshold(t float64, l *log.Logger) EqualityFunction {
 	return func(e1, e2 []string) bool {
-		vec1, err := CountEmbed(strings.Join(e1, " "), voc, tok)
-		if err != nil {
-			fmt.Println(err)
-			return false
+		sim := JaroWinkler(strings.Join(e1, " "), strings.Join(e2, " "))
+		if sim >= t {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "JaroWinklerThreshold", e1, e2, t, sim)
+			similarities.record("JaroWinklerThreshold", e1, e2, t, sim)
+			return true
+		}
+		return false
+	}
+}
+
+// Compares expression similarity via jaccard similarity of token sets
+func TokenJaccardThreshold(t float64, l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		e1 = strings.Split(strings.Join(e1, " "), " ")
+		e2 = strings.Split(strings.Join(e2, " "), " ")
+		sim := TokenJaccard(e1, e2)
+		if sim >= t {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "TokenJaccardThreshold", e1, e2, t, sim)
+			similarities.record("TokenJaccardThreshold", e1, e2, t, sim)
+			return true
+		}
+		return false
+	}
+}
+
+// Compares expression similarity via sorensen-dice similarity of token sets
+func SorensenDiceThreshold(t float64, l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		e1 = strings.Split(strings.Join(e1, " "), " ")
+		e2 = strings.Split(strings.Join(e2, " "), " ")
+		sim := SorensenDice(e1, e2)
+		if sim >= t {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "SorensenDiceThreshold", e1, e2, t, sim)
+			similarities.record("SorensenDiceThreshold", e1, e2, t, sim)
+			return true
 		}
-		vec2, err := CountEmbed(strings.Join(e2, " "), voc, tok)
-		if err != nil {
-			fmt.Println(err)
-			return false
+		return false
+	}
+}
+
+// Compares expression similarity via Jaccard similarity of character n-grams of length n
+func CharacterNGramThreshold(t float64, n int, l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		sim := CharacterNGramOverlap(strings.Join(e1, " "), strings.Join(e2, " "), n)
+		if sim >= t {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "CharacterNGramThreshold", e1, e2, t, sim)
+			similarities.record("CharacterNGramThreshold", e1, e2, t, sim)
+			return true
 		}
-		vec1 = TFIDFTransform(vec1, voc, idf)
-		vec2 = TFIDFTransform(vec2, voc, idf)
-		sim, err := CosineSimilarity(vec1, vec2)
-		if err != nil {
-			fmt.Println(err)
-			return false
+		return false
+	}
+}
+
+// Compares expression similarity via character level longest common subsequence
+func LCSRatioThreshold(t float64, l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		sim := LCSRatio(strings.Join(e1, " "), strings.Join(e2, " "))
+		if sim >= t {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "LCSRatioThreshold", e1, e2, t, sim)
+			similarities.record("LCSRatioThreshold", e1, e2, t, sim)
+			return true
 		}
-		if sim >= thr {
-			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "TFIDFCosineThreshold", e1, e2, thr, sim)
+		return false
+	}
+}
+
+// Compares expression similarity via token edit distance weighted by universal POS tags
+func POSWeightedThreshold(t float64, c SyntacticTagger, costs EditCosts, l *log.Logger) EqualityFunction {
+	c = c.WithTagMap(UniversalTagMap)
+
+	return func(e1, e2 []string) bool {
+		t1, s1 := c.POS(strings.Join(e1, " "))
+		t2, s2 := c.POS(strings.Join(e2, " "))
+		sim := WeightedLevenshtein(s1, s2, t1, t2, costs)
+		if sim >= t {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "POSWeightedThreshold", e1, e2, t, sim)
+			similarities.record("POSWeightedThreshold", e1, e2, t, sim)
 			return true
 		}
 		return false
@@ -161,8 +220,31 @@ func SortPRS(r []Rule) {
 	})
 }
 
+// Merges each rule into the previous one while check matches and all guards allow the merge, in a single pass over sorted rules
+// merged rules are written back into r, which is reused for the result
+func mergeNeighbours(r []Rule, check func(Rule, Rule) bool, merge func(Rule, Rule) Rule, name string, l *log.Logger, g []MergeGuard) []Rule {
+	out := r[:0]
+
+	for i := range r {
+		if len(out) > 0 && check(out[len(out)-1], r[i]) {
+			rule := merge(out[len(out)-1], r[i])
+			rule.lines = unionLines(out[len(out)-1], r[i])
+			if !allowMerge(g, out[len(out)-1], r[i], rule) {
+				out = append(out, r[i])
+				continue
+			}
+			l.Printf("merge function %s replaced %v and %v with new rule %v\n", name, out[len(out)-1], r[i], rule)
+			out[len(out)-1] = rule
+			continue
+		}
+		out = append(out, r[i])
+	}
+
+	return out
+}
+
 // Merge rules based on equality function match in rule prefix
-func MergeP(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
+func MergeP(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
 	check := func(r1 Rule, r2 Rule) bool { return e(r1.pre, r2.pre) }
 	merge := func(r1 Rule, r2 Rule) Rule {
 		var r Rule
@@ -184,22 +266,11 @@ func MergeP(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
 	}
 
 	SortPRS(r)
-	for i := 0; i < len(r)-1; {
-		if check(r[i], r[i+1]) {
-			rule := merge(r[i], r[i+1])
-			l.Printf("merge function %s replaced %v and %v with new rule %v\n", "MergeP", r[i], r[i+1], rule)
-			r[i] = rule
-			r = slices.Delete(r, i+1, i+2)
-			continue
-		}
-		i++
-	}
-
-	return r
+	return mergeNeighbours(r, check, merge, "MergeP", l, g)
 }
 
 // Merge rules based on equality function match in rule root
-func MergeR(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
+func MergeR(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
 	check := func(r1 Rule, r2 Rule) bool { return e(r1.root, r2.root) }
 	merge := func(r1 Rule, r2 Rule) Rule {
 		var r Rule
@@ -221,22 +292,11 @@ func MergeR(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
 	}
 
 	SortPRS(r)
-	for i := 0; i < len(r)-1; {
-		if check(r[i], r[i+1]) {
-			rule := merge(r[i], r[i+1])
-			l.Printf("merge function %s replaced %v and %v with new rule %v\n", "MergeR", r[i], r[i+1], rule)
-			r[i] = rule
-			r = slices.Delete(r, i+1, i+2)
-			continue
-		}
-		i++
-	}
-
-	return r
+	return mergeNeighbours(r, check, merge, "MergeR", l, g)
 }
 
 // Merge rules based on equality function match in rule suffix
-func MergeS(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
+func MergeS(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
 	check := func(r1 Rule, r2 Rule) bool { return e(r1.suf, r2.suf) }
 	merge := func(r1 Rule, r2 Rule) Rule {
 		var r Rule
@@ -258,22 +318,11 @@ func MergeS(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
 	}
 
 	SortPRS(r)
-	for i := 0; i < len(r)-1; {
-		if check(r[i], r[i+1]) {
-			rule := merge(r[i], r[i+1])
-			l.Printf("merge function %s replaced %v and %v with new rule %v\n", "MergeS", r[i], r[i+1], rule)
-			r[i] = rule
-			r = slices.Delete(r, i+1, i+2)
-			continue
-		}
-		i++
-	}
-
-	return r
+	return mergeNeighbours(r, check, merge, "MergeS", l, g)
 }
 
 // Merge rules based on equality function match in rule prefix and root
-func MergePR(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
+func MergePR(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
 	check := func(r1 Rule, r2 Rule) bool {
 		return e(r1.pre, r2.pre) && e(r1.root, r2.root)
 	}
@@ -293,22 +342,11 @@ func MergePR(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
 	}
 
 	SortPR(r)
-	for i := 0; i < len(r)-1; {
-		if check(r[i], r[i+1]) {
-			rule := merge(r[i], r[i+1])
-			l.Printf("merge function %s replaced %v and %v with new rule %v\n", "MergePR", r[i], r[i+1], rule)
-			r[i] = rule
-			r = slices.Delete(r, i+1, i+2)
-			continue
-		}
-		i++
-	}
-
-	return r
+	return mergeNeighbours(r, check, merge, "MergePR", l, g)
 }
 
 // Merge rules based on equality function match in rule prefix and suffix
-func MergePS(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
+func MergePS(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
 	check := func(r1 Rule, r2 Rule) bool {
 		return e(r1.pre, r2.pre) && e(r1.suf, r2.suf)
 	}
@@ -328,22 +366,11 @@ func MergePS(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
 	}
 
 	SortPS(r)
-	for i := 0; i < len(r)-1; {
-		if check(r[i], r[i+1]) {
-			rule := merge(r[i], r[i+1])
-			l.Printf("merge function %s replaced %v and %v with new rule %v\n", "MergePS", r[i], r[i+1], rule)
-			r[i] = rule
-			r = slices.Delete(r, i+1, i+2)
-			continue
-		}
-		i++
-	}
-
-	return r
+	return mergeNeighbours(r, check, merge, "MergePS", l, g)
 }
 
 // Merge rules based on equality function match in rule root and suffix
-func MergeRS(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
+func MergeRS(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
 	check := func(r1 Rule, r2 Rule) bool {
 		return e(r1.root, r2.root) && e(r1.suf, r2.suf)
 	}
@@ -363,23 +390,13 @@ func MergeRS(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
 	}
 
 	SortRS(r)
-	for i := 0; i < len(r)-1; {
-		if check(r[i], r[i+1]) {
-			rule := merge(r[i], r[i+1])
-			l.Printf("merge function %s replaced %v and %v with new rule %v\n", "MergeRS", r[i], r[i+1], rule)
-			r[i] = rule
-			r = slices.Delete(r, i+1, i+2)
-			continue
-		}
-		i++
-	}
-
-	return r
+	return mergeNeighbours(r, check, merge, "MergeRS", l, g)
 }
 
 // Merge rules where prefix, root, and suffix are all len==1 or empty into one rule
-// generally applied after all other megring strategies
-func MergeMisc(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
+// generally applied after all other megring strategies, the new rule produces exactly the productions of the rules it replaces
+// guards are checked for each rule added, passing the new rule so far as r1, and rejected rules are kept as is
+func MergeMisc(r []Rule, e EqualityFunction, l *log.Logger, g ...MergeGuard) []Rule {
 	check := func(r Rule) bool {
 		return len(r.pre) <= 1 && len(r.root) <= 1 && len(r.suf) <= 1
 	}
@@ -406,28 +423,50 @@ func MergeMisc(r []Rule, e EqualityFunction, l *log.Logger) []Rule {
 			rule.pre = []string{}
 			rule.suf = []string{}
 			rule.isPublic = true
+			rule.lines = unionLines(rules...)
 		}
 
 		return rule
 	}
 
-	var rr []Rule
-	var res Rule
+	var (
+		rr   []Rule
+		res  Rule
+		out  = r[:0]
+		skip bool
+	)
 
 	SortPRS(r)
-	for i := 0; i < len(r); i++ {
-		if check(r[i]) {
+	for i := range r {
+		// the rule following each added rule is kept as is
+		if !skip && check(r[i]) {
+			if len(g) != 0 && !r[i].isEmpty() {
+				next := res.clone()
+				if added := merge(r[i]); !slices.Contains(next.root, added.root[0]) {
+					next.root = append(next.root, added.root[0])
+				}
+				next.pre, next.suf, next.isPublic, next.lines = []string{}, []string{}, true, unionLines(res, r[i])
+				if !allowMerge(g, res, r[i], next) {
+					skip = false
+					out = append(out, r[i])
+					continue
+				}
+				res = next
+			}
 			l.Printf("merge function %s added %v to new misc rule\n", "MergeMisc", r[i])
 			if !r[i].isEmpty() {
 				rr = append(rr, r[i])
 			}
-			r = slices.Delete(r, i, i+1)
+			skip = true
+			continue
 		}
+		skip = false
+		out = append(out, r[i])
 	}
 	res = merge(rr...)
 	if !res.isEmpty() {
-		r = append(r, res)
+		out = append(out, res)
 	}
 
-	return r
+	return out
 }
@@ -64,7 +64,7 @@ func TestSortPR(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -131,7 +131,7 @@ func TestSortPS(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -198,7 +198,7 @@ func TestSortRS(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -265,7 +265,7 @@ func TestSortPRS(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -312,7 +312,7 @@ func TestMergeP(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -381,7 +381,7 @@ func TestMergeR(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -431,7 +431,7 @@ func TestMergeS(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -499,7 +499,7 @@ func TestMergePR(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -547,7 +547,7 @@ func TestMergePS(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -615,7 +615,7 @@ func TestMergeRS(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -673,7 +673,7 @@ func TestMergeMisc(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -691,3 +691,51 @@ func TestMergeMisc(t *testing.T) {
 		})
 	}
 }
+
+func TestMergeMisc_guards(t *testing.T) {
+	rule := func(s string, line int) Rule {
+		return Rule{pre: []string{}, root: []string{s}, suf: []string{}, isPublic: true, lines: []int{line}}
+	}
+	var (
+		rules   = []Rule{rule("a", 1), rule("b", 2), rule("c", 3), rule("d", 4)}
+		checked []Rule
+		guard   = func(r1, r2, merged Rule) bool {
+			checked = append(checked, merged)
+			return r2.root[0] != "a"
+		}
+	)
+
+	res := MergeMisc(rules, DummyEqual(nilLogger), nilLogger, guard)
+	// rejected rules are kept as is, and the rule following each added rule is kept as is
+	assert.Equal(t, []Rule{rule("a", 1), rule("c", 3), {pre: []string{}, root: []string{"b", "d"}, suf: []string{}, isPublic: true, lines: []int{2, 4}}}, res)
+	assert.Equal(t, []Rule{{pre: []string{}, root: []string{"a"}, suf: []string{}, isPublic: true, lines: []int{1}}, {pre: []string{}, root: []string{"b"}, suf: []string{}, isPublic: true, lines: []int{2}}, {pre: []string{}, root: []string{"b", "d"}, suf: []string{}, isPublic: true, lines: []int{2, 4}}}, checked)
+}
+
+func TestMerge_lines(t *testing.T) {
+	tests := []struct {
+		m    MergeFunction
+		e    EqualityFunction
+		want [][]int
+	}{
+		{m: MergeS, e: LiteralEqual(nilLogger), want: [][]int{{1, 3, 5}, {2, 4}}},
+		{m: HashMerge("S"), e: LiteralEqual(nilLogger), want: [][]int{{1, 3, 5}, {2, 4}}},
+		{m: ClusterMerge("S", "single", NoBlocking), e: LiteralEqual(nilLogger), want: [][]int{{1, 3, 5}, {2, 4}}},
+		// the rule following each rule added to the misc rule is kept as is
+		{m: MergeMisc, e: DummyEqual(nilLogger), want: [][]int{{1, 5}, {2, 3}, {4}}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			rules := []Rule{
+				{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill"}, isPublic: true, lines: []int{3}},
+				{pre: []string{"can i"}, root: []string{"view"}, suf: []string{"my bill"}, isPublic: true, lines: []int{1, 5}},
+				{pre: []string{"how do i"}, root: []string{"cancel"}, suf: []string{"order"}, isPublic: true, lines: []int{2}},
+				{pre: []string{"i"}, root: []string{"want"}, suf: []string{"order"}, isPublic: true, lines: []int{4}},
+			}
+			var got [][]int
+			for _, r := range tt.m(rules, tt.e, nilLogger) {
+				got = append(got, r.lines)
+			}
+			assert.ElementsMatch(t, tt.want, got)
+		})
+	}
+}
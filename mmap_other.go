@@ -0,0 +1,20 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:52:39 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+//go:build !unix
+
+package main
+
+import "os"
+
+// Reads a file into memory on platforms without mmap support
+func mapFile(p string) ([]byte, func() error, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, func() error { return nil }, nil
+}
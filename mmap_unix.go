@@ -0,0 +1,37 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:52:39 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+//go:build unix
+
+package main
+
+import (
+	"os"
+	"syscall"
+)
+
+// Maps a file read only into memory, returning its contents and a function releasing the mapping
+func mapFile(p string) ([]byte, func() error, error) {
+	f, err := os.Open(p)
+	if err != nil {
+		return nil, nil, err
+	}
+	defer f.Close()
+
+	info, err := f.Stat()
+	if err != nil {
+		return nil, nil, err
+	}
+	if info.Size() == 0 {
+		return []byte{}, func() error { return nil }, nil
+	}
+
+	b, err := syscall.Mmap(int(f.Fd()), 0, int(info.Size()), syscall.PROT_READ, syscall.MAP_SHARED)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	return b, func() error { return syscall.Munmap(b) }, nil
+}
@@ -0,0 +1,311 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:41:18 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// References are expanded at most this many levels deep, guarding against cyclic rules
+const maxReferenceDepth = 8
+
+// Set of utterances matched against the productions of rules
+// utterances and rule productions are compared token by token, and only the parts of rules which could form one of the utterances are expanded
+type Utterances struct {
+	mu    sync.Mutex
+	tok   Tokenizer
+	texts []string
+	// spans[k][i][j] holds tokens i to j of utterance k
+	spans [][][]string
+	// positions of every span of every utterance, as utterance, start, and end
+	index map[string][][3]int
+	// rules which may be referenced from other rules, keyed by name
+	refs map[string]Rule
+	// productions of referenced rules, cleared whenever a rule is registered
+	expanded map[string]map[string]bool
+}
+
+// Normalizes utterances with tokenizer tok, references in rules are resolved using refs
+func NewUtterances(s []string, tok Tokenizer, refs []Rule) *Utterances {
+	u := Utterances{tok: tok, index: make(map[string][][3]int), refs: make(map[string]Rule), expanded: make(map[string]map[string]bool)}
+
+	for i := range s {
+		text := tok.normalize(s[i])
+		if text == "" || slices.Contains(u.texts, text) {
+			continue
+		}
+		tokens := strings.Fields(text)
+		spans := make([][]string, len(tokens)+1)
+		for i := range spans {
+			spans[i] = make([]string, len(tokens)+1)
+			for j := i; j <= len(tokens); j++ {
+				spans[i][j] = strings.Join(tokens[i:j], " ")
+				u.index[spans[i][j]] = append(u.index[spans[i][j]], [3]int{len(u.texts), i, j})
+			}
+		}
+		u.texts = append(u.texts, text)
+		u.spans = append(u.spans, spans)
+	}
+	u.register(refs...)
+
+	return &u
+}
+
+// Helper function to make rules available to references, callers must hold the lock once the utterances are in use
+func (u *Utterances) register(r ...Rule) {
+	for i := range r {
+		u.refs[r[i].name()] = r[i]
+	}
+	clear(u.expanded)
+}
+
+func (u *Utterances) Len() int {
+	return len(u.texts)
+}
+
+// Utterances which the grammar must not produce, such as examples of other intents
+type Negatives struct{ *Utterances }
+
+func NewNegatives(s []string, tok Tokenizer, refs []Rule) *Negatives {
+	return &Negatives{NewUtterances(s, tok, refs)}
+}
+
+// Reads negative examples, one per line
+func ReadNegatives(p string) ([]string, error) {
+	var out []string
+
+	file, err := os.Open(p)
+	if err != nil {
+		return out, err
+	}
+	defer file.Close()
+
+	for _, t := range ReadTexts(bufio.NewScanner(file)) {
+		out = append(out, t.text)
+	}
+
+	return out, nil
+}
+
+// Helper function to join non empty expressions with a space
+func joinExpressions(s ...string) string {
+	return strings.Join(slices.DeleteFunc(s, func(e string) bool { return e == "" }), " ")
+}
+
+// Helper function to collect the productions of an expression group which appear in an utterance
+// references are replaced by the productions of the referenced rule, whose alternatives are normalized as they may hold values taken from the raw corpus
+// alternatives of printed rules are normalized, as printing joins boundary characters to the preceding token
+func (u *Utterances) expand(g []string, depth int) map[string]bool {
+	out := make(map[string]bool)
+
+	var alternatives []string
+	for a := range slotSet(g) {
+		alternatives = append(alternatives, splitAlternatives(a)...)
+	}
+	for _, a := range alternatives {
+		if depth > 0 || joinedBoundary(a) {
+			a = u.tok.normalize(a)
+		}
+		if !strings.Contains(a, "<") {
+			if len(u.index[a]) != 0 {
+				out[a] = true
+			}
+			continue
+		}
+		alts := []string{""}
+		for _, t := range strings.Fields(a) {
+			var next []string
+			name := strings.TrimSuffix(strings.TrimPrefix(t, "<"), ">")
+			ref, ok := u.refs[name]
+			if !ok || depth >= maxReferenceDepth {
+				for i := range alts {
+					next = append(next, joinExpressions(alts[i], t))
+				}
+				alts = next
+				continue
+			}
+			prods, ok := u.expanded[name]
+			if !ok {
+				prods = u.productions(ref, depth+1)
+				u.expanded[name] = prods
+			}
+			for p := range prods {
+				for i := range alts {
+					if e := joinExpressions(alts[i], p); len(u.index[e]) != 0 {
+						next = append(next, e)
+					}
+				}
+			}
+			alts = next
+		}
+		for i := range alts {
+			if len(u.index[alts[i]]) != 0 {
+				out[alts[i]] = true
+			}
+		}
+	}
+
+	return out
+}
+
+// Helper function to split an expression on | outside of rule references, as factored rules hold their alternatives joined by | in a single expression
+func splitAlternatives(s string) []string {
+	var (
+		out   []string
+		last  int
+		inRef bool
+	)
+
+	for i := range len(s) {
+		switch s[i] {
+		case '<':
+			inRef = true
+		case '>':
+			inRef = false
+		case '|':
+			if !inRef {
+				out = append(out, s[last:i])
+				last = i + 1
+			}
+		}
+	}
+
+	return append(out, s[last:])
+}
+
+// Helper function to check if an expression has boundary characters joined to the preceding token, as left by printing a rule
+func joinedBoundary(s string) bool {
+	for i := 1; i < len(s); i++ {
+		if s[i-1] != ' ' && slices.Contains(boundaryChars, s[i:i+1]) {
+			return true
+		}
+	}
+
+	return false
+}
+
+// Helper function to collect the productions of a rule which appear in an utterance
+func (u *Utterances) productions(r Rule, depth int) map[string]bool {
+	var (
+		out  = make(map[string]bool)
+		pre  = u.expand(r.pre, depth)
+		root = u.expand(r.root, depth)
+		suf  = u.expand(r.suf, depth)
+	)
+
+	for p := range pre {
+		for m := range root {
+			for s := range suf {
+				if e := joinExpressions(p, m, s); len(u.index[e]) != 0 {
+					out[e] = true
+				}
+			}
+		}
+	}
+
+	return out
+}
+
+// Lists the utterances produced by a rule, in the order they were given
+// each occurrence of a root alternative in an utterance is looked up, and the tokens before and after it are looked up in the prefixes and suffixes
+func (u *Utterances) Produced(r Rule) []string {
+	var (
+		out   []string
+		found = make(map[int]bool)
+	)
+
+	u.mu.Lock()
+	defer u.mu.Unlock()
+	if len(u.texts) == 0 {
+		return out
+	}
+
+	pre, root, suf := u.expand(r.pre, 0), u.expand(r.root, 0), u.expand(r.suf, 0)
+	for m := range root {
+		for _, o := range u.index[m] {
+			last := len(u.spans[o[0]]) - 1
+			if !found[o[0]] && pre[u.spans[o[0]][0][o[1]]] && suf[u.spans[o[0]][o[2]][last]] {
+				found[o[0]] = true
+			}
+		}
+	}
+	for _, k := range slices.Sorted(maps.Keys(found)) {
+		out = append(out, u.texts[k])
+	}
+
+	return out
+}
+
+// Guard rejecting merges and factoring steps whose new rule produces negative examples not produced by the rules it replaces
+// non public rules passed as r2 are factored out rules, and are registered so that references to them can be resolved
+func (n *Negatives) Guard(l *log.Logger) MergeGuard {
+	return func(r1, r2, merged Rule) bool {
+		if len(n.texts) == 0 {
+			return true
+		}
+		if !r2.isPublic {
+			n.mu.Lock()
+			if _, ok := n.refs[r2.name()]; !ok {
+				n.register(r2)
+			}
+			n.mu.Unlock()
+		}
+
+		produced := n.Produced(merged)
+		if len(produced) == 0 {
+			return true
+		}
+		existing := n.Produced(r1)
+		if r2.isPublic {
+			existing = append(existing, n.Produced(r2)...)
+		}
+		var introduced []string
+		for i := range produced {
+			if !slices.Contains(existing, produced[i]) {
+				introduced = append(introduced, produced[i])
+			}
+		}
+		if len(introduced) == 0 {
+			return true
+		}
+		l.Printf("merge guard %s rejected merging %v and %v, new rule %v produces negative examples %v\n", "Negatives", r1, r2, merged, introduced)
+
+		return false
+	}
+}
+
+// Checks each public rule of a grammar against the negative examples, logging each negative example produced
+// returns an error listing the negative examples produced
+func (n *Negatives) Check(rules []Rule, l *log.Logger) error {
+	var produced []string
+
+	n.mu.Lock()
+	n.register(slices.DeleteFunc(slices.Clone(rules), func(r Rule) bool { return r.isPublic })...)
+	n.mu.Unlock()
+	for i := range rules {
+		if !rules[i].isPublic {
+			continue
+		}
+		for _, p := range n.Produced(rules[i]) {
+			l.Printf("NEGATIVE: rule %v produces negative example %v\n", rules[i].name(), p)
+			if !slices.Contains(produced, p) {
+				produced = append(produced, p)
+			}
+		}
+	}
+	if len(produced) != 0 {
+		return fmt.Errorf("in Negatives.Check():\n%+w", fmt.Errorf("grammar produces negative examples %v", produced))
+	}
+
+	return nil
+}
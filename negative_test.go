@@ -0,0 +1,109 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:41:18 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestReadNegatives(t *testing.T) {
+	got, err := ReadNegatives("./data/tests/negatives1.txt")
+	assert.Nil(t, err)
+	assert.Equal(t, []string{"can i cancel my bill", "how do i cancel my bill?"}, got)
+
+	_, err = ReadNegatives("./data/tests/missing.txt")
+	assert.NotNil(t, err)
+}
+
+func TestNewNegatives(t *testing.T) {
+	n := NewNegatives([]string{"how do i cancel my bill?", "how do i cancel my bill ?", ""}, NewWordTokenizer(), []Rule{})
+
+	assert.Equal(t, 1, n.Len())
+	assert.Equal(t, []string{"how do i cancel my bill ?"}, n.texts)
+	assert.Equal(t, "cancel my", n.spans[0][3][5])
+	assert.Len(t, n.index[""], 8)
+	assert.Equal(t, [][3]int{{0, 0, 7}}, n.index["how do i cancel my bill ?"])
+	assert.Equal(t, 0, NewNegatives([]string{}, NewWordTokenizer(), []Rule{}).Len())
+}
+
+func TestNegatives_Produced(t *testing.T) {
+	var (
+		factored = Rule{pre: []string{}, root: []string{"bill", "invoice"}, suf: []string{}, id: 5}
+		class    = Rule{pre: []string{}, root: []string{"5", "$10"}, suf: []string{}, label: "NUM"}
+		n        = NewNegatives([]string{"can i cancel my bill", "pay $10 now", "cancel"}, NewWordTokenizer(), []Rule{factored, class})
+	)
+	tests := []struct {
+		r    Rule
+		want []string
+	}{
+		{r: Rule{}, want: nil},
+		{r: Rule{pre: []string{"can i cancel", "can i pay"}, root: []string{"my order", "my bill"}, suf: []string{""}}, want: []string{"can i cancel my bill"}},
+		{r: Rule{pre: []string{"can i"}, root: []string{"cancel my"}, suf: []string{"order", "invoice"}}, want: nil},
+		// empty expression groups and empty alternatives contribute no tokens
+		{r: Rule{pre: []string{}, root: []string{"cancel"}, suf: []string{"", "my bill"}}, want: []string{"cancel"}},
+		{r: Rule{pre: []string{"can i", ""}, root: []string{"cancel my <bill_invoice_5>"}, suf: []string{}}, want: []string{"can i cancel my bill"}},
+		{r: Rule{pre: []string{"pay"}, root: []string{"<NUM>"}, suf: []string{"now", "later"}}, want: []string{"pay $10 now"}},
+		{r: Rule{pre: []string{"pay"}, root: []string{"<unknown>"}, suf: []string{"now"}}, want: nil},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, n.Produced(tt.r))
+		})
+	}
+}
+
+func TestNegatives_Guard(t *testing.T) {
+	var (
+		n  = NewNegatives([]string{"can i cancel my bill"}, NewWordTokenizer(), []Rule{})
+		g  = n.Guard(nilLogger)
+		r1 = Rule{pre: []string{"can i cancel"}, root: []string{"my order"}, suf: []string{}, isPublic: true}
+		r2 = Rule{pre: []string{"can i pay"}, root: []string{"my bill"}, suf: []string{}, isPublic: true}
+		r3 = Rule{pre: []string{"can i cancel"}, root: []string{"my bill"}, suf: []string{}, isPublic: true}
+		f  = Rule{pre: []string{}, root: []string{"my bill", "my order"}, suf: []string{}, id: 3}
+	)
+
+	assert.False(t, g(r1, r2, Rule{pre: []string{"can i cancel", "can i pay"}, root: []string{"my bill", "my order"}, suf: []string{}, isPublic: true}))
+	assert.True(t, g(r2, r2, Rule{pre: []string{"can i pay"}, root: []string{"my bill", "my order"}, suf: []string{}, isPublic: true}))
+	// negatives already produced by one of the rules are not introduced by the merge
+	assert.True(t, g(r3, r1, Rule{pre: []string{"can i cancel"}, root: []string{"my bill", "my order"}, suf: []string{}, isPublic: true}))
+	// factored out rules are resolved when checking the factored rule
+	assert.False(t, g(r1, f, Rule{pre: []string{"can i cancel"}, root: []string{"<my_bill_my_order_3>"}, suf: []string{}, isPublic: true}))
+	assert.True(t, NewNegatives([]string{}, NewWordTokenizer(), []Rule{}).Guard(nilLogger)(r1, r2, r3))
+}
+
+func TestNegatives_Check(t *testing.T) {
+	n := NewNegatives([]string{"can i cancel my bill", "can i pay my order"}, NewWordTokenizer(), []Rule{})
+	rules := []Rule{
+		{pre: []string{"can i cancel"}, root: []string{"<my_bill_my_order_3>"}, suf: []string{}, isPublic: true},
+		{pre: []string{}, root: []string{"my bill", "my order"}, suf: []string{}, id: 3},
+		{pre: []string{"can i pay my"}, root: []string{"bill"}, suf: []string{}, isPublic: true},
+	}
+
+	assert.NotNil(t, n.Check(rules, nilLogger))
+	assert.ErrorContains(t, n.Check(rules, nilLogger), "[can i cancel my bill]")
+	assert.Nil(t, n.Check(rules[1:], nilLogger))
+}
+
+func TestSynonymFactor_negatives(t *testing.T) {
+	var (
+		n     = NewNegatives([]string{"can i pay my invoice"}, NewWordTokenizer(), []Rule{})
+		rules = []Rule{
+			{pre: []string{"can i pay"}, root: []string{"my bill"}, suf: []string{""}, isPublic: true},
+			{pre: []string{"can i view"}, root: []string{"my bill"}, suf: []string{""}, isPublic: true},
+		}
+		want = []Rule{
+			{pre: []string{"can i pay"}, root: []string{"my bill"}, suf: []string{""}, isPublic: true},
+			{pre: []string{"can i view"}, root: []string{"my <bill_invoice_3>"}, suf: []string{""}, isPublic: true},
+			{pre: []string{}, root: []string{"bill", "invoice"}, suf: []string{}, id: 3},
+		}
+	)
+
+	got := SynonymFactor(Synonyms{"bill": {"invoice"}}, NewWordTokenizer(), nilLogger)(rules, n.Guard(nilLogger))
+	assert.Equal(t, want, got)
+	assert.Nil(t, n.Check(got, nilLogger))
+}
@@ -0,0 +1,155 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:36:36 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"log"
+	"sync"
+)
+
+// Function that decides if two rules may be replaced by their merged rule
+type MergeGuard func(r1, r2, merged Rule) bool
+
+// Helper function to check a merge against all guards, in order and stopping at the first rejection
+// guards recording accepted merges should come last, as they cannot know if a later guard rejects the merge
+func allowMerge(g []MergeGuard, r1, r2, merged Rule) bool {
+	for i := range g {
+		if !g[i](r1, r2, merged) {
+			return false
+		}
+	}
+
+	return true
+}
+
+// Helper function to collect the distinct alternatives of an expression group, an empty group produces a single empty alternative
+func slotSet(s []string) map[string]bool {
+	if len(s) == 0 {
+		return map[string]bool{"": true}
+	}
+
+	m := make(map[string]bool)
+	for i := range s {
+		m[s[i]] = true
+	}
+
+	return m
+}
+
+// Counts the alternatives shared by all expression groups
+func sharedAlternatives(s ...[]string) int {
+	var (
+		n    int
+		sets = make([]map[string]bool, len(s))
+	)
+	for i := range s {
+		sets[i] = slotSet(s[i])
+	}
+
+	for k := range sets[0] {
+		shared := true
+		for i := range sets[1:] {
+			if !sets[i+1][k] {
+				shared = false
+				break
+			}
+		}
+		if shared {
+			n++
+		}
+	}
+
+	return n
+}
+
+// Counts the productions of a rule, the product of the number of alternatives in each expression group
+func Productions(r Rule) int {
+	return len(slotSet(r.pre)) * len(slotSet(r.root)) * len(slotSet(r.suf))
+}
+
+// Helper function to count the productions shared by all rules, the product of the alternatives shared in each expression group
+func sharedProductions(r ...Rule) int {
+	var pre, root, suf [][]string
+
+	for i := range r {
+		pre = append(pre, r[i].pre)
+		root = append(root, r[i].root)
+		suf = append(suf, r[i].suf)
+	}
+
+	return sharedAlternatives(pre...) * sharedAlternatives(root...) * sharedAlternatives(suf...)
+}
+
+// Counts the productions of a merged rule not produced by either of the rules it replaces
+// by inclusion-exclusion, novel = |m| - |m ∩ r1| - |m ∩ r2| + |m ∩ r1 ∩ r2|
+func NovelProductions(r1, r2, merged Rule) int {
+	return Productions(merged) - sharedProductions(merged, r1) - sharedProductions(merged, r2) + sharedProductions(merged, r1, r2)
+}
+
+// Limits the novel productions introduced by merging, per merge and across the grammar
+// budgets of 1 or more are absolute counts, budgets between 0 and 1 are ratios, and a budget of 0 is unlimited
+// per merge ratios are relative to the productions of the two rules being merged, and grammar ratios to the productions of the corpus
+type NovelBudget struct {
+	mu      sync.Mutex
+	merge   float64
+	grammar float64
+	corpus  int
+	novel   int
+}
+
+func NewNovelBudget(merge float64, grammar float64, corpus int) *NovelBudget {
+	return &NovelBudget{merge: merge, grammar: grammar, corpus: corpus}
+}
+
+// Total novel productions accepted so far
+func (b *NovelBudget) Novel() int {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	return b.novel
+}
+
+// Helper function to resolve a budget to a count of productions
+func budgetLimit(budget float64, base int) float64 {
+	if budget < 1 {
+		return budget * float64(base)
+	}
+	return budget
+}
+
+// Helper function to check a merge against the per merge budget and the remaining grammar budget, callers must hold the lock
+func (b *NovelBudget) fits(r1, r2, merged Rule, novel int, l *log.Logger) bool {
+	existing := Productions(r1) + Productions(r2) - sharedProductions(r1, r2)
+	if limit := budgetLimit(b.merge, existing); b.merge > 0 && float64(novel) > limit {
+		l.Printf("merge guard %s rejected merging %v and %v, %v novel productions exceed merge budget of %v\n", "NovelBudget", r1, r2, novel, limit)
+		return false
+	}
+	if limit := budgetLimit(b.grammar, b.corpus); b.grammar > 0 && float64(b.novel+novel) > limit {
+		l.Printf("merge guard %s rejected merging %v and %v, %v novel productions exceed remaining grammar budget of %v\n", "NovelBudget", r1, r2, novel, limit-float64(b.novel))
+		return false
+	}
+
+	return true
+}
+
+// Guard rejecting merges which exceed the per merge budget or the remaining grammar budget, accepted merges are counted against the grammar budget
+func (b *NovelBudget) Guard(l *log.Logger) MergeGuard {
+	return func(r1, r2, merged Rule) bool {
+		novel := NovelProductions(r1, r2, merged)
+		if novel == 0 {
+			return true
+		}
+
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		if !b.fits(r1, r2, merged, novel, l) {
+			return false
+		}
+		b.novel += novel
+
+		return true
+	}
+}
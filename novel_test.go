@@ -0,0 +1,142 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:36:36 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"fmt"
+	"slices"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// Expression group of n numbered alternatives
+func alternatives(s string, n int) []string {
+	var out []string
+	for i := range n {
+		out = append(out, fmt.Sprintf("%s%d", s, i))
+	}
+
+	return out
+}
+
+func TestProductions(t *testing.T) {
+	tests := []struct {
+		r    Rule
+		want int
+	}{
+		{r: Rule{}, want: 1},
+		{r: Rule{pre: []string{""}, root: []string{"pay"}, suf: []string{""}}, want: 1},
+		{r: Rule{pre: []string{"i want to", "can i"}, root: []string{"pay"}, suf: []string{"my bill", "my invoice", "now"}}, want: 6},
+		{r: Rule{pre: []string{"can i", "can i"}, root: []string{"pay"}, suf: []string{}}, want: 1},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, Productions(tt.r))
+		})
+	}
+}
+
+func TestNovelProductions(t *testing.T) {
+	type args struct {
+		r1     Rule
+		r2     Rule
+		merged Rule
+	}
+	tests := []struct {
+		args args
+		want int
+	}{
+		// identical prefix and root, the merged rule produces exactly the productions of both rules
+		{args: args{
+			r1:     Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill"}},
+			r2:     Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"now"}},
+			merged: Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill", "now"}},
+		}, want: 0},
+		{args: args{
+			r1:     Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill"}},
+			r2:     Rule{pre: []string{"i want to"}, root: []string{"pay"}, suf: []string{"now"}},
+			merged: Rule{pre: []string{"can i", "i want to"}, root: []string{"pay"}, suf: []string{"my bill", "now"}},
+		}, want: 2},
+		{args: args{
+			r1:     Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill", "now"}},
+			r2:     Rule{pre: []string{"i want to"}, root: []string{"pay"}, suf: []string{"now"}},
+			merged: Rule{pre: []string{"can i", "i want to"}, root: []string{"pay"}, suf: []string{"my bill", "now"}},
+		}, want: 1},
+		{args: args{
+			r1:     Rule{pre: alternatives("p", 40), root: []string{"pay"}, suf: []string{""}},
+			r2:     Rule{pre: []string{""}, root: []string{"pay"}, suf: alternatives("s", 60)},
+			merged: Rule{pre: slices.Concat([]string{""}, alternatives("p", 40)), root: []string{"pay"}, suf: slices.Concat([]string{""}, alternatives("s", 60))},
+		}, want: 2401},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, NovelProductions(tt.args.r1, tt.args.r2, tt.args.merged))
+		})
+	}
+}
+
+func TestNovelBudget_Guard(t *testing.T) {
+	type args struct {
+		merge   float64
+		grammar float64
+		corpus  int
+	}
+	var (
+		r1     = Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill"}}
+		r2     = Rule{pre: []string{"i want to"}, root: []string{"pay"}, suf: []string{"now"}}
+		merged = Rule{pre: []string{"can i", "i want to"}, root: []string{"pay"}, suf: []string{"my bill", "now"}}
+		r3     = Rule{pre: alternatives("p", 40), root: []string{"pay"}, suf: []string{""}}
+		r4     = Rule{pre: []string{""}, root: []string{"pay"}, suf: alternatives("s", 60)}
+		large  = Rule{pre: slices.Concat([]string{""}, alternatives("p", 40)), root: []string{"pay"}, suf: slices.Concat([]string{""}, alternatives("s", 60))}
+	)
+	tests := []struct {
+		args  args
+		want  []bool
+		novel int
+	}{
+		{args: args{merge: 0, grammar: 0, corpus: 100}, want: []bool{true, true}, novel: 2403},
+		{args: args{merge: 100, grammar: 0, corpus: 100}, want: []bool{true, false}, novel: 2},
+		{args: args{merge: 1, grammar: 0, corpus: 100}, want: []bool{false, false}, novel: 0},
+		// ratios are relative to the 2 and 100 productions of each pair of rules, which both merges exceed
+		{args: args{merge: 0.9, grammar: 0, corpus: 100}, want: []bool{false, false}, novel: 0},
+		{args: args{merge: 0, grammar: 2500, corpus: 100}, want: []bool{true, true}, novel: 2403},
+		{args: args{merge: 0, grammar: 2402, corpus: 100}, want: []bool{true, false}, novel: 2},
+		{args: args{merge: 0, grammar: 0.01, corpus: 100}, want: []bool{false, false}, novel: 0},
+		{args: args{merge: 0, grammar: 0.02, corpus: 100}, want: []bool{true, false}, novel: 2},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			b := NewNovelBudget(tt.args.merge, tt.args.grammar, tt.args.corpus)
+			g := b.Guard(nilLogger)
+			assert.Equal(t, tt.want, []bool{g(r1, r2, merged), g(r3, r4, large)})
+			assert.Equal(t, tt.novel, b.Novel())
+			// merges without novel productions are always allowed
+			assert.True(t, g(r1, r1, r1))
+		})
+	}
+}
+
+func TestMergeGuards(t *testing.T) {
+	var (
+		r = []Rule{
+			{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill"}},
+			{pre: []string{"i want to"}, root: []string{"pay"}, suf: []string{"now"}},
+			{pre: []string{"please"}, root: []string{"pay"}, suf: []string{"my invoice"}},
+		}
+		// rejects any merge adding a third prefix
+		twoPrefixes MergeGuard = func(r1, r2, merged Rule) bool { return len(merged.pre) <= 2 }
+		want                   = []Rule{
+			{pre: []string{"can i", "i want to"}, root: []string{"pay"}, suf: []string{"my bill", "now"}, isPublic: true},
+			{pre: []string{"please"}, root: []string{"pay"}, suf: []string{"my invoice"}},
+		}
+	)
+
+	assert.Equal(t, want, MergeR(slices.Clone(r), LiteralEqual(nilLogger), nilLogger, twoPrefixes))
+	assert.Equal(t, want, HashMerge("R")(slices.Clone(r), LiteralEqual(nilLogger), nilLogger, twoPrefixes))
+	assert.Equal(t, want, ClusterMerge("R", "single", NoBlocking)(slices.Clone(r), LiteralEqual(nilLogger), nilLogger, twoPrefixes))
+	assert.Len(t, MergeR(slices.Clone(r), LiteralEqual(nilLogger), nilLogger), 1)
+}
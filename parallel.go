@@ -0,0 +1,45 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:40:22 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"sync"
+)
+
+// Applies f to each element of in using n worker goroutines
+// results are returned in the same order as in, so output is identical to a serial loop for any n
+func ParallelMap[T, R any](in []T, n int, f func(T) R) []R {
+	var (
+		out  = make([]R, len(in))
+		jobs = make(chan int)
+		wg   sync.WaitGroup
+	)
+
+	if n <= 1 || len(in) <= 1 {
+		for i := range in {
+			out[i] = f(in[i])
+		}
+		return out
+	}
+	n = min(n, len(in))
+
+	wg.Add(n)
+	for range n {
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				out[i] = f(in[i])
+			}
+		}()
+	}
+	for i := range in {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+
+	return out
+}
@@ -0,0 +1,80 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:40:22 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bufio"
+	"os"
+	"strings"
+	"testing"
+
+	"github.com/jdkato/prose/tag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParallelMap(t *testing.T) {
+	type args struct {
+		in []string
+		n  int
+	}
+	tests := []struct {
+		args args
+		want []string
+	}{
+		{args: args{in: []string{}, n: 4}, want: []string{}},
+		{args: args{in: []string{"a"}, n: 4}, want: []string{"A"}},
+		{args: args{in: []string{"a", "b", "c"}, n: 0}, want: []string{"A", "B", "C"}},
+		{args: args{in: []string{"a", "b", "c"}, n: 1}, want: []string{"A", "B", "C"}},
+		{args: args{in: []string{"a", "b", "c"}, n: 2}, want: []string{"A", "B", "C"}},
+		{args: args{in: []string{"a", "b", "c", "d", "e", "f", "g"}, n: 16}, want: []string{"A", "B", "C", "D", "E", "F", "G"}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, ParallelMap(tt.args.in, tt.args.n, strings.ToUpper))
+		})
+	}
+}
+
+func TestParallelCorpus(t *testing.T) {
+	type args struct {
+		f string
+		n int
+	}
+	tests := []struct {
+		args args
+	}{
+		{args: args{f: "./data/tests/test1.csv", n: 2}},
+		{args: args{f: "./data/tests/test2.csv", n: 4}},
+		{args: args{f: "./data/tests/test6.csv", n: 8}},
+		{args: args{f: "./data/tests/test9.csv", n: 3}},
+		{args: args{f: "./data/tests/test10.csv", n: 4}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			tk := NewWordTokenizer()
+			file, _ := os.Open(tt.args.f)
+			defer file.Close()
+			s := bufio.NewScanner(file)
+			tx := ReadTexts(s)
+			for i, t := range tx {
+				tx[i].text = tk.normalize(t.text)
+			}
+			mod := tag.NewPerceptronTagger()
+			tagger := NewSyntacticTagger(mod, tk)
+			for _, fn := range []TransitionSplitFunction{TokenSplit(tk), POSSplit(tagger), ConstituencySplit(tagger)} {
+				assert.Equal(t, CollectTransitions(tx, fn, 1), CollectTransitions(tx, fn, tt.args.n))
+			}
+			serialModel, parallelModel := NewTFIDFModel(tx, tk, "tf", 1), NewTFIDFModel(tx, tk, "tf", tt.args.n)
+			assert.Equal(t, serialModel.index, parallelModel.index)
+			assert.Equal(t, serialModel.df, parallelModel.df)
+			for _, q := range []float64{0.2, 0.5} {
+				serial := FilterTexts(append([]Text{}, tx...), tagger, q, 1)
+				parallel := FilterTexts(append([]Text{}, tx...), tagger, q, tt.args.n)
+				assert.Equal(t, serial, parallel)
+			}
+		})
+	}
+}
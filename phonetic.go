@@ -0,0 +1,310 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:58:32 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bufio"
+	"fmt"
+	"log"
+	"os"
+	"slices"
+	"strings"
+	"unicode"
+)
+
+// Helper function to keep only the ascii letters of a word, uppercased
+func letters(s string) []rune {
+	var out []rune
+
+	for _, r := range strings.ToUpper(s) {
+		if r >= 'A' && r <= 'Z' {
+			out = append(out, r)
+		}
+	}
+
+	return out
+}
+
+// Soundex digit for each letter, vowels and y separate runs of the same digit, h and w do not
+var soundexCodes = map[rune]rune{
+	'B': '1', 'F': '1', 'P': '1', 'V': '1',
+	'C': '2', 'G': '2', 'J': '2', 'K': '2', 'Q': '2', 'S': '2', 'X': '2', 'Z': '2',
+	'D': '3', 'T': '3',
+	'L': '4',
+	'M': '5', 'N': '5',
+	'R': '6',
+	'A': '0', 'E': '0', 'I': '0', 'O': '0', 'U': '0', 'Y': '0',
+}
+
+// Encodes a word with american soundex, returns "" for words without letters
+func Soundex(s string) string {
+	word := letters(s)
+	if len(word) == 0 {
+		return ""
+	}
+
+	var (
+		out  = []rune{word[0]}
+		prev = soundexCodes[word[0]]
+	)
+	for _, r := range word[1:] {
+		code, ok := soundexCodes[r]
+		if !ok {
+			continue
+		}
+		if code != '0' && code != prev {
+			out = append(out, code)
+		}
+		prev = code
+		if len(out) == 4 {
+			break
+		}
+	}
+	for len(out) < 4 {
+		out = append(out, '0')
+	}
+
+	return string(out)
+}
+
+// Helper function to check for vowels in metaphone encoding
+func isVowel(r rune) bool {
+	return strings.ContainsRune("AEIOU", r)
+}
+
+// Encodes a word with the original metaphone algorithm, returns "" for words without letters
+func Metaphone(s string) string {
+	word := letters(s)
+	if len(word) == 0 {
+		return ""
+	}
+
+	switch {
+	case len(word) > 1 && slices.Contains([]string{"AE", "GN", "KN", "PN", "WR"}, string(word[:2])):
+		word = word[1:]
+	case word[0] == 'X':
+		word[0] = 'S'
+	case len(word) > 1 && string(word[:2]) == "WH":
+		word = append([]rune{'W'}, word[2:]...)
+	}
+
+	var (
+		out strings.Builder
+		at  = func(i int) rune {
+			if i < 0 || i >= len(word) {
+				return 0
+			}
+			return word[i]
+		}
+		next = func(i int, s string) bool {
+			return strings.HasPrefix(string(word[min(i, len(word)):]), s)
+		}
+	)
+
+	for i, r := range word {
+		if r == at(i-1) && r != 'C' {
+			continue
+		}
+		switch r {
+		case 'A', 'E', 'I', 'O', 'U':
+			if i == 0 {
+				out.WriteRune(r)
+			}
+		case 'B':
+			if !(at(i-1) == 'M' && i == len(word)-1) {
+				out.WriteRune('B')
+			}
+		case 'C':
+			switch {
+			case next(i+1, "IA") || (at(i+1) == 'H' && at(i-1) != 'S'):
+				out.WriteRune('X')
+			case strings.ContainsRune("IEY", at(i+1)):
+				if at(i-1) != 'S' {
+					out.WriteRune('S')
+				}
+			default:
+				out.WriteRune('K')
+			}
+		case 'D':
+			if at(i+1) == 'G' && strings.ContainsRune("EIY", at(i+2)) {
+				out.WriteRune('J')
+			} else {
+				out.WriteRune('T')
+			}
+		case 'G':
+			switch {
+			case at(i+1) == 'H' && !isVowel(at(i+2)):
+			case at(i-1) == 'D' && strings.ContainsRune("EIY", at(i+1)):
+			case at(i+1) == 'N' && (i+2 == len(word) || (next(i+1, "NED") && i+4 == len(word))):
+			case strings.ContainsRune("IEY", at(i+1)) && at(i-1) != 'G':
+				out.WriteRune('J')
+			default:
+				out.WriteRune('K')
+			}
+		case 'H':
+			if isVowel(at(i+1)) && !strings.ContainsRune("CSPTG", at(i-1)) {
+				out.WriteRune('H')
+			}
+		case 'K':
+			if at(i-1) != 'C' {
+				out.WriteRune('K')
+			}
+		case 'P':
+			if at(i+1) == 'H' {
+				out.WriteRune('F')
+			} else {
+				out.WriteRune('P')
+			}
+		case 'Q':
+			out.WriteRune('K')
+		case 'S':
+			if at(i+1) == 'H' || next(i+1, "IO") || next(i+1, "IA") {
+				out.WriteRune('X')
+			} else {
+				out.WriteRune('S')
+			}
+		case 'T':
+			switch {
+			case next(i+1, "IA") || next(i+1, "IO"):
+				out.WriteRune('X')
+			case at(i+1) == 'H':
+				out.WriteRune('0')
+			case next(i+1, "CH"):
+			default:
+				out.WriteRune('T')
+			}
+		case 'V':
+			out.WriteRune('F')
+		case 'W', 'Y':
+			if isVowel(at(i + 1)) {
+				out.WriteRune(r)
+			}
+		case 'X':
+			out.WriteString("KS")
+		case 'Z':
+			out.WriteRune('S')
+		default:
+			out.WriteRune(r)
+		}
+	}
+
+	return out.String()
+}
+
+// Pronunciations of words as sequences of phonemes, as found in CMUdict
+type Pronunciations map[string][]string
+
+// Reads the first pronunciation of each word from a CMUdict format file, removing stress markers
+func ReadPronunciations(p string) (Pronunciations, error) {
+	pron := Pronunciations{}
+
+	f, err := os.Open(p)
+	if err != nil {
+		return pron, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, ";;;") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		word := strings.ToLower(fields[0])
+		// alternate pronunciations are listed as word(2), word(3), ...
+		if strings.HasSuffix(word, ")") {
+			continue
+		}
+		if _, ok := pron[word]; ok {
+			continue
+		}
+		for i := range fields[1:] {
+			fields[i+1] = strings.TrimRightFunc(fields[i+1], unicode.IsDigit)
+		}
+		pron[word] = fields[1:]
+	}
+
+	return pron, scanner.Err()
+}
+
+// Converts a string to a sequence of phonemes, out of vocabulary words are kept as a single literal symbol
+func (p Pronunciations) phonemes(s string) []string {
+	var out []string
+
+	for _, w := range strings.Fields(strings.ToLower(s)) {
+		ph, ok := p[w]
+		if !ok {
+			ph, ok = p[strings.TrimFunc(w, func(r rune) bool { return !unicode.IsLetter(r) && r != '\'' })]
+		}
+		if !ok {
+			out = append(out, fmt.Sprintf("<%s>", w))
+			continue
+		}
+		out = append(out, ph...)
+	}
+
+	return out
+}
+
+// Helper function to encode each word of an expression group
+func encodeWords(e []string, encode func(string) string) []string {
+	var out []string
+
+	for _, w := range strings.Fields(strings.Join(e, " ")) {
+		code := encode(w)
+		if code == "" {
+			code = w
+		}
+		out = append(out, code)
+	}
+
+	return out
+}
+
+// Compares expressions for matching sequences of soundex codes
+func SoundexEqual(l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		s1 := strings.Join(encodeWords(e1, Soundex), " ")
+		s2 := strings.Join(encodeWords(e2, Soundex), " ")
+		if s1 == s2 {
+			l.Printf("equality function %s matched %v and %v, encoding %v\n", "SoundexEqual", e1, e2, s1)
+			return true
+		}
+		return false
+	}
+}
+
+// Compares expressions for matching sequences of metaphone codes
+func MetaphoneEqual(l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		s1 := strings.Join(encodeWords(e1, Metaphone), " ")
+		s2 := strings.Join(encodeWords(e2, Metaphone), " ")
+		if s1 == s2 {
+			l.Printf("equality function %s matched %v and %v, encoding %v\n", "MetaphoneEqual", e1, e2, s1)
+			return true
+		}
+		return false
+	}
+}
+
+// Compares expression similarity via levenshtein distance between phoneme sequences from a pronunciation dictionary
+func PhonemeThreshold(t float64, p Pronunciations, l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		ph1 := p.phonemes(strings.Join(e1, " "))
+		ph2 := p.phonemes(strings.Join(e2, " "))
+		sim := TokenLevenshtein(ph1, ph2)
+		if sim >= t {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "PhonemeThreshold", e1, e2, t, sim)
+			similarities.record("PhonemeThreshold", e1, e2, t, sim)
+			return true
+		}
+		return false
+	}
+}
@@ -0,0 +1,148 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:58:32 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSoundex(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		args args
+		want string
+	}{
+		{args: args{s: ""}, want: ""},
+		{args: args{s: "123"}, want: ""},
+		{args: args{s: "A"}, want: "A000"},
+		{args: args{s: "Robert"}, want: "R163"},
+		{args: args{s: "Rupert"}, want: "R163"},
+		{args: args{s: "Ashcraft"}, want: "A261"},
+		{args: args{s: "Tymczak"}, want: "T522"},
+		{args: args{s: "Pfister"}, want: "P236"},
+		{args: args{s: "there"}, want: "T600"},
+		{args: args{s: "their"}, want: "T600"},
+		{args: args{s: "four,"}, want: "F600"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, Soundex(tt.args.s))
+		})
+	}
+}
+
+func TestMetaphone(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		args args
+		want string
+	}{
+		{args: args{s: ""}, want: ""},
+		{args: args{s: "A"}, want: "A"},
+		{args: args{s: "there"}, want: "0R"},
+		{args: args{s: "their"}, want: "0R"},
+		{args: args{s: "for"}, want: "FR"},
+		{args: args{s: "Four"}, want: "FR"},
+		{args: args{s: "knight"}, want: "NT"},
+		{args: args{s: "night"}, want: "NT"},
+		{args: args{s: "phone"}, want: "FN"},
+		{args: args{s: "write"}, want: "RT"},
+		{args: args{s: "white"}, want: "WT"},
+		{args: args{s: "xylophone"}, want: "SLFN"},
+		{args: args{s: "science"}, want: "SNS"},
+		{args: args{s: "school"}, want: "SKL"},
+		{args: args{s: "judge"}, want: "JJ"},
+		{args: args{s: "gnome"}, want: "NM"},
+		{args: args{s: "thumb"}, want: "0M"},
+		{args: args{s: "nation"}, want: "NXN"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, Metaphone(tt.args.s))
+		})
+	}
+}
+
+func TestReadPronunciations(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		args      args
+		want      Pronunciations
+		assertion assert.ErrorAssertionFunc
+	}{
+		{args: args{p: ""}, want: Pronunciations{}, assertion: assert.Error},
+		{args: args{p: "./data/tests/cmudict1.txt"}, want: Pronunciations{
+			"for": {"F", "AO", "R"}, "four": {"F", "AO", "R"}, "their": {"DH", "EH", "R"}, "there": {"DH", "EH", "R"},
+			"two": {"T", "UW"}, "to": {"T", "UW"}, "too": {"T", "UW"}, "send": {"S", "EH", "N", "D"}, "sent": {"S", "EH", "N", "T"}, "me": {"M", "IY"},
+		}, assertion: assert.NoError},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := ReadPronunciations(tt.args.p)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestPronunciations_phonemes(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		args args
+		want []string
+	}{
+		{args: args{s: ""}, want: nil},
+		{args: args{s: "Send me"}, want: []string{"S", "EH", "N", "D", "M", "IY"}},
+		{args: args{s: "send it, too"}, want: []string{"S", "EH", "N", "D", "<it,>", "T", "UW"}},
+	}
+	pron, err := ReadPronunciations("./data/tests/cmudict1.txt")
+	assert.NoError(t, err)
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, pron.phonemes(tt.args.s))
+		})
+	}
+}
+
+func TestPhoneticEqual(t *testing.T) {
+	type args struct {
+		e1 []string
+		e2 []string
+	}
+	tests := []struct {
+		args      args
+		soundex   bool
+		metaphone bool
+		phoneme   bool
+	}{
+		{args: args{e1: []string{"over there"}, e2: []string{"over their"}}, soundex: true, metaphone: true, phoneme: true},
+		{args: args{e1: []string{"for", "me"}, e2: []string{"four me"}}, soundex: true, metaphone: true, phoneme: true},
+		{args: args{e1: []string{"two"}, e2: []string{"too"}}, soundex: true, metaphone: false, phoneme: true},
+		{args: args{e1: []string{"send me"}, e2: []string{"sent me"}}, soundex: true, metaphone: true, phoneme: true},
+		{args: args{e1: []string{"send"}, e2: []string{"send me"}}, soundex: false, metaphone: false, phoneme: false},
+		{args: args{e1: []string{"123"}, e2: []string{"123"}}, soundex: true, metaphone: true, phoneme: true},
+		{args: args{e1: []string{"123"}, e2: []string{"456"}}, soundex: false, metaphone: false, phoneme: false},
+	}
+	pron, err := ReadPronunciations("./data/tests/cmudict1.txt")
+	assert.NoError(t, err)
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.soundex, SoundexEqual(nilLogger)(tt.args.e1, tt.args.e2))
+			assert.Equal(t, tt.metaphone, MetaphoneEqual(nilLogger)(tt.args.e1, tt.args.e2))
+			assert.Equal(t, tt.phoneme, PhonemeThreshold(0.8, pron, nilLogger)(tt.args.e1, tt.args.e2))
+		})
+	}
+}
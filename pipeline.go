@@ -0,0 +1,291 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:52:35 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"slices"
+	"strings"
+
+	"github.com/urfave/cli/v3"
+	"gopkg.in/yaml.v3"
+)
+
+// Stages of a pipeline in the order they may be run, export stages may be run at any point after chunking
+var pipelineStages = []string{"filter", "chunk", "merge", "misc", "factor", "synonyms", "export"}
+
+// Options used to set up the equality function of merge stages
+var equalityOptions = []string{"merge", "sim", "ngram", "ngramMin", "ngramMax", "vectors", "sif", "endpoint", "embedModel", "embedBatch", "embedCache", "wordnet", "wnSenses", "cmudict", "costs", "lemma", "weighting", "lemmaVocab"}
+
+// Options which may be set for a single stage, all other options are set for the whole pipeline
+var stageOptions = map[string][]string{
+	"filter":   {"filter"},
+	"chunk":    {"chunk", "prob"},
+	"merge":    slices.Concat(equalityOptions, []string{"cluster", "block"}),
+	"misc":     {},
+	"factor":   {"factorN", "conFactor"},
+	"synonyms": {"synFile", "wnSyn", "wnDepth", "wnSenses"},
+	"export":   {"outFile", "main", "provenance", "provenanceFile"},
+}
+
+// One stage of a pipeline, with options named as cli flags which only apply to this stage
+// slots lists the combinations of matched slots merged by merge stages, in order
+type Stage struct {
+	Stage   string         `yaml:"stage"`
+	Slots   []string       `yaml:"slots"`
+	Options map[string]any `yaml:",inline"`
+}
+
+// Ordered stages building a grammar from a corpus, with options named as cli flags which apply to every stage
+type Pipeline struct {
+	Options map[string]any `yaml:"options"`
+	Stages  []Stage        `yaml:"stages"`
+	// pipeline as read, recorded in the header of exported grammars
+	spec string
+}
+
+// Reads a pipeline from a yaml or json file
+func ReadPipeline(p string) (Pipeline, error) {
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return Pipeline{}, fmt.Errorf("in ReadPipeline():\n%+w", err)
+	}
+	out, err := ParsePipeline(b)
+	if err != nil {
+		return out, fmt.Errorf("in ReadPipeline():\n%+w", err)
+	}
+
+	return out, nil
+}
+
+// Parses and checks a pipeline, json being read as yaml
+// a chunk stage is added ahead of the first stage needing rules and an export stage is added at the end, if not already present
+// merge stages without slots merge all combinations of slots, in the order used by the other commands
+func ParsePipeline(b []byte) (Pipeline, error) {
+	var (
+		out  Pipeline
+		spec any
+	)
+
+	dec := yaml.NewDecoder(bytes.NewReader(b))
+	dec.KnownFields(true)
+	err := dec.Decode(&out)
+	if err != nil {
+		return out, fmt.Errorf("in ParsePipeline():\n%+w", err)
+	}
+	err = yaml.Unmarshal(b, &spec)
+	if err != nil {
+		return out, fmt.Errorf("in ParsePipeline():\n%+w", err)
+	}
+	j, err := json.Marshal(spec)
+	if err != nil {
+		return out, fmt.Errorf("in ParsePipeline():\n%+w", err)
+	}
+	out.spec = string(j)
+
+	if !slices.ContainsFunc(out.Stages, func(s Stage) bool { return s.Stage == "chunk" }) {
+		i := slices.IndexFunc(out.Stages, func(s Stage) bool { return s.Stage != "filter" })
+		if i == -1 {
+			i = len(out.Stages)
+		}
+		out.Stages = slices.Insert(out.Stages, i, Stage{Stage: "chunk"})
+	}
+	if out.Stages[len(out.Stages)-1].Stage != "export" {
+		out.Stages = append(out.Stages, Stage{Stage: "export"})
+	}
+
+	var prev string
+	for i, s := range out.Stages {
+		switch {
+		case !slices.Contains(pipelineStages, s.Stage):
+			return out, fmt.Errorf("in ParsePipeline():\n%+w", fmt.Errorf("stage %v is not one of %v", s.Stage, pipelineStages))
+		case s.Stage == "export" && (prev == "" || prev == "filter"):
+			return out, fmt.Errorf("in ParsePipeline():\n%+w", fmt.Errorf("export stage can not be run before chunk stage"))
+		case s.Stage == "export":
+		case slices.Index(pipelineStages, s.Stage) < slices.Index(pipelineStages, prev), s.Stage == "chunk" && prev == "chunk":
+			return out, fmt.Errorf("in ParsePipeline():\n%+w", fmt.Errorf("%v stage can not be run after %v stage", s.Stage, prev))
+		default:
+			prev = s.Stage
+		}
+		if len(s.Slots) != 0 && s.Stage != "merge" {
+			return out, fmt.Errorf("in ParsePipeline():\n%+w", fmt.Errorf("slots can only be set for merge stages"))
+		}
+		for _, slots := range s.Slots {
+			if !slices.Contains(mergeSlots, slots) {
+				return out, fmt.Errorf("in ParsePipeline():\n%+w", fmt.Errorf("slots %v is not one of %v", slots, mergeSlots))
+			}
+		}
+		for k := range s.Options {
+			if !slices.Contains(stageOptions[s.Stage], k) {
+				return out, fmt.Errorf("in ParsePipeline():\n%+w", fmt.Errorf("option %v can not be set for %v stages, options for %v stages are %v", k, s.Stage, s.Stage, stageOptions[s.Stage]))
+			}
+		}
+		if s.Stage == "merge" && len(s.Slots) == 0 {
+			out.Stages[i].Slots = mergeSlots
+		}
+	}
+
+	return out, nil
+}
+
+// Helper function to format an option value as a cli flag value, lists are joined with commas
+func optionValue(v any) string {
+	l, ok := v.([]any)
+	if !ok {
+		return fmt.Sprint(v)
+	}
+	var s []string
+	for i := range l {
+		s = append(s, fmt.Sprint(l[i]))
+	}
+
+	return strings.Join(s, ",")
+}
+
+// Sets cli flags from pipeline options, returning the previous value of each flag set
+func setOptions(cmd *cli.Command, o map[string]any) (map[string]string, error) {
+	prev := make(map[string]string)
+
+	for _, k := range slices.Sorted(maps.Keys(o)) {
+		prev[k] = fmt.Sprint(cmd.Value(k))
+		err := cmd.Set(k, optionValue(o[k]))
+		if err != nil {
+			return prev, fmt.Errorf("in setOptions(%v):\n%+w", k, err)
+		}
+	}
+
+	return prev, nil
+}
+
+// Restores cli flags to the values they held before a stage
+// empty defaults of file flags fail validation, but are restored before being validated
+func restoreOptions(cmd *cli.Command, prev map[string]string) {
+	for k, v := range prev {
+		cmd.Set(k, v)
+	}
+}
+
+// Runs each stage of the pipeline on the corpus, exporting the grammar at each export stage
+// merge guards and constraints are set up once texts are chunked, so that novel production budgets and held-out utterances span all merge stages
+func (p *Pipeline) Run(cmd *cli.Command, l *log.Logger) error {
+	var (
+		texts    []Text
+		rules    []Rule
+		exported []Rule
+		con      *Constraints
+		neg      *Negatives
+		held     *HeldOut
+		guards   map[string][]MergeGuard
+		ided     bool
+	)
+
+	texts, err := readInfile(cmd)
+	if err != nil {
+		return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+	}
+	for _, s := range p.Stages {
+		prev, err := setOptions(cmd, s.Options)
+		if err != nil {
+			return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+		}
+		l.Printf("PIPELINE: running %v stage with options %v\n", s.Stage, s.Options)
+
+		switch s.Stage {
+		case "filter":
+			tagger, err := setTagger(cmd)
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+			texts = FilterTexts(texts, tagger, cmd.Float64("filter"), cmd.Int("workers"))
+		case "chunk":
+			texts, held = setHeldOut(cmd, texts)
+			rules, err = applyChunking(texts, cmd)
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+			neg, err = setNegatives(cmd, texts)
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+			con, err = setConstraints(cmd, texts)
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+			guards = setMergeGuards(cmd, rules, con, neg, held, l)
+		case "misc":
+			// the misc merge does not compare rules, so no merge strategy is set up
+			rules = MergeMisc(rules, LiteralEqual(l), l, con.Guard(l))
+		case "merge":
+			// cached results only hold for the equality function of one stage, hit and miss counts are kept for the whole run
+			equalityCache.clear()
+			eqfunc, err := setMerge(cmd, texts, rules)
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+			mergefuncs, err := setMergeFunctions(cmd, guards)
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+			for _, slots := range s.Slots {
+				rules = mergefuncs[slots](rules, eqfunc, l)
+			}
+		case "factor", "synonyms":
+			// ids are set once, as factored rules are referenced by name
+			if !ided {
+				rules = SetIDs(rules)
+				ided = true
+			}
+			var facfunc FactorFunction
+			if s.Stage == "synonyms" {
+				// synonyms are collected from the texts as they stand at this stage
+				facfunc, err = setSynonyms(cmd, texts)
+			} else {
+				facfunc, err = setFactor(cmd)
+			}
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+			rules = facfunc(rules, con.Guard(l), neg.Guard(l))
+		case "export":
+			exported = []Rule{}
+			for i := range rules {
+				exported = append(exported, rules[i].clone())
+			}
+			if !ided {
+				exported = SetIDs(exported)
+			}
+			exported = append(exported, ClassRules(texts)...)
+			err = neg.Check(exported, l)
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+			err = checkEndpoint()
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+			g := Grammar{Rules: exported, Tags: CollectWritten(texts), Pipeline: p.spec}
+			err = g.write(cmd)
+			if err != nil {
+				return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+			}
+		}
+		restoreOptions(cmd, prev)
+	}
+	logCaches(l)
+	if held.Len() != 0 {
+		err = held.WriteReport(cmd.String("heldOutReport"), exported, l)
+		if err != nil {
+			return fmt.Errorf("in Pipeline.Run():\n%+w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,92 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:52:35 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestReadPipeline(t *testing.T) {
+	got, err := ReadPipeline("./data/tests/pipeline1.yaml")
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]any{"classes": true}, got.Options)
+	assert.Equal(t, []Stage{
+		{Stage: "chunk"},
+		{Stage: "merge", Slots: []string{"PR", "PS", "RS"}},
+		{Stage: "merge", Slots: []string{"P", "R", "S"}, Options: map[string]any{"merge": "charDistance", "sim": 0.9}},
+		{Stage: "misc"},
+		{Stage: "factor", Options: map[string]any{"factorN": 2}},
+		{Stage: "export", Options: map[string]any{"outFile": "grammar.jsgf"}},
+	}, got.Stages)
+	assert.Contains(t, got.spec, `{"merge":"charDistance","sim":0.9,"slots":["P","R","S"],"stage":"merge"}`)
+
+	_, err = ReadPipeline("./data/tests/missing.yaml")
+	assert.NotNil(t, err)
+}
+
+func TestParsePipeline(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []string
+		err  string
+	}{
+		{s: "stages: []", want: []string{"chunk", "export"}},
+		{s: `{"stages": [{"stage": "filter", "filter": 0.1}, {"stage": "merge"}]}`, want: []string{"filter", "chunk", "merge", "export"}},
+		{s: "stages:\n  - stage: merge\n  - stage: export\n  - stage: factor\n", want: []string{"chunk", "merge", "export", "factor", "export"}},
+		{s: "stages:\n  - stage: expand\n", err: "stage expand is not one of"},
+		{s: "stages:\n  - stage: factor\n  - stage: merge\n", err: "merge stage can not be run after factor stage"},
+		{s: "stages:\n  - stage: chunk\n  - stage: chunk\n", err: "chunk stage can not be run after chunk stage"},
+		{s: "stages:\n  - stage: export\n  - stage: chunk\n", err: "export stage can not be run before chunk stage"},
+		{s: "stages:\n  - stage: merge\n    slots: [PRS]\n", err: "slots PRS is not one of"},
+		{s: "stages:\n  - stage: factor\n    slots: [P]\n", err: "slots can only be set for merge stages"},
+		{s: "stages:\n  - stage: merge\n    factorN: 2\n", err: "option factorN can not be set for merge stages"},
+		{s: "stages:\n  - stage: misc\n    merge: vectors\n", err: "option merge can not be set for misc stages"},
+		{s: "stage: merge\n", err: "field stage not found"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := ParsePipeline([]byte(tt.s))
+			if tt.err != "" {
+				assert.ErrorContains(t, err, tt.err)
+				return
+			}
+			assert.Nil(t, err)
+			var stages []string
+			for _, s := range got.Stages {
+				stages = append(stages, s.Stage)
+				if s.Stage == "merge" {
+					assert.Equal(t, mergeSlots, s.Slots)
+				}
+			}
+			assert.Equal(t, tt.want, stages)
+		})
+	}
+}
+
+func TestSetOptions(t *testing.T) {
+	cmd := &cli.Command{Flags: []cli.Flag{
+		&cli.FloatFlag{Name: "sim", Value: 0.8, Validator: similarity.Validator},
+		&cli.StringFlag{Name: "wnSyn"},
+		&cli.StringFlag{Name: "synFile", Validator: synFile.Validator},
+	}}
+
+	prev, err := setOptions(cmd, map[string]any{"sim": 0.5, "wnSyn": []any{"noun", "verb"}, "synFile": "./data/tests/syn1.json"})
+	assert.Nil(t, err)
+	assert.Equal(t, map[string]string{"sim": "0.8", "wnSyn": "", "synFile": ""}, prev)
+	assert.Equal(t, 0.5, cmd.Float64("sim"))
+	assert.Equal(t, "noun,verb", cmd.String("wnSyn"))
+	restoreOptions(cmd, prev)
+	assert.Equal(t, 0.8, cmd.Float64("sim"))
+	assert.Equal(t, "", cmd.String("synFile"))
+
+	_, err = setOptions(cmd, map[string]any{"sim": 2})
+	assert.ErrorContains(t, err, "similarity must be between 0 and 1")
+	_, err = setOptions(cmd, map[string]any{"unknown": 1})
+	assert.NotNil(t, err)
+}
@@ -0,0 +1,366 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 03:17:35 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bufio"
+	"cmp"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/urfave/cli/v3"
+)
+
+// Columns of csv plans, csv plans are preceded by a comment line holding the command, corpus, and options of the plan as json
+var planColumns = []string{"id", "step", "slots", "rule1", "rule2", "merged", "lines1", "lines2", "equality", "score", "similarity", "novel", "decision"}
+
+// One merge proposed by a merge function, with the decision of a reviewer
+// lines holds the corpus lines of each rule, and score is the similarity of the least similar matched slot, slots matched without a similarity score 1
+type PlannedMerge struct {
+	ID         int       `json:"id"`
+	Step       string    `json:"step"`
+	Slots      string    `json:"slots"`
+	Rules      [2]string `json:"rules"`
+	Merged     string    `json:"merged"`
+	Lines      [2][]int  `json:"lines"`
+	Equality   string    `json:"equality"`
+	Score      float64   `json:"score"`
+	Similarity []string  `json:"similarity,omitempty"`
+	Novel      int       `json:"novel"`
+	Decision   string    `json:"decision"`
+}
+
+// Command, corpus, and options a plan was made with, used to build the grammar again
+type PlanHeader struct {
+	Command string            `json:"command"`
+	InFile  string            `json:"inFile"`
+	Options map[string]string `json:"options"`
+}
+
+// Merges proposed while building a grammar
+type MergePlan struct {
+	mu sync.Mutex
+	PlanHeader
+	Merges []PlannedMerge `json:"merges"`
+	// corpus lines joined by the proposed merges of each step, as a disjoint set keyed by line
+	joined map[string]map[int]int
+	// rejected merges of each step, as sets of the corpus lines of each rule
+	rejected map[string][][2]map[int]bool
+	// counts of merges made and rejected while applying the plan
+	made, refused, unplanned int
+}
+
+// Starts an empty plan for the command, recording the options set on the command line
+func NewMergePlan(cmd *cli.Command) *MergePlan {
+	p := MergePlan{PlanHeader: PlanHeader{Command: cmd.Name, InFile: cmd.String("inFile"), Options: make(map[string]string)}, Merges: []PlannedMerge{}}
+
+	for _, f := range cmd.Flags {
+		n := f.Names()[0]
+		if slices.Contains([]string{"help", "inFile", "plan"}, n) || !cmd.IsSet(n) {
+			continue
+		}
+		p.Options[n] = fmt.Sprint(cmd.Value(n))
+	}
+
+	return &p
+}
+
+// Helper function to find the representative of a corpus line among the lines joined by the merges of step
+func (p *MergePlan) find(step string, n int) int {
+	j := p.joined[step]
+	if _, ok := j[n]; !ok {
+		return n
+	}
+	for j[n] != n {
+		j[n] = j[j[n]]
+		n = j[n]
+	}
+
+	return n
+}
+
+// Helper function to check the decisions of a plan, and index the corpus lines joined and rejected by the merges of each step
+func (p *MergePlan) index() error {
+	p.joined = make(map[string]map[int]int)
+	p.rejected = make(map[string][][2]map[int]bool)
+
+	for i, m := range p.Merges {
+		d := strings.ToLower(strings.TrimSpace(m.Decision))
+		if d != "accept" && d != "reject" {
+			return fmt.Errorf("decision %v of merge %v is not one of accept, reject", m.Decision, m.ID)
+		}
+		p.Merges[i].Decision = d
+
+		lines := slices.Concat(m.Lines[0], m.Lines[1])
+		if _, ok := p.joined[m.Step]; !ok {
+			p.joined[m.Step] = make(map[int]int)
+		}
+		for _, n := range lines {
+			if _, ok := p.joined[m.Step][n]; !ok {
+				p.joined[m.Step][n] = n
+			}
+			p.joined[m.Step][p.find(m.Step, n)] = p.find(m.Step, lines[0])
+		}
+		if d == "reject" {
+			var sets [2]map[int]bool
+			for k := range sets {
+				sets[k] = make(map[int]bool)
+				for _, n := range m.Lines[k] {
+					sets[k][n] = true
+				}
+			}
+			p.rejected[m.Step] = append(p.rejected[m.Step], sets)
+		}
+	}
+
+	return nil
+}
+
+// Guard recording each merge of step as a proposed merge, accepted unless a reviewer rejects it
+// the guard accepts every merge, and should come after all other guards so that only merges accepted by them are proposed
+// slots lists the expression groups matched by the step, whose recorded similarities are kept with the merge
+func (p *MergePlan) Propose(step string, equality string, slots string, l *log.Logger) MergeGuard {
+	return func(r1, r2, merged Rule) bool {
+		m := PlannedMerge{
+			Step:     step,
+			Slots:    slots,
+			Rules:    [2]string{printRule(r1), printRule(r2)},
+			Merged:   printRule(merged),
+			Lines:    [2][]int{r1.lines, r2.lines},
+			Equality: equality,
+			Score:    1,
+			Novel:    NovelProductions(r1, r2, merged),
+			Decision: "accept",
+		}
+		matches := similarities.lookupSlots(r1, r2, slots)
+		for _, c := range slots {
+			if len(matches[c]) == 0 {
+				continue
+			}
+			m.Score = min(m.Score, slices.MaxFunc(matches[c], func(a, b Match) int { return cmp.Compare(a.Similarity, b.Similarity) }).Similarity)
+			for _, s := range matches[c] {
+				m.Similarity = append(m.Similarity, s.String())
+			}
+		}
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		m.ID = len(p.Merges)
+		p.Merges = append(p.Merges, m)
+		l.Printf("PLAN: proposed merge %v of %v and %v by step %s with score %v\n", m.ID, r1, r2, step, m.Score)
+
+		return true
+	}
+}
+
+// Guard accepting merges of step whose corpus lines were all joined by the merges proposed for step, unless a rejected merge held lines of each rule
+// merges are matched by corpus lines rather than by rules, so that rules left apart by a rejected merge can still be merged with the other rules proposed
+// the guard should come before all other guards, so that the merges they record are the merges made
+func (p *MergePlan) Guard(step string, l *log.Logger) MergeGuard {
+	overlaps := func(lines []int, set map[int]bool) bool {
+		return slices.ContainsFunc(lines, func(n int) bool { return set[n] })
+	}
+
+	return func(r1, r2, merged Rule) bool {
+		lines := slices.Concat(r1.lines, r2.lines)
+
+		p.mu.Lock()
+		defer p.mu.Unlock()
+		if len(lines) == 0 || slices.ContainsFunc(lines, func(n int) bool { return p.find(step, n) != p.find(step, lines[0]) }) {
+			p.unplanned++
+			l.Printf("merge guard %s rejected merging %v and %v, merge is not in the plan\n", "Plan", r1, r2)
+			return false
+		}
+		for _, r := range p.rejected[step] {
+			if (overlaps(r1.lines, r[0]) && overlaps(r2.lines, r[1])) || (overlaps(r1.lines, r[1]) && overlaps(r2.lines, r[0])) {
+				p.refused++
+				l.Printf("merge guard %s rejected merging %v and %v, merge was rejected in the plan\n", "Plan", r1, r2)
+				return false
+			}
+		}
+		p.made++
+
+		return true
+	}
+}
+
+// Logs how many merges were made and rejected while applying the plan
+func (p *MergePlan) Log(l *log.Logger) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l.Printf("REPORT: applied plan of %v proposed merges, %v merges made, %v rejected in the plan, %v not in the plan\n", len(p.Merges), p.made, p.refused, p.unplanned)
+}
+
+// Reads a plan from a json or csv file, checking that each merge is marked accept or reject
+func ReadPlan(p string) (*MergePlan, error) {
+	var (
+		out MergePlan
+		err error
+	)
+
+	b, err := os.ReadFile(p)
+	if err != nil {
+		return &out, fmt.Errorf("in ReadPlan():\n%+w", err)
+	}
+	switch filepath.Ext(p) {
+	case ".csv":
+		err = out.parseCSV(string(b))
+	default:
+		err = json.Unmarshal(b, &out)
+	}
+	if err != nil {
+		return &out, fmt.Errorf("in ReadPlan():\n%+w", err)
+	}
+	err = out.index()
+	if err != nil {
+		return &out, fmt.Errorf("in ReadPlan():\n%+w", err)
+	}
+
+	return &out, nil
+}
+
+// Helper function to parse space separated corpus lines
+func parseLines(s string) ([]int, error) {
+	var out []int
+
+	for _, f := range strings.Fields(s) {
+		n, err := strconv.Atoi(f)
+		if err != nil {
+			return out, err
+		}
+		out = append(out, n)
+	}
+
+	return out, nil
+}
+
+// Helper function to parse a csv plan, columns are looked up by name and only step, lines1, lines2, and decision are required
+func (p *MergePlan) parseCSV(s string) error {
+	header, body, _ := strings.Cut(s, "\n")
+	if !strings.HasPrefix(header, "#") {
+		return fmt.Errorf("csv plan does not start with a comment line holding the plan options")
+	}
+	err := json.Unmarshal([]byte(strings.TrimPrefix(header, "#")), &p.PlanHeader)
+	if err != nil {
+		return err
+	}
+
+	records, err := csv.NewReader(strings.NewReader(body)).ReadAll()
+	if err != nil {
+		return err
+	}
+	if len(records) == 0 {
+		return fmt.Errorf("csv plan has no header row")
+	}
+	cols := make(map[string]int)
+	for i, c := range records[0] {
+		cols[strings.TrimSpace(c)] = i
+	}
+	for _, c := range []string{"step", "lines1", "lines2", "decision"} {
+		if _, ok := cols[c]; !ok {
+			return fmt.Errorf("csv plan has no %v column", c)
+		}
+	}
+
+	p.Merges = []PlannedMerge{}
+	for i, r := range records[1:] {
+		get := func(c string) string {
+			if j, ok := cols[c]; ok {
+				return r[j]
+			}
+			return ""
+		}
+		m := PlannedMerge{ID: i, Step: get("step"), Slots: get("slots"), Rules: [2]string{get("rule1"), get("rule2")}, Merged: get("merged"), Equality: get("equality"), Score: 1, Decision: get("decision")}
+		if s := get("id"); s != "" {
+			m.ID, err = strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+		}
+		if s := get("score"); s != "" {
+			m.Score, err = strconv.ParseFloat(s, 64)
+			if err != nil {
+				return err
+			}
+		}
+		if s := get("novel"); s != "" {
+			m.Novel, err = strconv.Atoi(s)
+			if err != nil {
+				return err
+			}
+		}
+		for k, c := range []string{"lines1", "lines2"} {
+			m.Lines[k], err = parseLines(get(c))
+			if err != nil {
+				return err
+			}
+		}
+		if s := get("similarity"); s != "" {
+			m.Similarity = strings.Split(s, "; ")
+		}
+		p.Merges = append(p.Merges, m)
+	}
+
+	return nil
+}
+
+// Writes the plan to a json or csv file, chosen by file extension
+func (p *MergePlan) Write(path string, l *log.Logger) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	l.Printf("REPORT: writing plan of %v proposed merges to %v\n", len(p.Merges), path)
+	file, err := os.Create(path)
+	if err != nil {
+		return fmt.Errorf("in MergePlan.Write():\n%+w", err)
+	}
+	defer file.Close()
+	buf := bufio.NewWriter(file)
+	// rules are written as printed, without escaping rule references
+	enc := json.NewEncoder(buf)
+	enc.SetEscapeHTML(false)
+	if filepath.Ext(path) != ".csv" {
+		enc.SetIndent("", "  ")
+		err = enc.Encode(p)
+		if err != nil {
+			return fmt.Errorf("in MergePlan.Write():\n%+w", err)
+		}
+		return buf.Flush()
+	}
+
+	buf.WriteString("#")
+	err = enc.Encode(p.PlanHeader)
+	if err != nil {
+		return fmt.Errorf("in MergePlan.Write():\n%+w", err)
+	}
+	w := csv.NewWriter(buf)
+	w.Write(planColumns)
+	for _, m := range p.Merges {
+		var lines [2][]string
+		for k := range lines {
+			for _, n := range m.Lines[k] {
+				lines[k] = append(lines[k], strconv.Itoa(n))
+			}
+		}
+		w.Write([]string{strconv.Itoa(m.ID), m.Step, m.Slots, m.Rules[0], m.Rules[1], m.Merged, strings.Join(lines[0], " "), strings.Join(lines[1], " "), m.Equality, fmt.Sprint(m.Score), strings.Join(m.Similarity, "; "), strconv.Itoa(m.Novel), m.Decision})
+	}
+	w.Flush()
+	err = w.Error()
+	if err != nil {
+		return fmt.Errorf("in MergePlan.Write():\n%+w", err)
+	}
+
+	return buf.Flush()
+}
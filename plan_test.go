@@ -0,0 +1,121 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 03:17:35 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/urfave/cli/v3"
+)
+
+func TestNewMergePlan(t *testing.T) {
+	cmd := &cli.Command{Name: "interpolate", Flags: []cli.Flag{
+		&cli.StringFlag{Name: "inFile"},
+		&cli.StringFlag{Name: "plan"},
+		&cli.StringFlag{Name: "merge", Value: "literal"},
+		&cli.FloatFlag{Name: "sim", Value: 0.8},
+	}}
+	cmd.Set("inFile", "example.csv")
+	cmd.Set("plan", "plan.json")
+	cmd.Set("sim", "0.5")
+
+	p := NewMergePlan(cmd)
+	assert.Equal(t, PlanHeader{Command: "interpolate", InFile: "example.csv", Options: map[string]string{"sim": "0.5"}}, p.PlanHeader)
+	assert.Empty(t, p.Merges)
+}
+
+func TestMergePlan_Propose(t *testing.T) {
+	var (
+		p  = &MergePlan{Merges: []PlannedMerge{}}
+		r1 = Rule{pre: []string{"can i"}, root: []string{"pay"}, suf: []string{"my bill"}, isPublic: true, lines: []int{1}}
+		r2 = Rule{pre: []string{"could i"}, root: []string{"pay"}, suf: []string{"my order"}, isPublic: true, lines: []int{2, 3}}
+		m  = Rule{pre: []string{"can i", "could i"}, root: []string{"pay"}, suf: []string{"my bill", "my order"}, isPublic: true, lines: []int{1, 2, 3}}
+	)
+	similarities = NewSimilarities()
+	defer func() { similarities = nil }()
+	similarities.record("CharacterLevenshteinThreshold", r1.pre, r2.pre, 0.5, 0.6)
+	similarities.record("TFIDFCosineThreshold", r1.pre, r2.pre, 0.5, 0.7)
+
+	assert.True(t, p.Propose("MergePR", "charDistance|tfidf", "PR", nilLogger)(r1, r2, m))
+	assert.True(t, p.Propose("MergeR", "literal", "R", nilLogger)(r1, r2, m))
+	assert.Equal(t, []PlannedMerge{
+		{
+			ID:         0,
+			Step:       "MergePR",
+			Slots:      "PR",
+			Rules:      [2]string{printRule(r1), printRule(r2)},
+			Merged:     printRule(m),
+			Lines:      [2][]int{{1}, {2, 3}},
+			Equality:   "charDistance|tfidf",
+			Score:      0.7,
+			Similarity: []string{"CharacterLevenshteinThreshold similarity 0.6, threshold 0.5", "TFIDFCosineThreshold similarity 0.7, threshold 0.5"},
+			Novel:      2,
+			Decision:   "accept",
+		},
+		{ID: 1, Step: "MergeR", Slots: "R", Rules: [2]string{printRule(r1), printRule(r2)}, Merged: printRule(m), Lines: [2][]int{{1}, {2, 3}}, Equality: "literal", Score: 1, Novel: 2, Decision: "accept"},
+	}, p.Merges)
+}
+
+func TestMergePlan_Guard(t *testing.T) {
+	rule := func(lines ...int) Rule {
+		return Rule{pre: []string{}, root: []string{"pay"}, suf: []string{}, isPublic: true, lines: lines}
+	}
+	p := &MergePlan{Merges: []PlannedMerge{
+		{Step: "MergeS", Lines: [2][]int{{1}, {2}}, Decision: "reject"},
+		{Step: "MergeS", Lines: [2][]int{{1, 2}, {3}}, Decision: " Accept"},
+		{Step: "MergeS", Lines: [2][]int{{1, 2, 3}, {4}}, Decision: "accept"},
+		{Step: "MergeP", Lines: [2][]int{{5}, {6}}, Decision: "accept"},
+	}}
+	assert.Nil(t, p.index())
+	g := p.Guard("MergeS", nilLogger)
+
+	assert.False(t, g(rule(1), rule(2), rule(1, 2)))
+	// rules left apart by the rejected merge are still merged with the other rules proposed
+	assert.True(t, g(rule(2), rule(3), rule(2, 3)))
+	assert.False(t, g(rule(1), rule(2, 3), rule(1, 2, 3)))
+	assert.True(t, g(rule(1), rule(4), rule(1, 4)))
+	// merges joining lines not joined in the plan, or proposed for other steps, are not in the plan
+	assert.False(t, g(rule(4), rule(5), rule(4, 5)))
+	assert.False(t, g(rule(5), rule(6), rule(5, 6)))
+	assert.True(t, p.Guard("MergeP", nilLogger)(rule(5), rule(6), rule(5, 6)))
+	assert.Equal(t, [3]int{3, 2, 2}, [3]int{p.made, p.refused, p.unplanned})
+
+	p = &MergePlan{Merges: []PlannedMerge{{ID: 3, Step: "MergeS", Decision: "maybe"}}}
+	assert.ErrorContains(t, p.index(), "decision maybe of merge 3 is not one of accept, reject")
+}
+
+func TestReadPlan(t *testing.T) {
+	var (
+		dir  = t.TempDir()
+		plan = &MergePlan{
+			PlanHeader: PlanHeader{Command: "interpolate", InFile: "example.csv", Options: map[string]string{"merge": "charDistance", "sim": "0.7"}},
+			Merges: []PlannedMerge{
+				{ID: 0, Step: "MergePR", Slots: "PR", Rules: [2]string{"public <can_i> = (can i) (pay, bill);", "public <can_i> = (can i) (view);"}, Merged: "public <can_i> = (can i) (pay, bill|view);", Lines: [2][]int{{1, 3}, {2}}, Equality: "charDistance", Score: 0.75, Similarity: []string{"CharacterLevenshteinThreshold similarity 0.75, threshold 0.7"}, Novel: 0, Decision: "accept"},
+				{ID: 1, Step: "MergeS", Slots: "S", Rules: [2]string{"public <pay> = (pay);", "public <view> = (view);"}, Merged: "public <pay> = (pay|view);", Lines: [2][]int{{4}, {5}}, Equality: "charDistance", Score: 1, Novel: 1, Decision: "reject"},
+			},
+		}
+	)
+
+	for _, f := range []string{"plan.json", "plan.csv"} {
+		p := filepath.Join(dir, f)
+		assert.Nil(t, plan.Write(p, nilLogger))
+		got, err := ReadPlan(p)
+		assert.Nil(t, err)
+		assert.Equal(t, plan.PlanHeader, got.PlanHeader)
+		assert.Equal(t, plan.Merges, got.Merges)
+	}
+	b, _ := os.ReadFile(filepath.Join(dir, "plan.csv"))
+	assert.Contains(t, string(b), "#{\"command\":\"interpolate\",\"inFile\":\"example.csv\",\"options\":{\"merge\":\"charDistance\",\"sim\":\"0.7\"}}\nid,step,slots,rule1,rule2,merged,lines1,lines2,equality,score,similarity,novel,decision\n0,MergePR,PR,\"public <can_i> = (can i) (pay, bill);\"")
+
+	os.WriteFile(filepath.Join(dir, "columns.csv"), []byte("#{}\nstep,lines1,decision\nMergeS,1,accept\n"), 0644)
+	_, err := ReadPlan(filepath.Join(dir, "columns.csv"))
+	assert.ErrorContains(t, err, "csv plan has no lines2 column")
+	_, err = ReadPlan(filepath.Join(dir, "missing.json"))
+	assert.NotNil(t, err)
+}
@@ -7,7 +7,6 @@ package main
 
 import (
 	"bufio"
-	"log"
 	"os"
 	"os/exec"
 	"slices"
@@ -20,7 +19,7 @@ import (
 )
 
 func TestGrammarProductions(t *testing.T) {
-	var mergers = []func(r []Rule, e EqualityFunction, l *log.Logger) []Rule{MergePR, MergePS, MergeRS, MergeMisc}
+	var mergers = []MergeFunction{MergePR, MergePS, MergeRS, MergeMisc}
 	var permutations [][]int
 	for i := range 4 {
 		permutations = append(permutations, combin.Permutations(4, i+1)...)
@@ -68,7 +67,7 @@ func TestGrammarProductions(t *testing.T) {
 			model := tag.NewPerceptronTagger()
 			tagger := NewSyntacticTagger(model, tk)
 			for _, fn := range []TransitionSplitFunction{TokenSplit(tk), POSSplit(tagger), ConstituencySplit(tagger)} {
-				tr := CollectTransitions(tx, fn)
+				tr := CollectTransitions(tx, fn, 1)
 				for i, t := range tx {
 					tokens := tk.tokenize(t.text)
 					tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -118,7 +117,7 @@ func TestGrammarProductions(t *testing.T) {
 }
 
 func TestGrammarMainProductions(t *testing.T) {
-	var mergers = []func(r []Rule, e EqualityFunction, l *log.Logger) []Rule{MergePR, MergePS, MergeRS, MergeMisc}
+	var mergers = []MergeFunction{MergePR, MergePS, MergeRS, MergeMisc}
 	var permutations [][]int
 	for i := range 4 {
 		permutations = append(permutations, combin.Permutations(4, i+1)...)
@@ -166,7 +165,7 @@ func TestGrammarMainProductions(t *testing.T) {
 			model := tag.NewPerceptronTagger()
 			tagger := NewSyntacticTagger(model, tk)
 			for _, fn := range []TransitionSplitFunction{TokenSplit(tk), POSSplit(tagger), ConstituencySplit(tagger)} {
-				tr := CollectTransitions(tx, fn)
+				tr := CollectTransitions(tx, fn, 1)
 				for i, t := range tx {
 					tokens := tk.tokenize(t.text)
 					tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
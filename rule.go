@@ -19,6 +19,10 @@ type Rule struct {
 	suf      []string
 	isPublic bool
 	id       int
+	// fixed rule name, used in place of the derived name when set
+	label string
+	// corpus lines of the texts the rule was built from
+	lines []int
 }
 
 // Checks if pre, root, and suf are empty slices or contain at least one non-empty string element
@@ -109,6 +113,10 @@ func (r *Rule) print(n string) string {
 func (r *Rule) name() string {
 	var b string
 
+	if r.label != "" {
+		return r.label
+	}
+
 	b = strings.Join(r.root, "_")
 	b = strings.ReplaceAll(b, " ", "_")
 	b = strings.ReplaceAll(b, "<", "")
@@ -120,6 +128,26 @@ func (r *Rule) name() string {
 	return fmt.Sprintf("%.*s", 20, b)
 }
 
+// Copies a rule, so that its expression groups can be modified in place
+func (r *Rule) clone() Rule {
+	c := *r
+	c.pre, c.root, c.suf = slices.Clone(r.pre), slices.Clone(r.root), slices.Clone(r.suf)
+
+	return c
+}
+
+// Collects the corpus lines of rules, sorted and without duplicates
+func unionLines(r ...Rule) []int {
+	var out []int
+
+	for i := range r {
+		out = append(out, r[i].lines...)
+	}
+	slices.Sort(out)
+
+	return slices.Compact(out)
+}
+
 func (r *Rule) sort() Rule {
 	slices.Sort(r.pre)
 	slices.Sort(r.root)
@@ -128,11 +156,23 @@ func (r *Rule) sort() Rule {
 }
 
 // Sorts rules and sets integer ids
+// class rule labels are reserved, so rules whose derived name would repeat a label are given an unused id instead
 func SetIDs(rules []Rule) []Rule {
 	slices.SortStableFunc(rules, func(i, j Rule) int { return strings.Compare(i.name(), j.name()) })
 
+	labels := make(map[string]bool)
 	for i := range rules {
 		rules[i].id = i
+		if rules[i].label != "" {
+			labels[rules[i].label] = true
+		}
+	}
+	next := len(rules)
+	for i := range rules {
+		if rules[i].label == "" && labels[rules[i].name()] {
+			rules[i].id = next
+			next++
+		}
 	}
 
 	return rules
@@ -184,7 +184,7 @@ func TestSetIDs(t *testing.T) {
 			for i, t := range tx {
 				tx[i].text = tk.normalize(t.text)
 			}
-			tr := CollectTransitions(tx, TokenSplit(tk))
+			tr := CollectTransitions(tx, TokenSplit(tk), 1)
 			for i, t := range tx {
 				tokens := tk.tokenize(t.text)
 				tx[i].chunk = TransitionChunk(tokens, tokens, tr, 0.1)
@@ -202,3 +202,36 @@ func TestSetIDs(t *testing.T) {
 		})
 	}
 }
+
+func TestSetIDs_labels(t *testing.T) {
+	rules := []Rule{
+		{pre: []string{""}, root: []string{"<money>"}, suf: []string{""}, isPublic: true},
+		{pre: []string{}, root: []string{"$300"}, suf: []string{}, label: "money"},
+		{pre: []string{""}, root: []string{"send <money>"}, suf: []string{""}, isPublic: true},
+	}
+
+	// the rule holding only a class reference would otherwise share the class rule name
+	res := SetIDs(rules)
+	names := []string{}
+	for i := range res {
+		names = append(names, res[i].name())
+	}
+	assert.Equal(t, []string{"money_3", "money", "send_money_2"}, names)
+}
+
+func Test_unionLines(t *testing.T) {
+	tests := []struct {
+		r    []Rule
+		want []int
+	}{
+		{r: []Rule{}, want: nil},
+		{r: []Rule{{}, {}}, want: nil},
+		{r: []Rule{{lines: []int{3}}, {}}, want: []int{3}},
+		{r: []Rule{{lines: []int{2, 7}}, {lines: []int{1, 7}}, {lines: []int{4}}}, want: []int{1, 2, 4, 7}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, unionLines(tt.r...))
+		})
+	}
+}
@@ -0,0 +1,324 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:47:33 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// Language specific words used to expand written forms (numbers, currency, times, abbreviations, symbols) to spoken forms
+type SpokenTable struct {
+	// number words for 0-19
+	Ones []string `json:"ones"`
+	// number words for 0, 10, 20, ... 90
+	Tens []string `json:"tens"`
+	// word for hundreds
+	Hundred string `json:"hundred"`
+	// words for each power of 1000, starting with 1000
+	Scales []string `json:"scales"`
+	// word used between the whole and fractional parts of decimals
+	Point string `json:"point"`
+	// ordinal forms of number words, numbers not listed here take OrdinalSuffix
+	Ordinals      map[string]string `json:"ordinals"`
+	OrdinalSuffix string            `json:"ordinalSuffix"`
+	// singular and plural currency names for each currency symbol
+	Currencies map[string][]string `json:"currencies"`
+	// singular and plural names of the fractional currency unit, and the word joining them to the whole amount
+	Cents []string `json:"cents"`
+	And   string   `json:"and"`
+	// spoken forms of am and pm, and the word used for minutes 1-9 (e.g. "oh" in ten oh five)
+	AM      string `json:"am"`
+	PM      string `json:"pm"`
+	Minutes string `json:"minutes"`
+	// spoken forms of abbreviations, matched case sensitively
+	Abbreviations map[string]string `json:"abbreviations"`
+	// spoken forms of symbols
+	Symbols map[string]string `json:"symbols"`
+	// word read for the dots of emails and hostnames, which are left as is if blank
+	Dot string `json:"dot"`
+}
+
+var EnglishSpoken = SpokenTable{
+	Ones:    []string{"zero", "one", "two", "three", "four", "five", "six", "seven", "eight", "nine", "ten", "eleven", "twelve", "thirteen", "fourteen", "fifteen", "sixteen", "seventeen", "eighteen", "nineteen"},
+	Tens:    []string{"", "ten", "twenty", "thirty", "forty", "fifty", "sixty", "seventy", "eighty", "ninety"},
+	Hundred: "hundred",
+	Scales:  []string{"thousand", "million", "billion"},
+	Point:   "point",
+	Ordinals: map[string]string{
+		"one": "first", "two": "second", "three": "third", "five": "fifth", "eight": "eighth", "nine": "ninth", "twelve": "twelfth",
+		"twenty": "twentieth", "thirty": "thirtieth", "forty": "fortieth", "fifty": "fiftieth", "sixty": "sixtieth", "seventy": "seventieth", "eighty": "eightieth", "ninety": "ninetieth",
+	},
+	OrdinalSuffix: "th",
+	Currencies:    map[string][]string{"$": {"dollar", "dollars"}, "€": {"euro", "euros"}, "£": {"pound", "pounds"}},
+	Cents:         []string{"cent", "cents"},
+	And:           "and",
+	AM:            "a m",
+	PM:            "p m",
+	Minutes:       "oh",
+	Abbreviations: map[string]string{
+		"Dr.": "doctor", "Mr.": "mister", "Mrs.": "missus", "Ms.": "miz", "St.": "street", "Ave.": "avenue", "Rd.": "road",
+		"Jr.": "junior", "Sr.": "senior", "etc.": "et cetera", "e.g.": "for example", "i.e.": "that is", "vs.": "versus",
+		"approx.": "approximately", "appt.": "appointment", "dept.": "department", "acct.": "account",
+	},
+	Symbols: map[string]string{"&": "and", "%": "percent", "@": "at", "+": "plus", "=": "equals", "#": "number"},
+	Dot:     "dot",
+}
+
+// Spoken form tables by language code
+var SpokenTables = map[string]SpokenTable{
+	"en": EnglishSpoken,
+}
+
+// Reads a user provided spoken form table from a json file
+func ReadSpokenTable(p string) (SpokenTable, error) {
+	var err error
+	tbl := SpokenTable{}
+
+	file, err := os.Open(p)
+	if err != nil {
+		return tbl, err
+	}
+	defer file.Close()
+	dec := json.NewDecoder(file)
+	err = dec.Decode(&tbl)
+
+	return tbl, err
+}
+
+var (
+	spokenTime     = regexp.MustCompile(`(?i)\b(\d{1,2})(?::(\d{2}))?\s?([ap])\.?m\b\.?`)
+	spokenOrdinal  = regexp.MustCompile(`\b(\d+)(?:st|nd|rd|th)\b`)
+	spokenDecimal  = regexp.MustCompile(`\b(\d+)\.(\d+)\b`)
+	spokenCardinal = regexp.MustCompile(`\b\d{1,3}(?:,\d{3})+\b|\b\d+\b`)
+	spokenAddress  = regexp.MustCompile(`(?i)\b(?:[\w+-]+(?:\.[\w+-]+)*@)?[a-z\d-]+(?:\.[a-z\d-]+)*\.[a-z]{2,}\b`)
+)
+
+// Matches amounts preceded by any of the table's currency symbols
+func (tbl SpokenTable) currency() *regexp.Regexp {
+	var symbols []string
+
+	for _, k := range slices.Sorted(maps.Keys(tbl.Currencies)) {
+		symbols = append(symbols, regexp.QuoteMeta(k))
+	}
+	if len(symbols) == 0 {
+		return regexp.MustCompile(`$^`)
+	}
+
+	return regexp.MustCompile(fmt.Sprintf(`(%s)\s?(\d[\d,]*)(?:\.(\d{1,2}))?\b`, strings.Join(symbols, "|")))
+}
+
+// Spells out a non negative integer string
+// numbers with leading zeros or beyond the largest scale are read digit by digit
+func (tbl SpokenTable) number(s string) string {
+	s = strings.ReplaceAll(s, ",", "")
+	n, err := strconv.Atoi(s)
+	if err != nil || (len(s) > 1 && s[0] == '0') || len(s) > 3*(len(tbl.Scales)+1) {
+		return tbl.digits(s)
+	}
+	if n == 0 {
+		return tbl.Ones[0]
+	}
+
+	var words []string
+	for i := len(tbl.Scales); i >= 0; i-- {
+		scale := 1
+		for range i {
+			scale *= 1000
+		}
+		group := n / scale % 1000
+		if group == 0 {
+			continue
+		}
+		words = append(words, tbl.hundreds(group)...)
+		if i > 0 {
+			words = append(words, tbl.Scales[i-1])
+		}
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Spells out a number between 1 and 999
+func (tbl SpokenTable) hundreds(n int) []string {
+	var words []string
+
+	if n >= 100 {
+		words = append(words, tbl.Ones[n/100], tbl.Hundred)
+		n %= 100
+	}
+	switch {
+	case n == 0:
+	case n < 20:
+		words = append(words, tbl.Ones[n])
+	case n%10 == 0:
+		words = append(words, tbl.Tens[n/10])
+	default:
+		words = append(words, tbl.Tens[n/10], tbl.Ones[n%10])
+	}
+
+	return words
+}
+
+// Spells out each digit of a string of digits
+func (tbl SpokenTable) digits(s string) string {
+	var words []string
+
+	for _, r := range s {
+		if r < '0' || r > '9' {
+			continue
+		}
+		words = append(words, tbl.Ones[r-'0'])
+	}
+
+	return strings.Join(words, " ")
+}
+
+// Spells out an integer string as an ordinal
+func (tbl SpokenTable) ordinal(s string) string {
+	words := strings.Split(tbl.number(s), " ")
+	last := words[len(words)-1]
+
+	ord, ok := tbl.Ordinals[last]
+	if !ok {
+		ord = last + tbl.OrdinalSuffix
+	}
+	words[len(words)-1] = ord
+
+	return strings.Join(words, " ")
+}
+
+// Picks the singular or plural form of a unit
+func plural(forms []string, s string) string {
+	if len(forms) == 0 {
+		return ""
+	}
+	if strings.TrimLeft(s, "0") == "1" || len(forms) == 1 {
+		return forms[0]
+	}
+	return forms[1]
+}
+
+// Expands written forms in s to spoken forms
+// returns the expanded string and a mapping from each spoken form to the written form it replaced
+func SpokenForm(s string, tbl SpokenTable) (string, map[string]string) {
+	written := make(map[string]string)
+	record := func(w string, sp string) string {
+		if _, ok := written[sp]; !ok && w != sp {
+			written[sp] = w
+		}
+		return fmt.Sprint(" ", sp, " ")
+	}
+
+	// emails and hostnames are expanded first, so their dots are read out and their digits are not read as numbers
+	if tbl.Dot != "" {
+		s = spokenAddress.ReplaceAllStringFunc(s, func(m string) string {
+			sp := strings.ReplaceAll(m, ".", fmt.Sprint(" ", tbl.Dot, " "))
+			if at, ok := tbl.Symbols["@"]; ok {
+				sp = strings.ReplaceAll(sp, "@", fmt.Sprint(" ", at, " "))
+			}
+			return record(m, strings.Join(strings.Fields(sp), " "))
+		})
+	}
+	spokenCurrency := tbl.currency()
+	s = spokenCurrency.ReplaceAllStringFunc(s, func(m string) string {
+		sub := spokenCurrency.FindStringSubmatch(m)
+		words := []string{tbl.number(sub[2]), plural(tbl.Currencies[sub[1]], sub[2])}
+		if sub[3] != "" && strings.Trim(sub[3], "0") != "" {
+			cents := sub[3]
+			if len(cents) == 1 {
+				cents += "0"
+			}
+			cents = strings.TrimLeft(cents, "0")
+			words = append(words, tbl.And, tbl.number(cents), plural(tbl.Cents, cents))
+		}
+		return record(strings.TrimSpace(m), strings.Join(slices.DeleteFunc(words, func(w string) bool { return w == "" }), " "))
+	})
+	s = spokenTime.ReplaceAllStringFunc(s, func(m string) string {
+		sub := spokenTime.FindStringSubmatch(m)
+		words := []string{tbl.number(sub[1])}
+		switch {
+		case sub[2] == "" || sub[2] == "00":
+		case sub[2][0] == '0':
+			words = append(words, tbl.Minutes, tbl.number(sub[2][1:]))
+		default:
+			words = append(words, tbl.number(sub[2]))
+		}
+		if strings.ToLower(sub[3]) == "a" {
+			words = append(words, tbl.AM)
+		} else {
+			words = append(words, tbl.PM)
+		}
+		return record(strings.TrimSpace(m), strings.Join(words, " "))
+	})
+	s = spokenOrdinal.ReplaceAllStringFunc(s, func(m string) string {
+		return record(m, tbl.ordinal(spokenOrdinal.FindStringSubmatch(m)[1]))
+	})
+	s = spokenDecimal.ReplaceAllStringFunc(s, func(m string) string {
+		sub := spokenDecimal.FindStringSubmatch(m)
+		return record(m, strings.Join([]string{tbl.number(sub[1]), tbl.Point, tbl.digits(sub[2])}, " "))
+	})
+	s = spokenCardinal.ReplaceAllStringFunc(s, func(m string) string {
+		return record(m, tbl.number(m))
+	})
+
+	fields := strings.Fields(s)
+	for i := range fields {
+		sp, ok := tbl.Abbreviations[fields[i]]
+		if ok {
+			fields[i] = strings.TrimSpace(record(fields[i], sp))
+		}
+	}
+	s = strings.Join(fields, " ")
+
+	// symbols expand to common words (and, at, plus), so they are not recorded
+	for _, sym := range slices.Sorted(maps.Keys(tbl.Symbols)) {
+		s = strings.ReplaceAll(s, sym, fmt.Sprint(" ", tbl.Symbols[sym], " "))
+	}
+
+	return strings.Join(strings.Fields(s), " "), written
+}
+
+// Expands written forms in each text to spoken forms, recording the written form of each expansion
+// texts which become identical after expansion are merged
+func SpokenNormalize(t []Text, tbl SpokenTable, l *log.Logger) []Text {
+	for i := range t {
+		s, written := SpokenForm(t[i].text, tbl)
+		if s == t[i].text {
+			continue
+		}
+		l.Printf("NORMALIZE: spoken form normalization replaced %v with %v\n", t[i].text, s)
+		t[i].text = s
+		if len(written) != 0 {
+			t[i].written = written
+		}
+	}
+
+	return compactTexts(t)
+}
+
+// Collects the texts holding written forms of spoken forms, keyed by the corpus lines of each text
+func CollectWritten(t []Text) map[int]Text {
+	written := make(map[int]Text)
+
+	for i := range t {
+		if len(t[i].written) == 0 {
+			continue
+		}
+		for _, l := range t[i].lines {
+			written[l] = t[i]
+		}
+	}
+
+	return written
+}
@@ -0,0 +1,114 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:47:33 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSpokenForm(t *testing.T) {
+	type args struct {
+		s string
+	}
+	tests := []struct {
+		args  args
+		want  string
+		want1 map[string]string
+	}{
+		{args: args{s: ""}, want: "", want1: map[string]string{}},
+		{args: args{s: "I want an account"}, want: "I want an account", want1: map[string]string{}},
+		{args: args{s: "send me $300 now"}, want: "send me three hundred dollars now", want1: map[string]string{"three hundred dollars": "$300"}},
+		{args: args{s: "send me $1.05"}, want: "send me one dollar and five cents", want1: map[string]string{"one dollar and five cents": "$1.05"}},
+		{args: args{s: "send me £2.5"}, want: "send me two pounds and fifty cents", want1: map[string]string{"two pounds and fifty cents": "£2.5"}},
+		{args: args{s: "meet Dr. Smith at 3pm"}, want: "meet doctor Smith at three p m", want1: map[string]string{"doctor": "Dr.", "three p m": "3pm"}},
+		{args: args{s: "at 10:05 am or 11:30 P.M."}, want: "at ten oh five a m or eleven thirty p m", want1: map[string]string{"ten oh five a m": "10:05 am", "eleven thirty p m": "11:30 P.M."}},
+		{args: args{s: "the 2nd, 21st and 100th"}, want: "the second , twenty first and one hundredth", want1: map[string]string{"second": "2nd", "twenty first": "21st", "one hundredth": "100th"}},
+		{args: args{s: "pi is 3.14"}, want: "pi is three point one four", want1: map[string]string{"three point one four": "3.14"}},
+		{args: args{s: "50% off & more"}, want: "fifty percent off and more", want1: map[string]string{"fifty": "50"}},
+		{args: args{s: "call 007"}, want: "call zero zero seven", want1: map[string]string{"zero zero seven": "007"}},
+		{args: args{s: "1,200,000 people"}, want: "one million two hundred thousand people", want1: map[string]string{"one million two hundred thousand": "1,200,000"}},
+		{args: args{s: "3 and 3"}, want: "three and three", want1: map[string]string{"three": "3"}},
+		{args: args{s: "write to bob.smith@example.com"}, want: "write to bob dot smith at example dot com", want1: map[string]string{"bob dot smith at example dot com": "bob.smith@example.com"}},
+		{args: args{s: "go to www.example.co.uk now"}, want: "go to www dot example dot co dot uk now", want1: map[string]string{"www dot example dot co dot uk": "www.example.co.uk"}},
+		{args: args{s: "use e.g. this"}, want: "use for example this", want1: map[string]string{"for example": "e.g."}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, got1 := SpokenForm(tt.args.s, EnglishSpoken)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want1, got1)
+		})
+	}
+}
+
+func TestSpokenNormalize(t *testing.T) {
+	type args struct {
+		t []Text
+	}
+	tests := []struct {
+		args args
+		want []Text
+	}{
+		{args: args{t: []Text{}}, want: []Text{}},
+		{args: args{t: []Text{{text: "I want an account"}}}, want: []Text{{text: "I want an account"}}},
+		{args: args{t: []Text{{text: "I want 2 accounts"}, {text: "I want two accounts"}}}, want: []Text{{text: "I want two accounts", written: map[string]string{"two": "2"}}}},
+		{args: args{t: []Text{{text: "pay $5"}, {text: "pay 5 dollars"}}}, want: []Text{{text: "pay five dollars", written: map[string]string{"five": "5", "five dollars": "$5"}}}},
+		// symbols are expanded without recording a written form
+		{args: args{t: []Text{{text: "you & me"}}}, want: []Text{{text: "you and me"}}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, SpokenNormalize(tt.args.t, EnglishSpoken, nilLogger))
+		})
+	}
+}
+
+func TestReadSpokenTable(t *testing.T) {
+	type args struct {
+		p string
+		s string
+	}
+	tests := []struct {
+		args      args
+		want      string
+		assertion assert.ErrorAssertionFunc
+	}{
+		{args: args{p: "", s: ""}, want: "", assertion: assert.Error},
+		{args: args{p: "./data/tests/spoken1.json", s: "Nr. 21 & €300"}, want: "nummer zwanzig eins und drei hundert euro", assertion: assert.NoError},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			tbl, err := ReadSpokenTable(tt.args.p)
+			tt.assertion(t, err)
+			if err != nil {
+				return
+			}
+			got, _ := SpokenForm(tt.args.s, tbl)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestCollectWritten(t *testing.T) {
+	type args struct {
+		t []Text
+	}
+	tests := []struct {
+		args args
+		want map[int]Text
+	}{
+		{args: args{t: []Text{}}, want: map[int]Text{}},
+		{args: args{t: []Text{{text: "a", lines: []int{0}}, {text: "two", written: map[string]string{"two": "2"}, lines: []int{1, 3}}}}, want: map[int]Text{1: {text: "two", written: map[string]string{"two": "2"}, lines: []int{1, 3}}, 3: {text: "two", written: map[string]string{"two": "2"}, lines: []int{1, 3}}}},
+		{args: args{t: []Text{{text: "two", written: map[string]string{"two": "2"}, lines: []int{1}}, {text: "two b", written: map[string]string{"two": "02", "b": "B"}, lines: []int{2}}}}, want: map[int]Text{1: {text: "two", written: map[string]string{"two": "2"}, lines: []int{1}}, 2: {text: "two b", written: map[string]string{"two": "02", "b": "B"}, lines: []int{2}}}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, CollectWritten(tt.args.t))
+		})
+	}
+}
@@ -6,6 +6,8 @@
 package main
 
 import (
+	"encoding/json"
+	"os"
 	"slices"
 	"strings"
 
@@ -17,6 +19,10 @@ type SyntacticTagger struct {
 	*tag.PerceptronTagger
 	Tokenizer
 	rules []ConstituencyRule
+	// optional mapping applied to POS tags before they are returned
+	coarse TagMap
+	// optional cache of Penn Treebank POS and constituency lookups
+	cache *Cache[[2]string, tagResult]
 }
 
 // Constituency tag and the equivaelent sequence of POS tags
@@ -25,11 +31,23 @@ type ConstituencyRule struct {
 	tag  string
 }
 
-// Lookup POS tags for string
+// Lookup POS tags for string, mapped to the coarse tagset if one is set
 func (t *SyntacticTagger) POS(s string) ([]string, []string) {
+	tags, tokens := t.penn(s)
+
+	return t.coarse.apply(tags), tokens
+}
+
+// Lookup Penn Treebank POS tags for string
+func (t *SyntacticTagger) penn(s string) ([]string, []string) {
 	var tags []string
-	tokens := t.tokenize(s)
 
+	res, ok := t.cache.get([2]string{"pos", s})
+	if ok {
+		return slices.Clone(res.tags), slices.Clone(res.tokens)
+	}
+
+	tokens := t.tokenize(s)
 	if len(tokens) == 0 {
 		return []string{}, []string{}
 	}
@@ -37,11 +55,13 @@ func (t *SyntacticTagger) POS(s string) ([]string, []string) {
 	for _, tag := range t.Tag(tokens) {
 		tags = append(tags, tag.Tag)
 	}
+	t.cache.put([2]string{"pos", s}, tagResult{slices.Clone(tags), slices.Clone(tokens)})
 
 	return tags, tokens
 }
 
 // Lookup constituency tags for string
+// constituency rules are resolved on Penn Treebank tags, remaining POS tags are then mapped to the coarse tagset if one is set
 func (t *SyntacticTagger) Constituency(s string) ([]string, []string) {
 	scanSubseq := func(s1, s2 []string) ([]int, bool) {
 		var res []int
@@ -66,7 +86,12 @@ func (t *SyntacticTagger) Constituency(s string) ([]string, []string) {
 		return res, false
 	}
 
-	tags, tokens := t.POS(s)
+	res, ok := t.cache.get([2]string{"con", s})
+	if ok {
+		return t.coarse.apply(slices.Clone(res.tags)), slices.Clone(res.tokens)
+	}
+
+	tags, tokens := t.penn(s)
 
 	if len(tokens) == 0 {
 		return []string{}, []string{}
@@ -84,8 +109,22 @@ func (t *SyntacticTagger) Constituency(s string) ([]string, []string) {
 			tokens = slices.Replace(tokens, ind[0], ind[1], strings.Join(tokens[ind[0]:ind[1]], " "))
 		}
 	}
+	t.cache.put([2]string{"con", s}, tagResult{slices.Clone(tags), slices.Clone(tokens)})
 
-	return tags, tokens
+	return t.coarse.apply(tags), tokens
+}
+
+// Returns a copy of the tagger which stores lookups in c
+// the cache holds Penn Treebank tags, so it can be shared between taggers with different tag maps
+func (t SyntacticTagger) WithCache(c *Cache[[2]string, tagResult]) SyntacticTagger {
+	t.cache = c
+	return t
+}
+
+// Returns a copy of the tagger which maps POS tags to the tagset defined by m
+func (t SyntacticTagger) WithTagMap(m TagMap) SyntacticTagger {
+	t.coarse = m
+	return t
 }
 
 func NewSyntacticTagger(m *tag.PerceptronTagger, t Tokenizer) SyntacticTagger {
@@ -179,5 +218,94 @@ func NewSyntacticTagger(m *tag.PerceptronTagger, t Tokenizer) SyntacticTagger {
 	}
 	slices.SortStableFunc(rules, func(i, j ConstituencyRule) int { return len(i.rule) - len(j.rule) })
 
-	return SyntacticTagger{m, t, rules}
+	return SyntacticTagger{m, t, rules, nil, nil}
+}
+
+// Mapping from fine grained POS tags to a coarser tagset
+type TagMap map[string]string
+
+// Maps Penn Treebank POS tags to Universal Dependencies UPOS tags
+var UniversalTagMap = TagMap{
+	"CC":     "CCONJ",
+	"CD":     "NUM",
+	"DT":     "DET",
+	"EX":     "PRON",
+	"FW":     "X",
+	"IN":     "ADP",
+	"JJ":     "ADJ",
+	"JJR":    "ADJ",
+	"JJS":    "ADJ",
+	"LS":     "X",
+	"MD":     "AUX",
+	"NN":     "NOUN",
+	"NNS":    "NOUN",
+	"NNP":    "PROPN",
+	"NNPS":   "PROPN",
+	"PDT":    "DET",
+	"POS":    "PART",
+	"PRP":    "PRON",
+	"PRP$":   "PRON",
+	"RB":     "ADV",
+	"RBR":    "ADV",
+	"RBS":    "ADV",
+	"RP":     "ADP",
+	"SYM":    "SYM",
+	"TO":     "PART",
+	"UH":     "INTJ",
+	"VB":     "VERB",
+	"VBD":    "VERB",
+	"VBG":    "VERB",
+	"VBN":    "VERB",
+	"VBP":    "VERB",
+	"VBZ":    "VERB",
+	"WDT":    "DET",
+	"WP":     "PRON",
+	"WP$":    "PRON",
+	"WRB":    "ADV",
+	"$":      "SYM",
+	"#":      "SYM",
+	".":      "PUNCT",
+	",":      "PUNCT",
+	":":      "PUNCT",
+	"(":      "PUNCT",
+	")":      "PUNCT",
+	"-LRB-":  "PUNCT",
+	"-RRB-":  "PUNCT",
+	"``":     "PUNCT",
+	"''":     "PUNCT",
+	"-NONE-": "X",
+}
+
+// Maps each tag via m, tags without a mapping are left unchanged
+func (m TagMap) apply(tags []string) []string {
+	if m == nil {
+		return tags
+	}
+
+	out := make([]string, len(tags))
+	for i := range tags {
+		mapped, ok := m[tags[i]]
+		if !ok {
+			mapped = tags[i]
+		}
+		out[i] = mapped
+	}
+
+	return out
+}
+
+// Reads a user provided json object mapping fine grained tags to coarse tags
+func ReadTagMap(p string) (TagMap, error) {
+	var err error
+	m := TagMap{}
+
+	file, err := os.Open(p)
+	if err != nil {
+		return m, err
+	}
+	defer file.Close()
+	dec := json.NewDecoder(file)
+	err = dec.Decode(&m)
+
+	return m, err
 }
@@ -77,3 +77,56 @@ func TestSyntacticTagger_Constituency(t *testing.T) {
 		})
 	}
 }
+
+func TestSyntacticTagger_WithTagMap(t *testing.T) {
+	type args struct {
+		s string
+		m TagMap
+	}
+	tests := []struct {
+		args  args
+		want  []string
+		want1 []string
+	}{
+		{args: args{s: "", m: UniversalTagMap}, want: []string{}, want1: []string{}},
+		{args: args{s: "I", m: UniversalTagMap}, want: []string{"PRON"}, want1: []string{"PRON"}},
+		{args: args{s: "I have no online account", m: UniversalTagMap}, want: []string{"PRON", "VERB", "DET", "NOUN", "NOUN"}, want1: []string{"PRON", "VP", "NOUN"}},
+		{args: args{s: "i want an account", m: UniversalTagMap}, want: []string{"NOUN", "VERB", "DET", "NOUN"}, want1: []string{"NOUN", "VP"}},
+		{args: args{s: "can you show me my invoices?", m: UniversalTagMap}, want: []string{"AUX", "PRON", "VERB", "PRON", "PRON", "NOUN", "PUNCT"}, want1: []string{"AUX", "PRON", "VERB", "PRON", "PRON", "NOUN", "PUNCT"}},
+		{args: args{s: "I have no online account", m: TagMap{"NN": "N"}}, want: []string{"PRP", "VBP", "DT", "N", "N"}, want1: []string{"PRP", "VP", "N"}},
+		{args: args{s: "I have no online account", m: nil}, want: []string{"PRP", "VBP", "DT", "NN", "NN"}, want1: []string{"PRP", "VP", "NN"}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			tok := NewWordTokenizer()
+			mod := tag.NewPerceptronTagger()
+			tag := NewSyntacticTagger(mod, tok).WithTagMap(tt.args.m)
+			got, _ := tag.POS(tt.args.s)
+			got1, _ := tag.Constituency(tt.args.s)
+			assert.Equal(t, tt.want, got)
+			assert.Equal(t, tt.want1, got1)
+		})
+	}
+}
+
+func TestReadTagMap(t *testing.T) {
+	type args struct {
+		p string
+	}
+	tests := []struct {
+		args      args
+		want      TagMap
+		assertion assert.ErrorAssertionFunc
+	}{
+		{args: args{p: ""}, want: TagMap{}, assertion: assert.Error},
+		{args: args{p: "./data/tests/tags1.json"}, want: TagMap{"NN": "N", "NNS": "N", "VB": "V", "VBP": "V", "PRP": "PRON"}, assertion: assert.NoError},
+		{args: args{p: "./data/tests/tags2.json"}, want: TagMap{}, assertion: assert.Error},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got, err := ReadTagMap(tt.args.p)
+			tt.assertion(t, err)
+			assert.Equal(t, tt.want, got)
+		})
+	}
+}
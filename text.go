@@ -25,23 +25,68 @@ type Text struct {
 	suf   string
 	text  string
 	chunk []string
+	// values replaced by class references, keyed by class name
+	classes map[string][]string
+	// written forms replaced by spoken forms, keyed by spoken form
+	written map[string]string
+	// corpus lines the text was read from
+	lines []int
 }
 
-// Reads each line of the input file, converting each line to a Text struct and removing duplicates
+// Reads each line of the input file as ReadCorpus does, without the line numbers each text was read from
 func ReadTexts(s *bufio.Scanner) []Text {
+	texts := ReadCorpus(s)
+	for i := range texts {
+		texts[i].lines = nil
+	}
+
+	return texts
+}
+
+// Reads each line of the input file, converting each line to a Text struct and merging duplicates, keeping the line numbers each text was read from
+func ReadCorpus(s *bufio.Scanner) []Text {
 	texts := []Text{}
 
-	for s.Scan() {
+	for i := 1; s.Scan(); i++ {
 		text := strings.TrimSpace(s.Text())
 		if text != "" {
-			texts = append(texts, Text{text: text, chunk: []string{}})
+			texts = append(texts, Text{text: text, chunk: []string{}, lines: []int{i}})
 		}
 	}
 
-	slices.SortStableFunc(texts, func(i, j Text) int { return strings.Compare(i.text, j.text) })
-	texts = slices.CompactFunc(texts, func(i, j Text) bool { return i.text == j.text })
+	return compactTexts(texts)
+}
 
-	return texts
+// Sorts texts and merges texts with identical content, keeping the class values, written forms, and corpus lines of both
+func compactTexts(t []Text) []Text {
+	slices.SortStableFunc(t, func(i, j Text) int { return strings.Compare(i.text, j.text) })
+
+	out := t[:0]
+	for i := range t {
+		if len(out) == 0 || out[len(out)-1].text != t[i].text {
+			out = append(out, t[i])
+			continue
+		}
+		last := &out[len(out)-1]
+		last.lines = slices.Concat(last.lines, t[i].lines)
+		slices.Sort(last.lines)
+		for k, v := range t[i].classes {
+			if last.classes == nil {
+				last.classes = make(map[string][]string)
+			}
+			last.classes[k] = append(last.classes[k], v...)
+		}
+		for k, v := range t[i].written {
+			if last.written == nil {
+				last.written = make(map[string]string)
+			}
+			if _, ok := last.written[k]; !ok {
+				last.written[k] = v
+			}
+		}
+	}
+
+	return out
 }
 
 // Sets the largest chunk in c present in t as t.root, sets prefix and suffix accordingly
@@ -68,16 +113,17 @@ func ToTriplet(t Text, c []string) Text {
 
 // Converts Text to Rule
 func ToRule(t Text) Rule {
-	return Rule{pre: []string{t.pre}, root: []string{t.root}, suf: []string{t.suf}, isPublic: true}
+	return Rule{pre: []string{t.pre}, root: []string{t.root}, suf: []string{t.suf}, isPublic: true, lines: t.lines}
 }
 
 // Keeps only the texts matching the most common structures found in the corpus
 // structures are determined by constituency tags, texts not matching the top q quantile of structures are removed
 // higher q will remove more texts
-func FilterTexts(t []Text, tag SyntacticTagger, q float64) []Text {
+// texts are tagged using n workers
+func FilterTexts(t []Text, tag SyntacticTagger, q float64, n int) []Text {
 	var (
 		counts    = make(map[string]int)
-		sigs      = []string{}
+		sigs      []string
 		vals      = []float64{}
 		threshold float64
 	)
@@ -91,11 +137,14 @@ func FilterTexts(t []Text, tag SyntacticTagger, q float64) []Text {
 	default:
 	}
 
-	for i := range t {
-		sig, _ := tag.Constituency(t[i].text)
-		sigs = append(sigs, strings.Join(sig, "-"))
-		counts[strings.Join(sig, "-")]++
+	sigs = ParallelMap(t, n, func(t Text) string {
+		sig, _ := tag.Constituency(t.text)
+		return strings.Join(sig, "-")
+	})
+	for i := range sigs {
+		counts[sigs[i]]++
 	}
+	textSigs := slices.Clone(sigs)
 
 	slices.SortStableFunc(sigs, func(i, j string) int { return counts[i] - counts[j] })
 
@@ -104,10 +153,12 @@ func FilterTexts(t []Text, tag SyntacticTagger, q float64) []Text {
 	}
 
 	threshold = stat.Quantile(q, stat.Empirical, vals, nil)
-	t = slices.DeleteFunc(t, func(i Text) bool {
-		sig, _ := tag.Constituency(i.text)
-		return counts[strings.Join(sig, "-")] < int(threshold)
-	})
+	kept := t[:0]
+	for i := range t {
+		if counts[textSigs[i]] >= int(threshold) {
+			kept = append(kept, t[i])
+		}
+	}
 
-	return t
+	return kept
 }
@@ -8,6 +8,7 @@ package main
 import (
 	"bufio"
 	"os"
+	"strings"
 	"testing"
 
 	"github.com/jdkato/prose/tag"
@@ -63,6 +64,22 @@ func TestReadTexts(t *testing.T) {
 	}
 }
 
+func TestReadCorpus(t *testing.T) {
+	tests := []struct {
+		s    string
+		want []Text
+	}{
+		{s: "", want: []Text{}},
+		{s: "a\n\nb\n a\n", want: []Text{{chunk: []string{}, text: "a", lines: []int{1, 4}}, {chunk: []string{}, text: "b", lines: []int{3}}}},
+		{s: "b\nb\nb", want: []Text{{chunk: []string{}, text: "b", lines: []int{1, 2, 3}}}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, ReadCorpus(bufio.NewScanner(strings.NewReader(tt.s))))
+		})
+	}
+}
+
 func TestToTriplet(t *testing.T) {
 	type args struct {
 		t Text
@@ -104,6 +121,7 @@ func TestToRule(t *testing.T) {
 		{args: args{t: Text{pre: "", root: "i get an error", suf: "message when i ty to make a payment for my order", chunk: []string{"i get", "an error message", "when i ty to make a payment for my order"}, text: "i get an error message when i ty to make a payment for my order"}}, want: Rule{pre: []string{""}, root: []string{"i get an error"}, suf: []string{"message when i ty to make a payment for my order"}, isPublic: true, id: 0}},
 		{args: args{t: Text{pre: "i get an error", root: "", suf: "message when i ty to make a payment for my order", chunk: []string{"i get an", "error message when", "i ty to make a payment for my order"}, text: "i get an error message when i ty to make a payment for my order"}}, want: Rule{pre: []string{"i get an error"}, root: []string{""}, suf: []string{"message when i ty to make a payment for my order"}, isPublic: true, id: 0}},
 		{args: args{t: Text{pre: "i", root: "get an error message when i ty to make a payment for my order", suf: "", chunk: []string{"i", "get", "an", "error", "message", "when", "i", "ty", "to", "make", "a", "payment", "for", "my", "order"}, text: "i get an error message when i ty to make a payment for my order"}}, want: Rule{pre: []string{"i"}, root: []string{"get an error message when i ty to make a payment for my order"}, suf: []string{""}, isPublic: true, id: 0}},
+		{args: args{t: Text{pre: "i", root: "get", suf: "an error", chunk: []string{"i", "get", "an error"}, text: "i get an error", lines: []int{2, 5}}}, want: Rule{pre: []string{"i"}, root: []string{"get"}, suf: []string{"an error"}, isPublic: true, lines: []int{2, 5}}},
 	}
 	for _, tt := range tests {
 		t.Run("", func(t *testing.T) {
@@ -153,7 +171,7 @@ func TestFilterTexts(t *testing.T) {
 				t.text = tokenizer.normalize(t.text)
 				tx[i] = t
 			}
-			assert.Equal(t, tt.want, FilterTexts(tx, tagger, tt.args.q))
+			assert.Equal(t, tt.want, FilterTexts(tx, tagger, tt.args.q, 1))
 		})
 	}
 }
@@ -0,0 +1,241 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:13:26 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"log"
+	"math"
+	"slices"
+	"strings"
+	"sync"
+)
+
+// Term weighting schemes supported by TFIDFModel
+var tfidfWeightings = []string{"tf", "sublinear", "bm25"}
+
+// BM25 term frequency saturation and length normalization parameters
+const (
+	bm25K1 = 1.2
+	bm25B  = 0.75
+)
+
+// Sparse vector with indices in ascending order
+type SparseVector struct {
+	indices []int
+	values  []float64
+}
+
+// Dot product of two sparse vectors
+func (v SparseVector) Dot(u SparseVector) float64 {
+	var (
+		dot  float64
+		i, j int
+	)
+
+	for i < len(v.indices) && j < len(u.indices) {
+		switch {
+		case v.indices[i] < u.indices[j]:
+			i++
+		case v.indices[i] > u.indices[j]:
+			j++
+		default:
+			dot += v.values[i] * u.values[j]
+			i++
+			j++
+		}
+	}
+
+	return dot
+}
+
+// Euclidean norm of a sparse vector
+func (v SparseVector) Norm() float64 {
+	var sum float64
+
+	for i := range v.values {
+		sum += v.values[i] * v.values[i]
+	}
+
+	return math.Sqrt(sum)
+}
+
+// Calculate cosine similarity between sparse vectors, empty vectors are equal to each other and dissimilar to everything else
+func SparseCosine(v1, v2 SparseVector) float64 {
+	n1, n2 := v1.Norm(), v2.Norm()
+	switch {
+	case n1 == 0 && n2 == 0:
+		return 1.0
+	case n1 == 0 || n2 == 0:
+		return 0.0
+	}
+
+	return v1.Dot(v2) / (n1 * n2)
+}
+
+// TF-IDF vector space model fit on a corpus of Texts
+// vocabulary tokens are looked up through a token to index map, and vectors for each expression group are cached once computed
+// out of vocabulary tokens are given their own indices and weighted as if they appeared in a single document, so groups sharing an unseen token can still match
+type TFIDFModel struct {
+	tok       Tokenizer
+	weighting string
+	index     map[string]int
+	df        []float64
+	docs      float64
+	avgLen    float64
+
+	mu      sync.Mutex
+	oov     map[string]int
+	vectors map[string]SparseVector
+}
+
+// Fits a TF-IDF model on lowercased Texts, tokenizing with n workers
+// weighting is one of tf (term frequency), sublinear (1 + log term frequency), or bm25
+func NewTFIDFModel(t []Text, tok Tokenizer, weighting string, n int) *TFIDFModel {
+	m := &TFIDFModel{
+		tok:       tok,
+		weighting: weighting,
+		index:     make(map[string]int),
+		docs:      float64(len(t)),
+		oov:       make(map[string]int),
+		vectors:   make(map[string]SparseVector),
+	}
+
+	var total int
+	tokens := ParallelMap(t, n, func(t Text) []string { return tok.tokenize(strings.ToLower(t.text)) })
+	for i := range tokens {
+		total += len(tokens[i])
+		slices.Sort(tokens[i])
+		for _, token := range slices.Compact(tokens[i]) {
+			ind, ok := m.index[token]
+			if !ok {
+				ind = len(m.df)
+				m.index[token] = ind
+				m.df = append(m.df, 0)
+			}
+			m.df[ind]++
+		}
+	}
+	if len(t) > 0 {
+		m.avgLen = float64(total) / m.docs
+	}
+
+	return m
+}
+
+// Number of tokens in the model vocabulary
+func (m *TFIDFModel) Len() int {
+	return len(m.df)
+}
+
+// Looks up the index of a token, assigning a new index past the vocabulary to out of vocabulary tokens
+func (m *TFIDFModel) lookup(token string) (int, float64) {
+	if ind, ok := m.index[token]; ok {
+		return ind, m.df[ind]
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	ind, ok := m.oov[token]
+	if !ok {
+		ind = len(m.df) + len(m.oov)
+		m.oov[token] = ind
+	}
+
+	return ind, 1
+}
+
+// Inverse document frequency of a token appearing in df documents
+func (m *TFIDFModel) idf(df float64) float64 {
+	if m.weighting == "bm25" {
+		return math.Log((max(m.docs, df)-df+0.5)/(df+0.5) + 1)
+	}
+	return math.Log(max(m.docs, 1)/df + 1)
+}
+
+// Weighted term frequency of a token appearing count times in an expression of length n
+func (m *TFIDFModel) tf(count float64, n int) float64 {
+	switch m.weighting {
+	case "sublinear":
+		return 1 + math.Log(count)
+	case "bm25":
+		norm := 1.0
+		if m.avgLen > 0 {
+			norm = 1 - bm25B + bm25B*float64(n)/m.avgLen
+		}
+		return count * (bm25K1 + 1) / (count + bm25K1*norm)
+	default:
+		return count / float64(n)
+	}
+}
+
+// Calculates the weighted vector for an expression without caching
+func (m *TFIDFModel) vectorize(s string) SparseVector {
+	var (
+		v      SparseVector
+		tokens = m.tok.tokenize(strings.ToLower(s))
+		counts = make(map[int]float64)
+		df     = make(map[int]float64)
+	)
+
+	for i := range tokens {
+		ind, d := m.lookup(tokens[i])
+		counts[ind]++
+		df[ind] = d
+	}
+	for k := range counts {
+		v.indices = append(v.indices, k)
+	}
+	slices.Sort(v.indices)
+	for _, k := range v.indices {
+		v.values = append(v.values, m.tf(counts[k], len(tokens))*m.idf(df[k]))
+	}
+
+	return v
+}
+
+// Weighted vector for an expression, computed once and cached
+func (m *TFIDFModel) Vector(s string) SparseVector {
+	m.mu.Lock()
+	v, ok := m.vectors[s]
+	m.mu.Unlock()
+	if ok {
+		return v
+	}
+
+	v = m.vectorize(s)
+	m.mu.Lock()
+	m.vectors[s] = v
+	m.mu.Unlock()
+
+	return v
+}
+
+// Computes and caches vectors for all expressions ahead of merging, with n workers
+func (m *TFIDFModel) Precompute(s []string, n int) {
+	s = slices.Clone(s)
+	slices.Sort(s)
+	s = slices.Compact(s)
+	vecs := ParallelMap(s, n, m.vectorize)
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	for i := range s {
+		m.vectors[s[i]] = vecs[i]
+	}
+}
+
+// Compares expression similarity via sparse tfidf vectors and cosine similarity
+func TFIDFCosineThreshold(thr float64, m *TFIDFModel, l *log.Logger) EqualityFunction {
+	return func(e1, e2 []string) bool {
+		sim := SparseCosine(m.Vector(strings.Join(e1, " ")), m.Vector(strings.Join(e2, " ")))
+		if sim >= thr {
+			l.Printf("equality function %s matched %v and %v, threshold %v, similarity %v\n", "TFIDFCosineThreshold", e1, e2, thr, sim)
+			similarities.record("TFIDFCosineThreshold", e1, e2, thr, sim)
+			return true
+		}
+		return false
+	}
+}
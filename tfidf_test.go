@@ -0,0 +1,146 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 02:13:26 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"math"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSparseVector_Dot(t *testing.T) {
+	type args struct {
+		v SparseVector
+		u SparseVector
+	}
+	tests := []struct {
+		args args
+		want float64
+	}{
+		{args: args{v: SparseVector{}, u: SparseVector{}}, want: 0.0},
+		{args: args{v: SparseVector{[]int{0, 2}, []float64{1, 2}}, u: SparseVector{}}, want: 0.0},
+		{args: args{v: SparseVector{[]int{0, 2}, []float64{1, 2}}, u: SparseVector{[]int{1, 3}, []float64{1, 2}}}, want: 0.0},
+		{args: args{v: SparseVector{[]int{0, 2, 5}, []float64{1, 2, 3}}, u: SparseVector{[]int{2, 3, 5}, []float64{4, 1, 2}}}, want: 14.0},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, tt.args.v.Dot(tt.args.u))
+		})
+	}
+}
+
+func TestSparseCosine(t *testing.T) {
+	type args struct {
+		v1 SparseVector
+		v2 SparseVector
+	}
+	tests := []struct {
+		args args
+		want float64
+	}{
+		{args: args{v1: SparseVector{}, v2: SparseVector{}}, want: 1.0},
+		{args: args{v1: SparseVector{[]int{0}, []float64{1}}, v2: SparseVector{}}, want: 0.0},
+		{args: args{v1: SparseVector{[]int{0}, []float64{1}}, v2: SparseVector{[]int{0}, []float64{3}}}, want: 1.0},
+		{args: args{v1: SparseVector{[]int{0}, []float64{1}}, v2: SparseVector{[]int{1}, []float64{1}}}, want: 0.0},
+		{args: args{v1: SparseVector{[]int{0, 1}, []float64{1, 1}}, v2: SparseVector{[]int{1}, []float64{1}}}, want: 1 / math.Sqrt(2)},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.InDelta(t, tt.want, SparseCosine(tt.args.v1, tt.args.v2), 1e-9)
+		})
+	}
+}
+
+func TestNewTFIDFModel(t *testing.T) {
+	texts := []Text{{text: "Send the invoice"}, {text: "pay the invoice"}, {text: "send a refund"}}
+	m := NewTFIDFModel(texts, NewWordTokenizer(), "tf", 1)
+
+	assert.Equal(t, 6, m.Len())
+	assert.Equal(t, map[string]int{"invoice": 0, "send": 1, "the": 2, "pay": 3, "a": 4, "refund": 5}, m.index)
+	assert.Equal(t, []float64{2, 2, 2, 1, 1, 1}, m.df)
+	assert.Equal(t, 3.0, m.avgLen)
+	assert.Equal(t, NewTFIDFModel(texts, NewWordTokenizer(), "tf", 1).index, NewTFIDFModel(texts, NewWordTokenizer(), "tf", 3).index)
+
+	empty := NewTFIDFModel([]Text{}, NewWordTokenizer(), "tf", 1)
+	assert.Equal(t, 0, empty.Len())
+	assert.Equal(t, SparseVector{[]int{0}, []float64{math.Log(2)}}, empty.Vector("test"))
+}
+
+func TestTFIDFModel_Vector(t *testing.T) {
+	type args struct {
+		weighting string
+		s         string
+	}
+	texts := []Text{{text: "send the invoice"}, {text: "pay the invoice"}, {text: "send a refund"}}
+	tests := []struct {
+		args args
+		want SparseVector
+	}{
+		{args: args{weighting: "tf", s: ""}, want: SparseVector{}},
+		{args: args{weighting: "tf", s: "Send"}, want: SparseVector{[]int{1}, []float64{math.Log(2.5)}}},
+		{args: args{weighting: "tf", s: "send the invoice"}, want: SparseVector{[]int{0, 1, 2}, []float64{math.Log(2.5) / 3, math.Log(2.5) / 3, math.Log(2.5) / 3}}},
+		{args: args{weighting: "tf", s: "pay pay"}, want: SparseVector{[]int{3}, []float64{math.Log(4)}}},
+		// out of vocabulary tokens are weighted as if they appeared in one document
+		{args: args{weighting: "tf", s: "wire transfer"}, want: SparseVector{[]int{6, 7}, []float64{0.5 * math.Log(4), 0.5 * math.Log(4)}}},
+		{args: args{weighting: "sublinear", s: "send send"}, want: SparseVector{[]int{1}, []float64{(1 + math.Log(2)) * math.Log(2.5)}}},
+		{args: args{weighting: "bm25", s: "send"}, want: SparseVector{[]int{1}, []float64{2.2 / 1.6 * math.Log(1.6)}}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			got := NewTFIDFModel(texts, NewWordTokenizer(), tt.args.weighting, 1).Vector(tt.args.s)
+			assert.Equal(t, tt.want.indices, got.indices)
+			assert.InDeltaSlice(t, tt.want.values, got.values, 1e-9)
+		})
+	}
+}
+
+func TestTFIDFModel_Precompute(t *testing.T) {
+	texts := []Text{{text: "send the invoice"}, {text: "pay the invoice"}, {text: "send a refund"}}
+	m := NewTFIDFModel(texts, NewWordTokenizer(), "tf", 1)
+	s := []string{"send", "wire", "send", "pay the invoice"}
+
+	m.Precompute(s, 2)
+	assert.Equal(t, []string{"send", "wire", "send", "pay the invoice"}, s)
+	assert.Len(t, m.vectors, 3)
+	assert.Equal(t, m.vectorize("pay the invoice"), m.Vector("pay the invoice"))
+	// groups sharing an unseen token are similar
+	assert.Greater(t, SparseCosine(m.Vector("wire"), m.Vector("wire money")), 0.0)
+}
+
+func TestTFIDFCosineThreshold(t *testing.T) {
+	type args struct {
+		e1 []string
+		e2 []string
+	}
+	texts := []Text{{text: "send the invoice"}, {text: "pay the invoice"}, {text: "send a refund"}}
+	m := NewTFIDFModel(texts, NewWordTokenizer(), "tf", 1)
+	tests := []struct {
+		args args
+		want bool
+	}{
+		{args: args{e1: []string{}, e2: []string{}}, want: true},
+		{args: args{e1: []string{"send the invoice"}, e2: []string{"send", "the invoice"}}, want: true},
+		{args: args{e1: []string{"send the invoice"}, e2: []string{"pay the invoice"}}, want: false},
+		{args: args{e1: []string{"send the invoice"}, e2: []string{}}, want: false},
+		{args: args{e1: []string{"wire the invoice"}, e2: []string{"wire the invoice"}}, want: true},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, TFIDFCosineThreshold(0.8, m, nilLogger)(tt.args.e1, tt.args.e2))
+		})
+	}
+}
+
+func TestCharNGramTFIDF(t *testing.T) {
+	texts := []Text{{text: "can i register two accounts"}, {text: "can i regisger two accounts"}, {text: "can i pay my bill"}}
+	m := NewTFIDFModel(texts, NewCharNGramTokenizer(NewWordTokenizer(), 2, 5), "tf", 1)
+
+	assert.Greater(t, SparseCosine(m.Vector("register"), m.Vector("regisger")), 0.3)
+	assert.Less(t, SparseCosine(m.Vector("register"), m.Vector("pay")), 0.1)
+	assert.True(t, TFIDFCosineThreshold(0.6, m, nilLogger)([]string{"regisger two accounts"}, []string{"register two accounts"}))
+	assert.False(t, TFIDFCosineThreshold(0.6, m, nilLogger)([]string{"regisger two accounts"}, []string{"pay my bill"}))
+}
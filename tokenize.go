@@ -134,3 +134,31 @@ func flushBuilder(b strings.Builder, out []string) (strings.Builder, []string) {
 
 	return b, out
 }
+
+// Tokenizer splitting each word into space padded character n-grams with lengths from min to max
+// n-grams are taken within words, so they mark word starts and ends but never span two words
+type charNGramTokenizer struct {
+	Tokenizer
+	min int
+	max int
+}
+
+func NewCharNGramTokenizer(tok Tokenizer, min int, max int) charNGramTokenizer {
+	return charNGramTokenizer{tok, min, max}
+}
+
+func (tok charNGramTokenizer) tokenize(s string) []string {
+	out := []string{}
+
+	for _, w := range tok.Tokenizer.tokenize(s) {
+		for n := tok.min; n <= tok.max; n++ {
+			out = append(out, CharacterNGrams(w, n)...)
+		}
+	}
+
+	return out
+}
+
+func (tok charNGramTokenizer) normalize(s string) string {
+	return strings.Join(tok.tokenize(s), " ")
+}
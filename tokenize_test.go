@@ -110,3 +110,28 @@ func Test_sepTokenizer_normalize(t *testing.T) {
 		})
 	}
 }
+
+func Test_charNGramTokenizer_tokenize(t *testing.T) {
+	type args struct {
+		s   string
+		min int
+		max int
+	}
+	tests := []struct {
+		args args
+		want []string
+	}{
+		{args: args{s: "", min: 2, max: 3}, want: []string{}},
+		{args: args{s: "a", min: 2, max: 3}, want: []string{" a", "a ", " a "}},
+		{args: args{s: "ab cd", min: 2, max: 2}, want: []string{" a", "ab", "b ", " c", "cd", "d "}},
+		{args: args{s: "ab cd", min: 3, max: 4}, want: []string{" ab", "ab ", " ab ", " cd", "cd ", " cd "}},
+		{args: args{s: "hi.", min: 3, max: 3}, want: []string{" hi", "hi ", " . "}},
+		{args: args{s: "abc", min: 6, max: 6}, want: []string{}},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			tk := NewCharNGramTokenizer(NewWordTokenizer(), tt.args.min, tt.args.max)
+			assert.Equal(t, tt.want, tk.tokenize(tt.args.s))
+		})
+	}
+}
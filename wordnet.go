@@ -0,0 +1,428 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:56:57 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"errors"
+	"fmt"
+	"log"
+	"maps"
+	"os"
+	"path/filepath"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// WordNet parts of speech and the names of their database files
+var wordnetFiles = map[string]string{"n": "noun", "v": "verb", "a": "adj", "r": "adv"}
+
+// WordNet parts of speech by the class names accepted as synonym targets
+var wordnetClasses = map[string]string{"noun": "n", "verb": "v", "adj": "a", "adv": "r"}
+
+// Suffix substitutions used to find the base form of inflected words, by part of speech
+var wordnetDetachment = map[string][][2]string{
+	"n": {{"s", ""}, {"ses", "s"}, {"xes", "x"}, {"zes", "z"}, {"ches", "ch"}, {"shes", "sh"}, {"men", "man"}, {"ies", "y"}},
+	"v": {{"s", ""}, {"ies", "y"}, {"es", "e"}, {"es", ""}, {"ed", "e"}, {"ed", ""}, {"ing", "e"}, {"ing", ""}},
+	"a": {{"er", ""}, {"est", ""}, {"er", "e"}, {"est", "e"}},
+	"r": {},
+}
+
+// Pointers followed when expanding synonyms beyond a word's own synsets: hypernyms and similar adjectives
+var wordnetRelations = []string{"@", "@i", "&"}
+
+// A synset, identified by its part of speech and byte offset in the data file
+type Synset struct {
+	pos    string
+	offset int
+}
+
+// Local WordNet database read from the standard dict/ files
+// data files are memory mapped, index and exception files are held in memory
+type WordNet struct {
+	index      map[string]map[string][]int
+	exceptions map[string]map[string][]string
+	data       map[string][]byte
+	close      []func() error
+}
+
+// Loads a WordNet database from a dict/ directory containing index.*, data.*, and optionally *.exc files
+func LoadWordNet(dir string) (*WordNet, error) {
+	w := &WordNet{index: make(map[string]map[string][]int), exceptions: make(map[string]map[string][]string), data: make(map[string][]byte)}
+
+	for _, pos := range slices.Sorted(maps.Keys(wordnetFiles)) {
+		name := wordnetFiles[pos]
+		idx, err := readWordNetIndex(filepath.Join(dir, "index."+name))
+		if err != nil {
+			w.Close()
+			return nil, fmt.Errorf("in LoadWordNet():\n%+w", err)
+		}
+		w.index[pos] = idx
+
+		data, closer, err := mapFile(filepath.Join(dir, "data."+name))
+		if err != nil {
+			w.Close()
+			return nil, fmt.Errorf("in LoadWordNet():\n%+w", err)
+		}
+		w.data[pos] = data
+		w.close = append(w.close, closer)
+
+		exc, err := readWordNetExceptions(filepath.Join(dir, name+".exc"))
+		if err != nil {
+			w.Close()
+			return nil, fmt.Errorf("in LoadWordNet():\n%+w", err)
+		}
+		w.exceptions[pos] = exc
+	}
+
+	return w, nil
+}
+
+// Releases the mapped data files
+func (w *WordNet) Close() error {
+	var err error
+
+	for i := range w.close {
+		err = errors.Join(err, w.close[i]())
+	}
+	w.close = nil
+
+	return err
+}
+
+// Reads the synset offsets of each lemma in an index file
+func readWordNetIndex(p string) (map[string][]int, error) {
+	idx := make(map[string][]int)
+
+	f, err := os.Open(p)
+	if err != nil {
+		return idx, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		line := scanner.Text()
+		// license header lines begin with spaces
+		if strings.HasPrefix(line, " ") {
+			continue
+		}
+		fields := strings.Fields(line)
+		if len(fields) < 4 {
+			continue
+		}
+		synsets, err := strconv.Atoi(fields[2])
+		if err != nil || len(fields) < synsets {
+			return idx, fmt.Errorf("invalid index line in %s: %q", p, line)
+		}
+		for _, o := range fields[len(fields)-synsets:] {
+			offset, err := strconv.Atoi(o)
+			if err != nil {
+				return idx, fmt.Errorf("invalid index line in %s: %q", p, line)
+			}
+			idx[fields[0]] = append(idx[fields[0]], offset)
+		}
+	}
+
+	return idx, scanner.Err()
+}
+
+// Reads the base forms of irregular inflections from an exception file, which may be absent
+func readWordNetExceptions(p string) (map[string][]string, error) {
+	exc := make(map[string][]string)
+
+	f, err := os.Open(p)
+	if errors.Is(err, os.ErrNotExist) {
+		return exc, nil
+	}
+	if err != nil {
+		return exc, err
+	}
+	defer f.Close()
+
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		exc[fields[0]] = append(exc[fields[0]], fields[1:]...)
+	}
+
+	return exc, scanner.Err()
+}
+
+// Finds the base forms of a word found in the index for a part of speech
+func (w *WordNet) morphy(s string, pos string) []string {
+	var (
+		lemma = strings.ReplaceAll(strings.ToLower(strings.TrimSpace(s)), " ", "_")
+		out   []string
+	)
+
+	add := func(l string) {
+		if _, ok := w.index[pos][l]; ok && !slices.Contains(out, l) {
+			out = append(out, l)
+		}
+	}
+
+	add(lemma)
+	for _, base := range w.exceptions[pos][lemma] {
+		add(base)
+	}
+	for _, rule := range wordnetDetachment[pos] {
+		if strings.HasSuffix(lemma, rule[0]) {
+			add(strings.TrimSuffix(lemma, rule[0]) + rule[1])
+		}
+	}
+
+	return out
+}
+
+// Looks up the synsets of a word for a part of speech, keeping at most senses synsets per base form
+// senses < 1 keeps all synsets
+func (w *WordNet) Synsets(s string, pos string, senses int) []Synset {
+	var out []Synset
+
+	for _, lemma := range w.morphy(s, pos) {
+		offsets := w.index[pos][lemma]
+		if senses > 0 && len(offsets) > senses {
+			offsets = offsets[:senses]
+		}
+		for _, o := range offsets {
+			if !slices.Contains(out, Synset{pos, o}) {
+				out = append(out, Synset{pos, o})
+			}
+		}
+	}
+
+	return out
+}
+
+// Reads the data file line for a synset
+func (w *WordNet) line(s Synset) []string {
+	data := w.data[s.pos]
+	if s.offset < 0 || s.offset >= len(data) {
+		return []string{}
+	}
+	end := bytes.IndexByte(data[s.offset:], '\n')
+	if end == -1 {
+		end = len(data) - s.offset
+	}
+	line := string(data[s.offset : s.offset+end])
+	line, _, _ = strings.Cut(line, "|")
+
+	return strings.Fields(line)
+}
+
+// Lists the words in a synset, with underscores replaced by spaces and adjective markers removed
+func (w *WordNet) Lemmas(s Synset) []string {
+	var (
+		fields = w.line(s)
+		out    []string
+	)
+
+	if len(fields) < 4 {
+		return out
+	}
+	count, err := strconv.ParseInt(fields[3], 16, 64)
+	if err != nil {
+		return out
+	}
+	for i := range int(count) {
+		if 4+2*i >= len(fields) {
+			break
+		}
+		word := fields[4+2*i]
+		if j := strings.IndexByte(word, '('); j > 0 {
+			word = word[:j]
+		}
+		out = append(out, strings.ReplaceAll(word, "_", " "))
+	}
+
+	return out
+}
+
+// Lists the synsets a synset points to with any of the given pointer symbols
+func (w *WordNet) Related(s Synset, symbols []string) []Synset {
+	var (
+		fields = w.line(s)
+		out    []Synset
+	)
+
+	if len(fields) < 4 {
+		return out
+	}
+	count, err := strconv.ParseInt(fields[3], 16, 64)
+	if err != nil {
+		return out
+	}
+	i := 4 + 2*int(count)
+	if i >= len(fields) {
+		return out
+	}
+	pointers, err := strconv.Atoi(fields[i])
+	if err != nil {
+		return out
+	}
+	for j := range pointers {
+		p := i + 1 + 4*j
+		if p+3 >= len(fields) || !slices.Contains(symbols, fields[p]) {
+			continue
+		}
+		offset, err := strconv.Atoi(fields[p+1])
+		if err != nil {
+			continue
+		}
+		pos := fields[p+2]
+		// satellite adjectives are stored with the other adjectives
+		if pos == "s" {
+			pos = "a"
+		}
+		out = append(out, Synset{pos, offset})
+	}
+
+	return out
+}
+
+// Collects synonyms of a word from its first senses synsets and from synsets up to depth hypernym or similarity links away
+func (w *WordNet) Synonyms(s string, pos string, senses int, depth int) []string {
+	var (
+		frontier = w.Synsets(s, pos, senses)
+		seen     = slices.Clone(frontier)
+		out      []string
+	)
+
+	for d := 0; len(frontier) > 0; d++ {
+		var next []Synset
+		for _, syn := range frontier {
+			out = append(out, w.Lemmas(syn)...)
+			if d >= depth {
+				continue
+			}
+			for _, rel := range w.Related(syn, wordnetRelations) {
+				if !slices.Contains(seen, rel) {
+					seen = append(seen, rel)
+					next = append(next, rel)
+				}
+			}
+		}
+		frontier = next
+	}
+
+	word := strings.ToLower(s)
+	out = slices.DeleteFunc(out, func(l string) bool { return strings.ToLower(l) == word })
+	slices.Sort(out)
+
+	return slices.Compact(out)
+}
+
+// Maps a Penn Treebank or Universal Dependencies POS tag to a WordNet part of speech, returns "" for function words
+func wordnetPOS(tag string) string {
+	switch {
+	case tag == "NOUN", tag == "PROPN", strings.HasPrefix(tag, "NN"):
+		return "n"
+	case tag == "VERB", strings.HasPrefix(tag, "VB"):
+		return "v"
+	case tag == "ADJ", strings.HasPrefix(tag, "JJ"):
+		return "a"
+	case tag == "ADV", strings.HasPrefix(tag, "RB"):
+		return "r"
+	default:
+		return ""
+	}
+}
+
+// Compares expressions token by token, where content words match if they share a WordNet synset and all other tokens must match literally
+func WordNetEqual(w *WordNet, t SyntacticTagger, senses int, l *log.Logger) EqualityFunction {
+	shared := func(s1, s2 string, pos string) bool {
+		syn2 := w.Synsets(s2, pos, senses)
+		for _, syn := range w.Synsets(s1, pos, senses) {
+			if slices.Contains(syn2, syn) {
+				return true
+			}
+		}
+		return false
+	}
+
+	return func(e1, e2 []string) bool {
+		tags1, tokens1 := t.POS(strings.Join(e1, " "))
+		tags2, tokens2 := t.POS(strings.Join(e2, " "))
+		if len(tokens1) != len(tokens2) {
+			return false
+		}
+		for i := range tokens1 {
+			if strings.EqualFold(tokens1[i], tokens2[i]) {
+				continue
+			}
+			pos1, pos2 := wordnetPOS(tags1[i]), wordnetPOS(tags2[i])
+			if pos1 == "" || pos1 != pos2 || !shared(tokens1[i], tokens2[i], pos1) {
+				return false
+			}
+		}
+		l.Printf("equality function %s matched %v and %v\n", "WordNetEqual", e1, e2)
+		return true
+	}
+}
+
+// Builds synonyms from WordNet for corpus words listed in targets, or whose part of speech (noun, verb, adj, adv) is listed in targets
+// texts are tagged with n workers, and only words with at least one synonym are kept
+func WordNetSynonyms(w *WordNet, t []Text, tag SyntacticTagger, targets []string, senses int, depth int, n int) Synonyms {
+	var (
+		syn     = Synonyms{}
+		classes = make(map[string]bool)
+		words   = make(map[string]bool)
+		found   = make(map[[2]string]bool)
+	)
+
+	for _, target := range targets {
+		pos, ok := wordnetClasses[target]
+		if ok {
+			classes[pos] = true
+			continue
+		}
+		words[strings.ToLower(target)] = true
+	}
+
+	tagged := ParallelMap(t, n, func(t Text) tagResult {
+		tags, tokens := tag.POS(t.text)
+		return tagResult{tags, tokens}
+	})
+	for i := range tagged {
+		for j := range tagged[i].tokens {
+			token := strings.ToLower(tagged[i].tokens[j])
+			pos := wordnetPOS(tagged[i].tags[j])
+			switch {
+			case words[token] && pos == "":
+				for _, p := range slices.Sorted(maps.Keys(wordnetFiles)) {
+					found[[2]string{token, p}] = true
+				}
+			case words[token], classes[pos]:
+				found[[2]string{token, pos}] = true
+			}
+		}
+	}
+
+	for k := range found {
+		syn[k[0]] = append(syn[k[0]], w.Synonyms(k[0], k[1], senses, depth)...)
+	}
+	// words already listed as a synonym of an earlier word are dropped, so each synonym set is factored once
+	covered := make(map[string]bool)
+	for _, k := range slices.Sorted(maps.Keys(syn)) {
+		if len(syn[k]) == 0 || covered[k] {
+			delete(syn, k)
+			continue
+		}
+		slices.Sort(syn[k])
+		syn[k] = slices.Compact(syn[k])
+		for _, v := range syn[k] {
+			covered[v] = true
+		}
+	}
+
+	return syn
+}
@@ -0,0 +1,173 @@
+// -*- coding: utf-8 -*-
+
+// Created on Mon Oct 19 01:56:57 PM EDT 2026
+// author: Ryan Hildebrandt, github.com/ryancahildebrandt
+
+package main
+
+import (
+	"testing"
+
+	"github.com/jdkato/prose/tag"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLoadWordNet(t *testing.T) {
+	type args struct {
+		dir string
+	}
+	tests := []struct {
+		args      args
+		assertion assert.ErrorAssertionFunc
+	}{
+		{args: args{dir: ""}, assertion: assert.Error},
+		{args: args{dir: "./data/tests"}, assertion: assert.Error},
+		{args: args{dir: "./data/tests/wordnet"}, assertion: assert.NoError},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			w, err := LoadWordNet(tt.args.dir)
+			tt.assertion(t, err)
+			if err == nil {
+				assert.NoError(t, w.Close())
+			}
+		})
+	}
+}
+
+func TestWordNet_morphy(t *testing.T) {
+	type args struct {
+		s   string
+		pos string
+	}
+	tests := []struct {
+		args args
+		want []string
+	}{
+		{args: args{s: "", pos: "v"}, want: nil},
+		{args: args{s: "cancel", pos: "v"}, want: []string{"cancel"}},
+		{args: args{s: "Cancelled", pos: "v"}, want: nil},
+		{args: args{s: "sending", pos: "v"}, want: []string{"send"}},
+		{args: args{s: "sent", pos: "v"}, want: []string{"send"}},
+		{args: args{s: "call off", pos: "v"}, want: []string{"call_off"}},
+		{args: args{s: "invoices", pos: "n"}, want: []string{"invoice"}},
+		{args: args{s: "invoices", pos: "v"}, want: nil},
+	}
+	w, err := LoadWordNet("./data/tests/wordnet")
+	assert.NoError(t, err)
+	defer w.Close()
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, w.morphy(tt.args.s, tt.args.pos))
+		})
+	}
+}
+
+func TestWordNet_Synonyms(t *testing.T) {
+	type args struct {
+		s      string
+		pos    string
+		senses int
+		depth  int
+	}
+	tests := []struct {
+		args args
+		want []string
+	}{
+		{args: args{s: "invoice", pos: "v", senses: 1, depth: 0}, want: nil},
+		{args: args{s: "cancel", pos: "v", senses: 1, depth: 0}, want: []string{"call off"}},
+		{args: args{s: "cancel", pos: "v", senses: 1, depth: 1}, want: []string{"call off", "end", "terminate"}},
+		{args: args{s: "sends", pos: "v", senses: 1, depth: 0}, want: []string{"direct", "send"}},
+		{args: args{s: "send", pos: "v", senses: 0, depth: 0}, want: []string{"direct", "mail", "post"}},
+		{args: args{s: "account", pos: "n", senses: 2, depth: 1}, want: []string{"bill", "history", "invoice"}},
+		{args: args{s: "quick", pos: "a", senses: 1, depth: 0}, want: []string{"speedy"}},
+		{args: args{s: "quick", pos: "a", senses: 1, depth: 3}, want: []string{"fast", "speedy"}},
+	}
+	w, err := LoadWordNet("./data/tests/wordnet")
+	assert.NoError(t, err)
+	defer w.Close()
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, w.Synonyms(tt.args.s, tt.args.pos, tt.args.senses, tt.args.depth))
+		})
+	}
+}
+
+func Test_wordnetPOS(t *testing.T) {
+	type args struct {
+		tag string
+	}
+	tests := []struct {
+		args args
+		want string
+	}{
+		{args: args{tag: ""}, want: ""},
+		{args: args{tag: "DT"}, want: ""},
+		{args: args{tag: "NNS"}, want: "n"},
+		{args: args{tag: "PROPN"}, want: "n"},
+		{args: args{tag: "VBD"}, want: "v"},
+		{args: args{tag: "JJR"}, want: "a"},
+		{args: args{tag: "ADV"}, want: "r"},
+	}
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, wordnetPOS(tt.args.tag))
+		})
+	}
+}
+
+func TestWordNetEqual(t *testing.T) {
+	type args struct {
+		e1 []string
+		e2 []string
+	}
+	tests := []struct {
+		args args
+		want bool
+	}{
+		{args: args{e1: []string{"the invoice"}, e2: []string{"the invoice"}}, want: true},
+		{args: args{e1: []string{"the invoice"}, e2: []string{"the bill"}}, want: true},
+		{args: args{e1: []string{"the invoice"}, e2: []string{"a bill"}}, want: false},
+		{args: args{e1: []string{"the invoice"}, e2: []string{"the account"}}, want: false},
+		{args: args{e1: []string{"the invoice"}, e2: []string{"the bill please"}}, want: false},
+		{args: args{e1: []string{"I want to cancel"}, e2: []string{"I desire to terminate"}}, want: false},
+	}
+	w, err := LoadWordNet("./data/tests/wordnet")
+	assert.NoError(t, err)
+	defer w.Close()
+	tagger := NewSyntacticTagger(tag.NewPerceptronTagger(), NewWordTokenizer())
+	eq := WordNetEqual(w, tagger, 0, nilLogger)
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, eq(tt.args.e1, tt.args.e2))
+		})
+	}
+}
+
+func TestWordNetSynonyms(t *testing.T) {
+	type args struct {
+		t       []Text
+		targets []string
+	}
+	tests := []struct {
+		args args
+		want Synonyms
+	}{
+		{args: args{t: []Text{}, targets: []string{"noun"}}, want: Synonyms{}},
+		{args: args{t: []Text{{text: "send the invoice"}}, targets: []string{}}, want: Synonyms{}},
+		{args: args{t: []Text{{text: "send the invoice"}}, targets: []string{"noun"}}, want: Synonyms{"invoice": {"bill"}}},
+		{args: args{t: []Text{{text: "send the invoice"}, {text: "cancel the order"}}, targets: []string{"cancel"}}, want: Synonyms{"cancel": {"call off"}}},
+		{args: args{t: []Text{{text: "send the invoice"}}, targets: []string{"noun", "send"}}, want: Synonyms{"invoice": {"bill"}, "send": {"direct"}}},
+		{args: args{t: []Text{{text: "send the invoice"}}, targets: []string{"adj"}}, want: Synonyms{}},
+		{args: args{t: []Text{{text: "send the invoice"}, {text: "pay the bill"}}, targets: []string{"noun"}}, want: Synonyms{"bill": {"invoice"}}},
+	}
+	w, err := LoadWordNet("./data/tests/wordnet")
+	assert.NoError(t, err)
+	defer w.Close()
+	tagger := NewSyntacticTagger(tag.NewPerceptronTagger(), NewWordTokenizer())
+	for _, tt := range tests {
+		t.Run("", func(t *testing.T) {
+			assert.Equal(t, tt.want, WordNetSynonyms(w, tt.args.t, tagger, tt.args.targets, 1, 0, 1))
+		})
+	}
+}